@@ -2,14 +2,27 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
 	"xiaohongshu/app/infra/app_context"
+	"xiaohongshu/app/infra/config"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/eventlog"
+	"xiaohongshu/app/infra/logging"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
 	appContext *app_context.AppContext
 	ctx        context.Context
+	logMu      sync.Mutex
+	stopLogs   func() // 停止推送日志，未推送时为 nil
 }
 
 // NewApp creates a new App application struct
@@ -21,9 +34,211 @@ func NewApp(appContext *app_context.AppContext) *App {
 
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	bus := a.appContext.Container().Bus
+	// 选择器覆盖文件重新加载后通知前端
+	bus.Subscribe(eventbus.TopicSelectorsReloaded, func(info interface{}) {
+		runtime.EventsEmit(ctx, "selectors-reloaded", info)
+	})
+	bus.Subscribe(eventbus.TopicConfigChanged, func(change interface{}) {
+		runtime.EventsEmit(ctx, "config-changed", change)
+	})
 }
 
 // Greet returns a greeting for the given name
 func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's show time!", a.appContext.GetRootPath())
 }
+
+// QueryEvents 查询事件日志，since/until 为毫秒时间戳，传 0 表示不限制
+func (a *App) QueryEvents(topic string, account string, since int64, until int64, limit int) ([]map[string]interface{}, error) {
+	store := a.appContext.Container().EventLog
+	if store == nil {
+		return nil, fmt.Errorf("event log is not initialized")
+	}
+	query := eventlog.Query{Account: account, Limit: limit}
+	if topic != "" {
+		query.Topics = []string{topic}
+	}
+	if since > 0 {
+		query.Since = time.UnixMilli(since)
+	}
+	if until > 0 {
+		query.Until = time.UnixMilli(until)
+	}
+	events, err := store.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		items = append(items, map[string]interface{}{
+			"id":        event.ID,
+			"topic":     event.Topic,
+			"account":   event.Account,
+			"payload":   event.Payload,
+			"createdAt": event.CreatedAt.UnixMilli(),
+		})
+	}
+	return items, nil
+}
+
+// GetLogs 返回最近 limit 条不低于 level 的日志，level 为空时返回全部级别
+func (a *App) GetLogs(limit int, level string) ([]map[string]interface{}, error) {
+	minLevel, err := parseLogLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	entries := a.appContext.Container().Logger.Logs().Recent(limit, minLevel)
+	items := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, entry)
+	}
+	return items, nil
+}
+
+// StreamLogs 开始通过 log-entry 事件推送不低于 level 的新日志，重复调用时替换之前的推送
+func (a *App) StreamLogs(level string) error {
+	minLevel, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	a.logMu.Lock()
+	defer a.logMu.Unlock()
+	if a.stopLogs != nil {
+		a.stopLogs()
+	}
+	a.stopLogs = a.appContext.Container().Logger.Logs().Subscribe(func(entry logging.Entry) {
+		if entry.Level() >= minLevel {
+			runtime.EventsEmit(a.ctx, "log-entry", map[string]interface{}(entry))
+		}
+	})
+	return nil
+}
+
+// StopLogStream 停止推送日志
+func (a *App) StopLogStream() {
+	a.logMu.Lock()
+	defer a.logMu.Unlock()
+	if a.stopLogs != nil {
+		a.stopLogs()
+		a.stopLogs = nil
+	}
+}
+
+// GetLogLevel 返回当前日志级别
+func (a *App) GetLogLevel() string {
+	return strings.ToLower(a.appContext.Container().Logger.Level().String())
+}
+
+// SetLogLevel 修改日志级别：debug、info、warn、error
+func (a *App) SetLogLevel(level string) error {
+	l, err := logging.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	a.appContext.Container().Logger.SetLevel(l)
+	return nil
+}
+
+// parseLogLevel 为空时返回最低级别
+func parseLogLevel(level string) (slog.Level, error) {
+	if level == "" {
+		return slog.LevelDebug, nil
+	}
+	return logging.ParseLevel(level)
+}
+
+// GetConfig 返回配置文件中的值、实际生效的值以及各配置项的说明
+func (a *App) GetConfig() (map[string]interface{}, error) {
+	store := a.appContext.Container().Config
+	if store == nil {
+		return nil, fmt.Errorf("config is not initialized")
+	}
+	return map[string]interface{}{
+		"path":       store.Path(),
+		"config":     store.File(),
+		"effective":  store.Get(),
+		"fields":     config.Fields(),
+		"overridden": store.Overridden(),
+	}, nil
+}
+
+// SaveConfig 校验并保存配置，返回修改的配置项以及其中需要重启才能生效的配置项
+func (a *App) SaveConfig(values map[string]interface{}) (map[string]interface{}, error) {
+	store := a.appContext.Container().Config
+	if store == nil {
+		return nil, fmt.Errorf("config is not initialized")
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	// 在当前配置上解析，前端未提交的配置项保持不变
+	next := store.File()
+	if err := json.Unmarshal(data, &next); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	change, err := store.Update(next)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"changed":         change.Changed,
+		"restartRequired": change.RestartRequired,
+	}, nil
+}
+
+// GetStartupStatus 返回各启动阶段的进度和启动错误，启动页面加载时用于补齐错过的事件
+func (a *App) GetStartupStatus() map[string]interface{} {
+	status := a.appContext.StartupStatus()
+	return map[string]interface{}{
+		"stages":  status.Stages,
+		"errors":  status.Errors,
+		"running": status.Running,
+		"done":    status.Done,
+		"ready":   status.Ready,
+	}
+}
+
+// RetryInitialization 重新执行出错的启动阶段，进度和结果通过 startup-progress 等事件推送
+func (a *App) RetryInitialization() error {
+	return a.appContext.Retry()
+}
+
+// GetSelectorMetrics 返回各选择器的累计命中统计
+func (a *App) GetSelectorMetrics() []map[string]interface{} {
+	metrics := a.appContext.Container().Metrics
+	stats := metrics.Stats()
+	items := make([]map[string]interface{}, 0, len(stats))
+	for _, stat := range stats {
+		item := map[string]interface{}{
+			"key":      stat.Key,
+			"pageType": stat.PageType,
+			"hits":     stat.Hits,
+			"misses":   stat.Misses,
+			"hitRate":  stat.HitRate(),
+			"optional": metrics.Registry().Optional(stat.Key),
+			"lastMiss": int64(0),
+		}
+		if !stat.LastMiss.IsZero() {
+			item["lastMiss"] = stat.LastMiss.UnixMilli()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// GetSelectors 返回当前生效的选择器及其版本
+func (a *App) GetSelectors() map[string]interface{} {
+	registry := a.appContext.Container().Selectors
+	info := registry.Info()
+	return map[string]interface{}{
+		"version":   info.Version,
+		"builtin":   info.Builtin,
+		"source":    info.Source,
+		"checksum":  info.Checksum,
+		"loadedAt":  info.LoadedAt.UnixMilli(),
+		"selectors": registry.All(),
+	}
+}
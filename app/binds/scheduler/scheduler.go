@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"xiaohongshu/app/infra/app_context"
+	"xiaohongshu/app/services/account"
+	"xiaohongshu/app/services/scheduler"
+)
+
+// Scheduler 定时任务管理
+type Scheduler struct {
+	appContext *app_context.AppContext
+	ctx        context.Context
+}
+
+// NewScheduler creates a new Scheduler application struct
+func NewScheduler(appContext *app_context.AppContext) *Scheduler {
+	return &Scheduler{
+		appContext: appContext,
+	}
+}
+
+// Startup 初始化上下文
+func (s *Scheduler) Startup(ctx context.Context) {
+	s.ctx = ctx
+}
+
+func (s *Scheduler) scheduler() (*scheduler.Scheduler, error) {
+	sch := s.appContext.Container().Scheduler
+	if sch == nil {
+		return nil, fmt.Errorf("scheduler is not initialized")
+	}
+	return sch, nil
+}
+
+// ListJobs 列出所有任务
+func (s *Scheduler) ListJobs() ([]map[string]interface{}, error) {
+	sch, err := s.scheduler()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := sch.ListJobs()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, jobToMap(job))
+	}
+	return items, nil
+}
+
+// CreateJob 创建任务，spec 为 cron 表达式，params 为对应任务类型的参数
+func (s *Scheduler) CreateJob(name string, kind string, account string, spec string, params map[string]interface{}, maxRetries int) (map[string]interface{}, error) {
+	sch, err := s.scheduler()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid params: %v", err)
+	}
+	job, err := sch.CreateJob(scheduler.Job{
+		Name:       name,
+		Kind:       kind,
+		Account:    account,
+		Spec:       spec,
+		Params:     string(data),
+		MaxRetries: maxRetries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobToMap(job), nil
+}
+
+// DeleteJob 删除任务
+func (s *Scheduler) DeleteJob(id uint) error {
+	sch, err := s.scheduler()
+	if err != nil {
+		return err
+	}
+	return sch.DeleteJob(id)
+}
+
+// PauseJob 暂停任务
+func (s *Scheduler) PauseJob(id uint) error {
+	sch, err := s.scheduler()
+	if err != nil {
+		return err
+	}
+	return sch.Pause(id)
+}
+
+// ResumeJob 恢复任务
+func (s *Scheduler) ResumeJob(id uint) error {
+	sch, err := s.scheduler()
+	if err != nil {
+		return err
+	}
+	return sch.Resume(id)
+}
+
+// ListChallengedAccounts 列出处于风控状态的账号，这些账号的任务已暂停
+func (s *Scheduler) ListChallengedAccounts() ([]map[string]interface{}, error) {
+	store := s.appContext.Container().Accounts
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	accounts, err := store.ListByState(account.StateChallenged)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(accounts))
+	for _, a := range accounts {
+		items = append(items, map[string]interface{}{
+			"id":          a.ID,
+			"nickname":    a.Nickname,
+			"state":       a.State,
+			"stateReason": a.StateReason,
+			"updatedAt":   a.UpdatedAt.UnixMilli(),
+		})
+	}
+	return items, nil
+}
+
+// ResolveAccount 确认账号的风控已人工处理，恢复账号的任务
+func (s *Scheduler) ResolveAccount(id string) error {
+	return s.appContext.Container().ResolveAccount(id)
+}
+
+// RunJob 立即执行一次任务
+func (s *Scheduler) RunJob(id uint) error {
+	sch, err := s.scheduler()
+	if err != nil {
+		return err
+	}
+	return sch.RunNow(id)
+}
+
+// GetJobHistory 获取任务最近的执行记录
+func (s *Scheduler) GetJobHistory(id uint, limit int) ([]map[string]interface{}, error) {
+	sch, err := s.scheduler()
+	if err != nil {
+		return nil, err
+	}
+	runs, err := sch.History(id, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, map[string]interface{}{
+			"id":         run.ID,
+			"jobId":      run.JobID,
+			"account":    run.Account,
+			"attempt":    run.Attempt,
+			"status":     run.Status,
+			"error":      run.Error,
+			"result":     run.Result,
+			"startedAt":  run.StartedAt.UnixMilli(),
+			"finishedAt": unixMilli(run.FinishedAt),
+		})
+	}
+	return items, nil
+}
+
+func jobToMap(job scheduler.Job) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         job.ID,
+		"name":       job.Name,
+		"kind":       job.Kind,
+		"account":    job.Account,
+		"spec":       job.Spec,
+		"params":     job.Params,
+		"paused":     job.Paused,
+		"maxRetries": job.MaxRetries,
+		"lastRunAt":  unixMilli(job.LastRunAt),
+		"nextRunAt":  unixMilli(job.NextRunAt),
+	}
+}
+
+// unixMilli 转换为毫秒时间戳，nil 返回 nil
+func unixMilli(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
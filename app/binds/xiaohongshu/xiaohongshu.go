@@ -4,17 +4,30 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
 	"time"
 	"xiaohongshu/app/infra/app_context"
 	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
 	"xiaohongshu/app/services"
+	"xiaohongshu/app/services/export"
+	"xiaohongshu/app/services/imagehash"
+	"xiaohongshu/app/services/keyframe"
+	"xiaohongshu/app/services/ocr"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/explore"
+	"xiaohongshu/app/services/xiaohongshu/har"
 	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
 
 	"github.com/playwright-community/playwright-go"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+var logger = logging.Component("bind")
+
 // Xiaohongshu struct
 type Xiaohongshu struct {
 	appContext  *app_context.AppContext
@@ -23,14 +36,21 @@ type Xiaohongshu struct {
 	page        playwright.Page
 	explorePage *explore.Explore
 	scriptPath  embed.FS
+	harFlags    har.Options   // 启动参数指定的 HAR 配置，非空的项覆盖配置文件
+	stopFrames  func()        // 取消上一篇笔记的视频帧订阅
+	recognizer  *ocr.Pipeline // 图片文字识别，未启用时为 nil
 }
 
 // NewXiaohongshu creates a new Xiaohongshu application struct
-func NewXiaohongshu(appContext *app_context.AppContext, scriptPath embed.FS) *Xiaohongshu {
-	return &Xiaohongshu{
+func NewXiaohongshu(appContext *app_context.AppContext, scriptPath embed.FS, harFlags har.Options) *Xiaohongshu {
+	x := &Xiaohongshu{
 		appContext: appContext,
 		scriptPath: scriptPath,
+		harFlags:   harFlags,
 	}
+	// 浏览器和数据库就绪后启动服务，失败时随初始化一起重试
+	appContext.AddStartHook(x.start)
+	return x
 }
 
 // Startup 初始化上下文
@@ -38,37 +58,236 @@ func (x *Xiaohongshu) Startup(ctx context.Context) {
 	x.ctx = ctx
 }
 
-// OnDomReady 当DOM准备就绪时调用
-func (x *Xiaohongshu) OnDomReady(ctx context.Context) {
+// harOptions 配置文件中的 HAR 配置，启动参数指定的项优先
+func (x *Xiaohongshu) harOptions() har.Options {
+	settings := x.appContext.Container().Settings().Har
+	options := har.Options{Mode: settings.Mode, File: settings.File}
+	if x.harFlags.Mode != "" {
+		options.Mode = x.harFlags.Mode
+	}
+	if x.harFlags.File != "" {
+		options.File = x.harFlags.File
+	}
+	return options
+}
+
+// start 创建并启动服务，订阅需要转发给前端的事件，已启动时什么也不做
+func (x *Xiaohongshu) start(ctx context.Context) error {
 	if x.service != nil {
-		return
+		return nil
 	}
-	var err error
-	x.service, err = services.NewXiaohongshuService(x.appContext.GetPlaywrightBrowser(), x.scriptPath)
+	deps := x.appContext.Container()
+	service, err := deps.NewService(x.scriptPath)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to create service: %w", err)
 	}
-	err = x.service.Start()
-	if err != nil {
-		return
+	if err := service.SetHarOptions(x.harOptions()); err != nil {
+		logger.Warn("invalid har options", "error", err)
 	}
+	if err := service.Start(); err != nil {
+		// 关闭已打开的会话，重试时重新创建
+		_ = service.Shutdown()
+		return fmt.Errorf("failed to start service: %w", err)
+	}
+	x.service = service
+	// 之后注册的图片哈希、归档、索引和文字识别在退出时先于服务停止
+	x.appContext.RegisterShutdown("xiaohongshu", func(context.Context) error {
+		return service.Shutdown()
+	})
 	x.page = x.service.GetPage()
-	x.explorePage = explore.NewExplore(x.page)
+	x.explorePage = explore.NewExplore(service.Site(), driver.NewPage(x.page))
+	// 会话重新打开后切换到新页面
+	deps.Bus.Subscribe(eventbus.TopicSessionRestarted, func(page interface{}) {
+		if p, ok := page.(playwright.Page); ok {
+			x.page = p
+			x.explorePage = explore.NewExplore(service.Site(), driver.NewPage(p))
+		}
+	})
 	// 监听用户登录事件
-	eventbus.GlobalEventBus.Subscribe("user-logged-in", func(userInfo interface{}) {
+	deps.Bus.Subscribe(eventbus.TopicUserLoggedIn, func(userInfo interface{}) {
 		runtime.EventsEmit(ctx, "user-logged-in", userInfo)
 	})
+	deps.Bus.Subscribe(eventbus.TopicUserLoggedOut, func(account interface{}) {
+		runtime.EventsEmit(ctx, "user-logged-out", account)
+	})
+	// 扫码登录状态：waiting、scanned、confirmed、expired、refreshed、failed
+	deps.Bus.Subscribe(eventbus.TopicLoginStatus, func(status interface{}) {
+		runtime.EventsEmit(ctx, "login-status", status)
+	})
+	// 风控提示，浏览器已被切到前台等待人工处理
+	deps.Bus.Subscribe(eventbus.TopicRiskChallenged, func(challenge interface{}) {
+		runtime.EventsEmit(ctx, "risk-challenged", challenge)
+	})
+	deps.Bus.Subscribe(eventbus.TopicRiskResolved, func(challenge interface{}) {
+		runtime.EventsEmit(ctx, "risk-resolved", challenge)
+	})
+	// 笔记详情打开并提取完成、笔记关闭，包括在浏览器中手动打开的笔记
+	deps.Bus.Subscribe(eventbus.TopicNoteOpened, func(detail interface{}) {
+		runtime.EventsEmit(ctx, "note-opened", detail)
+	})
+	deps.Bus.Subscribe(eventbus.TopicNoteClosed, func(detail interface{}) {
+		runtime.EventsEmit(ctx, "note-closed", detail)
+	})
+	// 选择器失效告警，附带容器的 DOM 快照
+	deps.Bus.Subscribe(eventbus.TopicSelectorsAlert, func(alert interface{}) {
+		runtime.EventsEmit(ctx, "selectors-alert", alert)
+	})
+	// 打开笔记后在后台计算图片的感知哈希
+	if store := deps.Images; store != nil {
+		images := imagehash.NewPipeline(store, func(url string) (image.Image, error) {
+			return scripts.LoadImage(service.GetPage(), url)
+		})
+		if err := images.Start(deps.Bus); err != nil {
+			logger.Error("failed to start image hash pipeline", "error", err)
+		} else {
+			x.appContext.RegisterShutdown("imagehash", func(context.Context) error {
+				images.Stop()
+				return nil
+			})
+		}
+	}
+	// 保存浏览和抓取到的数据，用于导出
+	if store := deps.Archive; store != nil {
+		if err := store.Start(deps.Bus); err != nil {
+			logger.Error("failed to start archive", "error", err)
+		} else {
+			x.appContext.RegisterShutdown("archive", func(context.Context) error {
+				store.Stop()
+				return nil
+			})
+		}
+	}
+	// 打开笔记后把标题和正文加入全文索引
+	if index := deps.Search; index != nil {
+		if err := index.Start(deps.Bus); err != nil {
+			logger.Error("failed to start search index", "error", err)
+		} else {
+			x.appContext.RegisterShutdown("search", func(context.Context) error {
+				index.Stop()
+				return nil
+			})
+		}
+	}
+	// 打开笔记后在后台识别图片中的文字，识别进度通过 ocr-progress 事件推送
+	if settings := deps.Settings().Media.OCR; settings.Enabled && deps.Texts != nil {
+		provider := ocr.NewTesseract(settings.Tesseract, settings.Languages)
+		if err := provider.Available(); err != nil {
+			logger.Warn("ocr is disabled", "error", err)
+		} else {
+			recognizer := ocr.NewPipeline(deps.Texts, provider, func(url string) (image.Image, error) {
+				return scripts.LoadImage(service.GetPage(), url)
+			})
+			deps.Bus.Subscribe(eventbus.TopicOCRProgress, func(progress interface{}) {
+				runtime.EventsEmit(ctx, "ocr-progress", progress)
+			})
+			if err := recognizer.Start(deps.Bus); err != nil {
+				logger.Error("failed to start ocr pipeline", "error", err)
+			} else {
+				x.recognizer = recognizer
+				x.appContext.RegisterShutdown("ocr", func(context.Context) error {
+					recognizer.Stop()
+					return nil
+				})
+			}
+		}
+	}
+	// 注册并启动定时任务
+	if sch := deps.Scheduler; sch != nil {
+		x.service.RegisterJobs(sch)
+		deps.Bus.Subscribe(eventbus.TopicJobFinished, func(result interface{}) {
+			runtime.EventsEmit(ctx, "job-finished", result)
+		})
+		if err := sch.Start(); err != nil {
+			logger.Error("failed to start scheduler", "error", err)
+		}
+	}
+	return nil
+}
+
+// StartLogin 开始扫码登录，二维码和登录状态通过 login-status 事件推送
+func (x *Xiaohongshu) StartLogin() error {
+	if x.service == nil {
+		return fmt.Errorf("service is not initialized")
+	}
+	ctx := logging.WithCorrelation(x.appContext.Context())
+	go func() {
+		if err := x.service.Login(ctx); err != nil {
+			logger.WarnContext(ctx, "login failed", "error", err)
+		}
+	}()
+	return nil
+}
+
+// CancelLogin 取消扫码登录
+func (x *Xiaohongshu) CancelLogin() {
+	if x.service != nil {
+		x.service.CancelLogin()
+	}
+}
+
+// Logout 退出登录并清除保存的会话
+func (x *Xiaohongshu) Logout() error {
+	if x.service == nil {
+		return fmt.Errorf("service is not initialized")
+	}
+	return x.service.Logout()
+}
+
+// ExportHar 选择保存位置并导出当前会话的 HAR，用于提交问题，返回保存路径
+// 取消选择时返回空字符串
+func (x *Xiaohongshu) ExportHar() (string, error) {
+	if x.service == nil {
+		return "", fmt.Errorf("service is not initialized")
+	}
+	if !x.service.HarOptions().Recording() {
+		return "", fmt.Errorf("har recording is not enabled, start with -har=record")
+	}
+	dest, err := runtime.SaveFileDialog(x.ctx, runtime.SaveDialogOptions{
+		Title:           "导出 HAR",
+		DefaultFilename: "xiaohongshu-" + time.Now().Format("20060102-150405") + ".har",
+		Filters:         []runtime.FileFilter{{DisplayName: "HAR (*.har)", Pattern: "*.har"}},
+	})
+	if err != nil || dest == "" {
+		return "", err
+	}
+	if filepath.Ext(dest) == "" {
+		dest += ".har"
+	}
+	return dest, x.service.ExportHar(dest)
+}
+
+// CheckSelectors 打开推荐页、搜索页和笔记页检查选择器是否失效，keyword 为空时使用默认关键词
+// 有选择器失效时同时推送 selectors-alert 事件
+func (x *Xiaohongshu) CheckSelectors(keyword string) ([]map[string]interface{}, error) {
+	if x.service == nil {
+		return nil, fmt.Errorf("service is not initialized")
+	}
+	ctx := logging.WithCorrelation(x.appContext.Context())
+	logger.InfoContext(ctx, "check selectors", "keyword", keyword)
+	reports, err := x.service.CheckSelectors(ctx, keyword)
+	items := make([]map[string]interface{}, 0, len(reports))
+	for _, report := range reports {
+		items = append(items, map[string]interface{}{
+			"page":      report.Page,
+			"url":       report.URL,
+			"version":   report.Version,
+			"healthy":   report.Healthy(),
+			"resolved":  report.Resolved,
+			"missing":   report.Missing,
+			"snapshot":  report.Snapshot,
+			"error":     report.Error,
+			"checkedAt": report.CheckedAt.UnixMilli(),
+		})
+	}
+	return items, err
 }
 
 // NextPage 下一页功能
 func (x *Xiaohongshu) NextPage() error {
-	// TODO: 实现下一页逻辑
 	if x.page == nil {
 		return fmt.Errorf("page is not initialized")
 	}
-	x.page.Evaluate(`console.log("window.__MediaCaptureController",window.__MediaCaptureController);`)
-	x.page.Evaluate(`console.log("window.test",window.test );`)
-	return nil
+	return x.explorePage.NextPage(logging.WithCorrelation(x.appContext.Context()))
 }
 
 // Refresh 刷新功能
@@ -77,7 +296,7 @@ func (x *Xiaohongshu) Refresh() error {
 	if x.page == nil {
 		return fmt.Errorf("page is not initialized")
 	}
-	err := x.explorePage.RefreshPage()
+	err := x.explorePage.RefreshPage(logging.WithCorrelation(x.appContext.Context()))
 	if err != nil {
 		return err
 	}
@@ -98,14 +317,10 @@ func (x *Xiaohongshu) GetItems() ([]map[string]interface{}, error) {
 	// 将 FeedsInfo 转换为 map[string]interface{} 以便前端使用
 	items := make([]map[string]interface{}, 0, len(feeds))
 	for _, feed := range feeds {
-		item := map[string]interface{}{
-			"index":         feed.Index,
-			"title":         feed.Title.Text,
-			"coverImageUrl": feed.Cover.Text,
-			"username":      feed.User.Text,
-			"avatarUrl":     feed.Avatar.Text,
-		}
-		items = append(items, item)
+		items = append(items, feed.Map())
+	}
+	if len(items) > 0 {
+		x.appContext.Container().Bus.Publish(eventbus.TopicFeedsSeen, items)
 	}
 
 	return items, nil
@@ -113,35 +328,325 @@ func (x *Xiaohongshu) GetItems() ([]map[string]interface{}, error) {
 
 // OnItemClick 当列表项被点击时调用
 func (x *Xiaohongshu) OnItemClick(index int) error {
-
-	fmt.Println("OnItemClick", index)
+	ctx := logging.WithCorrelation(x.ctx)
+	logger.InfoContext(ctx, "open note", "index", index)
 	feed, err := x.explorePage.GetFeed(index)
 	if err != nil {
 		return err
 	}
 
-	err = feed.Element.Click()
-	if err != nil {
-		return err
-	}
-	time.Sleep(time.Second * 1)
-	newNote, err := note.NewNote(x.page, x.service.MediaCapture()).Show()
+	// 笔记生命周期在详情加载完成后提取，不需要固定等待
+	newNote, err := x.service.Notes().WaitOpened(ctx, feed.Element.Click)
 	if err != nil {
-		fmt.Println(fmt.Sprintf("failed to get new note: %v", err))
+		logger.WarnContext(ctx, "failed to read note", "error", err)
 		return err
 	}
-	fmt.Println(fmt.Sprintf("new note: %+v", newNote))
+	info := newNote.Map()
+	logger.InfoContext(ctx, "note opened", "type", info["type"], "title", info["title"])
 	video := newNote.Video()
 	if video == nil {
-		fmt.Println(fmt.Sprintf("New video is nil"))
 		return nil
 	}
 	err = video.Start()
 	if err != nil {
 		return err
 	}
-	video.ListenVideoFrame(func(frame note.VideoFrame) {
-		fmt.Println(fmt.Sprintf("New video frame %+v", frame))
+	if x.stopFrames != nil {
+		x.stopFrames()
+	}
+	x.stopFrames, err = video.ListenVideoFrame(func(frame note.VideoFrame) {
+		logger.DebugContext(ctx, "video frame", "capture", frame.Capture, "width", frame.Width, "height", frame.Height, "ts", frame.Ts)
 	})
-	return nil
+	return err
+}
+
+// GetCaptures 获取主页面上所有媒体采集的帧率、丢帧数和音频块数
+func (x *Xiaohongshu) GetCaptures() ([]map[string]interface{}, error) {
+	if x.service == nil {
+		return nil, fmt.Errorf("service is not initialized")
+	}
+	capture := x.service.MediaCapture()
+	if capture == nil {
+		return []map[string]interface{}{}, nil
+	}
+	statuses := capture.Captures()
+	items := make([]map[string]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		items = append(items, map[string]interface{}{
+			"id":            status.ID,
+			"running":       status.Running,
+			"startedAt":     status.StartedAt.UnixMilli(),
+			"activeMs":      status.Active.Milliseconds(),
+			"frames":        status.Frames,
+			"droppedFrames": status.DroppedFrames,
+			"audioChunks":   status.AudioChunks,
+			"fps":           status.FPS,
+			"error":         status.Error,
+		})
+	}
+	return items, nil
+}
+
+// ExtractKeyframes 提取当前打开的视频笔记的关键帧并保存缩略图，mode 为 interval 或 scene
+// interval 为取帧或采样间隔（秒），threshold 为场景模式的差异阈值，参数为 0 时使用默认值
+// sheetColumns 大于 0 时同时生成该列数的联系表
+func (x *Xiaohongshu) ExtractKeyframes(mode string, interval float64, threshold float64, maxFrames int, sheetColumns int) ([]map[string]interface{}, error) {
+	if x.service == nil {
+		return nil, fmt.Errorf("service is not initialized")
+	}
+	store := x.appContext.Container().Keyframes
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	info, detail, ok := x.service.Notes().CurrentNote()
+	if !ok || info.Video() == nil {
+		return nil, fmt.Errorf("no video note is opened")
+	}
+	ctx := logging.WithCorrelation(x.ctx)
+	logger.InfoContext(ctx, "extract keyframes", "note", detail.ID, "mode", mode)
+	frames, err := info.Video().ExtractKeyframes(note.KeyframeOptions{
+		Mode:      note.KeyframeMode(mode),
+		Interval:  time.Duration(interval * float64(time.Second)),
+		Threshold: threshold,
+		MaxFrames: maxFrames,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var sheet image.Image
+	if sheetColumns > 0 && len(frames) > 0 {
+		if sheet, err = note.ContactSheet(frames, sheetColumns, note.DefaultKeyframeWidth); err != nil {
+			return nil, err
+		}
+	}
+	thumbnails, err := store.Save(detail.ID, frames, sheet)
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoContext(ctx, "keyframes saved", "note", detail.ID, "count", len(frames))
+	return thumbnailsToMaps(thumbnails), nil
+}
+
+// GetKeyframes 获取笔记已保存的关键帧和联系表
+func (x *Xiaohongshu) GetKeyframes(noteID string) ([]map[string]interface{}, error) {
+	store := x.appContext.Container().Keyframes
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	thumbnails, err := store.List(noteID)
+	if err != nil {
+		return nil, err
+	}
+	return thumbnailsToMaps(thumbnails), nil
+}
+
+func thumbnailsToMaps(thumbnails []keyframe.Thumbnail) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(thumbnails))
+	for _, t := range thumbnails {
+		items = append(items, map[string]interface{}{
+			"id":        t.ID,
+			"noteId":    t.NoteID,
+			"kind":      t.Kind,
+			"index":     t.Index,
+			"timeMs":    t.TimeMs,
+			"score":     t.Score,
+			"path":      t.Path,
+			"width":     t.Width,
+			"height":    t.Height,
+			"createdAt": t.CreatedAt.UnixMilli(),
+		})
+	}
+	return items
+}
+
+// FindSimilarImages 在已收集的所有笔记中查找与 noteID 的图片近似重复的笔记，按最小距离排列
+// threshold 为 pHash 的最大汉明距离（0-64），小于 0 时使用默认值
+func (x *Xiaohongshu) FindSimilarImages(noteID string, threshold int) ([]map[string]interface{}, error) {
+	store := x.appContext.Container().Images
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	if threshold < 0 {
+		threshold = imagehash.DefaultThreshold
+	}
+	notes, err := store.FindSimilar(noteID, threshold)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(notes))
+	for _, n := range notes {
+		matches := make([]map[string]interface{}, 0, len(n.Matches))
+		for _, m := range n.Matches {
+			matches = append(matches, map[string]interface{}{
+				"url":       m.URL,
+				"sourceUrl": m.SourceURL,
+				"distance":  m.Distance,
+			})
+		}
+		items = append(items, map[string]interface{}{
+			"noteId":   n.NoteID,
+			"distance": n.Distance,
+			"matches":  matches,
+		})
+	}
+	return items, nil
+}
+
+// SearchNotes 在本地全文索引中搜索笔记的标题、正文和图片中的文字，按相关度排列
+// limit 不大于 0 时使用默认数量
+func (x *Xiaohongshu) SearchNotes(query string, limit int) ([]map[string]interface{}, error) {
+	index := x.appContext.Container().Search
+	if index == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	hits, err := index.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		items = append(items, map[string]interface{}{
+			"noteId": hit.NoteID,
+			"source": hit.Source,
+			"ref":    hit.Ref,
+			"text":   hit.Text,
+			"rank":   hit.Rank,
+		})
+	}
+	return items, nil
+}
+
+// GetImageTexts 获取笔记图片的文字识别结果，每行文字附带在图片中的位置
+func (x *Xiaohongshu) GetImageTexts(noteID string) ([]map[string]interface{}, error) {
+	store := x.appContext.Container().Texts
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	rows, err := store.ForNote(noteID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		blocks := make([]map[string]interface{}, 0, len(row.Blocks))
+		for _, b := range row.Blocks {
+			blocks = append(blocks, map[string]interface{}{
+				"text":       b.Text,
+				"confidence": b.Confidence,
+				"x":          b.Box.X,
+				"y":          b.Box.Y,
+				"width":      b.Box.Width,
+				"height":     b.Box.Height,
+			})
+		}
+		items = append(items, map[string]interface{}{
+			"noteId":    row.NoteID,
+			"url":       row.URL,
+			"provider":  row.Provider,
+			"text":      row.Text,
+			"blocks":    blocks,
+			"error":     row.Error,
+			"updatedAt": row.UpdatedAt.UnixMilli(),
+		})
+	}
+	return items, nil
+}
+
+// GetOCRProgress 当前的文字识别进度，未启用文字识别时返回错误
+func (x *Xiaohongshu) GetOCRProgress() (map[string]interface{}, error) {
+	if x.recognizer == nil {
+		return nil, fmt.Errorf("ocr is not enabled")
+	}
+	progress := x.recognizer.Progress()
+	return map[string]interface{}{
+		"done":   progress.Done,
+		"failed": progress.Failed,
+		"total":  progress.Total,
+	}, nil
+}
+
+// GetNoteAnalytics 分析笔记已抓取的评论：每天的评论量、评论最多的用户、点赞最多的评论、关键词、话题和情感分布
+func (x *Xiaohongshu) GetNoteAnalytics(noteID string) (map[string]interface{}, error) {
+	service := x.appContext.Container().Analytics
+	if service == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	report, err := service.NoteAnalytics(noteID)
+	if err != nil {
+		return nil, err
+	}
+	liked := make([]map[string]interface{}, 0, len(report.TopLiked))
+	for _, c := range report.TopLiked {
+		var at int64
+		if !c.Time.IsZero() {
+			at = c.Time.UnixMilli()
+		}
+		liked = append(liked, map[string]interface{}{
+			"id":        c.ID,
+			"parentId":  c.ParentID,
+			"author":    c.Author,
+			"content":   c.Content,
+			"likes":     c.Likes,
+			"sentiment": c.Sentiment,
+			"time":      at,
+		})
+	}
+	return map[string]interface{}{
+		"noteId":        report.NoteID,
+		"title":         report.Title,
+		"author":        report.Author,
+		"comments":      report.Comments,
+		"topLevel":      report.TopLevel,
+		"replies":       report.Replies,
+		"commenters":    report.Commenters,
+		"likes":         report.Likes,
+		"undated":       report.Undated,
+		"crawledAt":     report.CrawledAt.UnixMilli(),
+		"volume":        report.Volume,
+		"topCommenters": report.TopCommenters,
+		"topLiked":      liked,
+		"keywords":      report.Keywords,
+		"hashtags":      report.Hashtags,
+		"sentiment":     report.Sentiment,
+	}, nil
+}
+
+// Export 导出采集到的数据，返回导出的行数和保存路径
+// kind 为 feeds、notes、comments 或 authors，format 为 csv、jsonl 或 xlsx
+// filters 支持 since、until（毫秒时间戳或 2006-01-02）、keyword、author 和 channel（仅 feeds）
+// path 为空时弹出保存对话框，取消选择时返回 nil
+func (x *Xiaohongshu) Export(kind string, filters map[string]interface{}, format string, path string) (map[string]interface{}, error) {
+	store := x.appContext.Container().Archive
+	if store == nil {
+		return nil, fmt.Errorf("database is not initialized")
+	}
+	filter, err := export.ParseFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		ext := export.Extension(format)
+		path, err = runtime.SaveFileDialog(x.ctx, runtime.SaveDialogOptions{
+			Title:           "导出数据",
+			DefaultFilename: "xiaohongshu-" + kind + "-" + time.Now().Format("20060102-150405") + ext,
+			Filters:         []runtime.FileFilter{{DisplayName: strings.ToUpper(format) + " (*" + ext + ")", Pattern: "*" + ext}},
+		})
+		if err != nil || path == "" {
+			return nil, err
+		}
+		if filepath.Ext(path) == "" {
+			path += ext
+		}
+	}
+	logger.Info("export", "kind", kind, "format", format, "path", path)
+	result, err := export.Export(store, kind, filter, format, path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"kind":   result.Kind,
+		"format": result.Format,
+		"path":   result.Path,
+		"rows":   result.Rows,
+	}, nil
 }
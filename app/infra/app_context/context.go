@@ -2,22 +2,40 @@ package app_context
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"io"
 	"path"
+	"path/filepath"
 	"sync"
-	"xiaohongshu/app/infra/browser"
+	"time"
+	"xiaohongshu/app/infra/config"
+	"xiaohongshu/app/infra/container"
 	"xiaohongshu/app/infra/db"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/infra/shutdown"
+	"xiaohongshu/app/infra/startup"
 	"xiaohongshu/app/pkg/utils"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
 
-	"github.com/playwright-community/playwright-go"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// selectorsWatchInterval 检查选择器覆盖文件是否修改的间隔
+const selectorsWatchInterval = 2 * time.Second
+
+// AppContext 管理应用的生命周期：启动时按顺序初始化容器中的依赖，退出时按顺序停止
 type AppContext struct {
+	ctx             context.Context // 根上下文，应用退出时取消
+	cancel          context.CancelFunc
+	wailsCtx        context.Context
+	container       *container.Container
 	environmentInfo runtime.EnvironmentInfo
 	rootPath        string
-	browser         *browser.Browser
-	errors          []error
+	logFile         io.Closer
+	shutdownMu      sync.Mutex
+	shutdownSteps   []shutdown.Step // 各模块注册的停止步骤
+	startHooks      []func(ctx context.Context) error
+	progress        *startup.Reporter
 	initComplete    chan struct{}
 	initOnce        sync.Once
 	// 添加用于控制持续发送事件的字段
@@ -25,72 +43,271 @@ type AppContext struct {
 	sendingStopped chan struct{}
 }
 
-func NewContext() *AppContext {
+// NewContext 创建应用上下文，deps 中的依赖在 OnStartup 时初始化
+func NewContext(deps *container.Container) *AppContext {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &AppContext{
-		browser:        browser.NewBrowser(),
-		errors:         make([]error, 0),
+		ctx:            ctx,
+		cancel:         cancel,
+		container:      deps,
+		progress:       startup.NewReporter(),
 		initComplete:   make(chan struct{}),
 		stopSending:    make(chan struct{}),
 		sendingStopped: make(chan struct{}),
 	}
 }
 
+// AddStartHook 注册浏览器和数据库就绪后执行的启动步骤，在 start-service 阶段按注册顺序执行
+// 步骤出错时可以通过 RetryInitialization 重新执行，因此步骤需要能够重复调用
+func (c *AppContext) AddStartHook(fn func(ctx context.Context) error) {
+	c.startHooks = append(c.startHooks, fn)
+}
+
 func (c *AppContext) OnStartup(ctx context.Context) {
+	c.wailsCtx = ctx
+	c.progress.SetEmitter(func(event string, payload interface{}) {
+		runtime.EventsEmit(ctx, event, payload)
+	})
+	c.container.Browser.SetProgress(c.progress.Update)
+	c.progress.Begin()
+
 	c.environmentInfo = runtime.Environment(ctx)
+	c.progress.Start(startup.StageResolvePaths, "确定应用目录")
 	var err error
 	c.rootPath, err = utils.GetPath(c.environmentInfo.BuildType)
 	if err != nil {
-		c.errors = append(c.errors, err)
+		c.progress.Fail(startup.StageResolvePaths, err)
+	} else {
+		c.progress.Complete(startup.StageResolvePaths)
+		c.progress.Start(startup.StageLoadConfig, "读取配置文件")
+		if err := c.container.LoadConfig(filepath.Join(c.rootPath, config.FileName)); err != nil {
+			// 配置最先加载，配置无效时使用默认配置继续启动
+			c.progress.Fail(startup.StageLoadConfig, err)
+		}
+		c.progress.Complete(startup.StageLoadConfig)
+	}
+	// 日志随后初始化，之后各模块的日志写入缓存目录下的日志文件
+	if err := c.initLogging(); err != nil {
+		c.container.Logger.Error("failed to initialize logging", "error", err)
+	}
+	if c.container.Config == nil {
+		c.progress.Finish()
 		close(c.initComplete) // 确保即使出错也能解除阻塞
 		return
 	}
+	// 加载缓存目录下的选择器覆盖文件，并在文件修改后自动重新加载
+	if err := c.initSelectors(); err != nil {
+		c.container.Logger.Error("failed to load selectors", "error", err)
+	}
 	go func() {
 		defer close(c.initComplete) // 确保在函数结束时关闭通道
-		// 收集浏览器下载过程中的错误
-		if err := c.browser.Init(); err != nil {
-			c.errors = append(c.errors, err)
-			log.Printf("Failed to download browser: %v", err)
+		c.initialize()
+	}()
+}
+
+// initialize 依次下载并连接浏览器、打开数据库、启动服务，某个阶段出错时停止，已完成的阶段在重试时跳过
+func (c *AppContext) initialize() {
+	defer c.progress.Finish()
+	deps := c.container
+	logger := deps.Logger
+
+	if !c.progress.Completed(startup.StageLaunchBrowser) {
+		// 重试前可能在设置页面修改了 CDP 地址
+		deps.Browser.SetCDPURL(deps.Settings().Browser.CDPURL)
+		if err := deps.Browser.Download(); err != nil {
+			stage := startup.StageDownloadDriver
+			if c.progress.Started(startup.StageDownloadBrowser) {
+				stage = startup.StageDownloadBrowser
+			}
+			c.progress.Fail(stage, err)
+			logger.Error("failed to download browser", "error", err)
+			return
+		}
+		c.progress.Complete(startup.StageDownloadDriver)
+		c.progress.Complete(startup.StageDownloadBrowser)
+		if err := deps.Browser.Launch(); err != nil {
+			c.progress.Fail(startup.StageLaunchBrowser, err)
+			logger.Error("failed to launch browser", "error", err)
 			return
 		}
+		c.progress.Complete(startup.StageLaunchBrowser)
+	}
 
-		// 收集数据库初始化过程中的错误
-		if _, err := db.Init(path.Join(c.rootPath, "app.db")); err != nil {
-			c.errors = append(c.errors, err)
-			log.Printf("Failed to initialize database: %v", err)
+	steps := []struct {
+		stage   startup.Stage
+		message string
+		run     func() error
+	}{
+		{startup.StageOpenDatabase, "打开数据库", func() error { return deps.OpenDatabase(path.Join(c.rootPath, "app.db")) }},
+		// 事件日志、任务调度器和账号存储随表结构迁移一起创建
+		{startup.StageMigrate, "迁移数据库", deps.Migrate},
+		{startup.StageStartService, "启动服务", c.runStartHooks},
+	}
+	for _, step := range steps {
+		if c.progress.Completed(step.stage) {
+			continue
+		}
+		c.progress.Start(step.stage, step.message)
+		if err := step.run(); err != nil {
+			c.progress.Fail(step.stage, err)
+			logger.Error("initialization failed", "stage", step.stage, "error", err)
 			return
 		}
+		c.progress.Complete(step.stage)
+	}
+}
+
+func (c *AppContext) runStartHooks() error {
+	for _, hook := range c.startHooks {
+		if err := hook(c.wailsCtx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retry 重新执行出错的初始化阶段，已完成的阶段跳过，进度和结果通过事件通知前端
+func (c *AppContext) Retry() error {
+	select {
+	case <-c.initComplete:
+	default:
+		return fmt.Errorf("initialization is still running")
+	}
+	if c.container.Config == nil {
+		return fmt.Errorf("application paths are unavailable, restart the application")
+	}
+	if !c.progress.Begin() {
+		return fmt.Errorf("initialization is still running")
+	}
+	go func() {
+		c.initialize()
+		if c.progress.Status().Ready {
+			c.OnMount(c.wailsCtx)
+		}
 	}()
+	return nil
 }
 
+// StartupStatus 当前的启动进度和错误
+func (c *AppContext) StartupStatus() startup.Status {
+	return c.progress.Status()
+}
+
+// initLogging 日志写入缓存目录下的 logs，级别等配置来自 log 配置项
+func (c *AppContext) initLogging() error {
+	directory, err := utils.GetDefaultCacheDirectory()
+	if err != nil {
+		return err
+	}
+	settings := c.container.Settings().Log
+	options := logging.DefaultOptions(filepath.Join(directory, "logs"))
+	options.MaxSize = int64(settings.MaxSizeMB) * 1024 * 1024
+	options.MaxFiles = settings.MaxFiles
+	if options.Level, err = logging.ParseLevel(settings.Level); err != nil {
+		return err
+	}
+	c.logFile, err = c.container.Logger.Open(options)
+	return err
+}
+
+// initSelectors 使用缓存目录下的 selectors.json 覆盖内置选择器
+func (c *AppContext) initSelectors() error {
+	directory, err := utils.GetDefaultCacheDirectory()
+	if err != nil {
+		return err
+	}
+	return c.container.WatchSelectors(filepath.Join(directory, selectors.OverrideFile), selectorsWatchInterval)
+}
+
+// OnDomReady 等待首轮初始化完成，启动进度由 startup-progress 等事件推送给前端
 func (c *AppContext) OnDomReady(ctx context.Context) {
 	runtime.LogPrint(ctx, "OnDomReady start")
-	// 等待初始化完成
 	<-c.initComplete
 	runtime.LogPrint(ctx, "OnDomReady end")
-
-	runtime.EventsOn(ctx, "startReady", func(optionalData ...interface{}) {
-		errorMessages := make([]string, len(c.errors))
-		for i, err := range c.errors {
-			errorMessages[i] = err.Error()
-		}
-		runtime.EventsEmit(ctx, "initialization-complete", errorMessages)
-
-	})
 }
 
 func (c *AppContext) OnMount(ctx context.Context) {
 	runtime.EventsEmit(ctx, "on-mount", nil)
 }
 
-// GetErrors 返回收集到的所有错误
-func (c *AppContext) GetErrors() []error {
-	return c.errors
-}
-
 func (c *AppContext) GetRootPath() string {
 	return c.rootPath
 }
 
-func (c *AppContext) GetPlaywrightBrowser() playwright.Browser {
-	return c.browser.GetBrowser()
+// Container 应用的依赖，依赖数据库的模块在初始化完成前或数据库初始化失败时为 nil
+func (c *AppContext) Container() *container.Container {
+	return c.container
+}
+
+// Context 根上下文，长时间运行的操作应使用它，以便应用退出时被取消
+func (c *AppContext) Context() context.Context {
+	return c.ctx
+}
+
+// RegisterShutdown 注册应用退出时执行的停止步骤，在定时任务停止之后、数据库和浏览器关闭之前
+// 按注册的相反顺序执行：后启动的模块可能依赖先启动的模块（如图片处理依赖抓取服务的页面），需要先停止
+func (c *AppContext) RegisterShutdown(name string, fn func(ctx context.Context) error) {
+	c.shutdownMu.Lock()
+	defer c.shutdownMu.Unlock()
+	c.shutdownSteps = append(c.shutdownSteps, shutdown.Step{Name: name, Run: fn})
+}
+
+// OnShutdown 取消根上下文并依次停止各个模块，最后记录未能正常停止的部分并关闭日志文件
+func (c *AppContext) OnShutdown(ctx context.Context) shutdown.Report {
+	c.cancel()
+	report := shutdown.Run(ctx, shutdown.DefaultOptions, c.shutdownPlan())
+	if failed := report.Failed(); len(failed) > 0 {
+		c.container.Logger.Error("shutdown incomplete", "failed", failed, "elapsed", report.Elapsed)
+	} else {
+		c.container.Logger.Info("shutdown complete", "elapsed", report.Elapsed)
+	}
+	if c.logFile != nil {
+		_ = c.logFile.Close()
+	}
+	return report
+}
+
+// shutdownPlan 停止顺序：定时任务、选择器监听、各模块注册的步骤（后注册的先执行）、事件日志、数据库、浏览器和 playwright 驱动
+func (c *AppContext) shutdownPlan() []shutdown.Step {
+	var steps []shutdown.Step
+	// 初始化可能仍在进行，此时跳过定时任务和事件日志
+	initialized := false
+	select {
+	case <-c.initComplete:
+		initialized = true
+	default:
+		c.container.Logger.Warn("shutting down before initialization completed")
+	}
+	deps := c.container
+	if initialized && deps.Scheduler != nil {
+		steps = append(steps, shutdown.Step{Name: "scheduler", Run: func(context.Context) error {
+			deps.Scheduler.Stop()
+			return nil
+		}})
+	}
+	steps = append(steps, shutdown.Step{Name: "selectors", Run: func(context.Context) error {
+		deps.Selectors.StopWatch()
+		return nil
+	}})
+	c.shutdownMu.Lock()
+	for i := len(c.shutdownSteps) - 1; i >= 0; i-- {
+		steps = append(steps, c.shutdownSteps[i])
+	}
+	c.shutdownMu.Unlock()
+	if initialized && deps.EventLog != nil {
+		steps = append(steps, shutdown.Step{Name: "event log", Run: func(context.Context) error {
+			deps.EventLog.Close()
+			return nil
+		}})
+	}
+	steps = append(steps,
+		shutdown.Step{Name: "database", Run: func(context.Context) error {
+			if !initialized {
+				return nil
+			}
+			return db.Close(deps.DB)
+		}},
+		shutdown.Step{Name: "browser", Run: func(context.Context) error { return deps.Browser.Close() }},
+	)
+	return steps
 }
@@ -1,46 +1,80 @@
 package browser
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/infra/startup"
 	"xiaohongshu/app/pkg/utils"
 
 	"github.com/playwright-community/playwright-go"
 )
 
+var logger = logging.Component("browser")
+
 // Browser 封装了playwright浏览器实例
 type Browser struct {
 	pw              *playwright.Playwright
 	browser         playwright.Browser
 	driverDirectory string
+	cdpURL          string // 连接已启动 Chrome 的 CDP 地址
+	progress        func(startup.Progress)
 	initialized     bool
 	mu              sync.Mutex
 }
 
 // NewBrowser 创建一个新的浏览器实例
 func NewBrowser() *Browser {
-	return &Browser{}
+	return &Browser{cdpURL: DefaultCDPURL}
 }
 
-// Init 初始化浏览器，首先调用Download方法下载浏览器（如果尚未安装）
-func (b *Browser) Init() error {
+// DefaultCDPURL 默认的 CDP 地址
+const DefaultCDPURL = "http://localhost:9222"
+
+// SetCDPURL 设置 CDP 地址，需在 Init 之前调用
+func (b *Browser) SetCDPURL(url string) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.cdpURL = url
+}
 
-	// 调用Download方法下载浏览器（如果尚未安装）
-	err := b.download()
-	if err != nil {
-		return err
+// SetProgress 设置下载和启动进度的回调，需在 Init 之前调用
+func (b *Browser) SetProgress(fn func(startup.Progress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.progress = fn
+}
+
+func (b *Browser) report(progress startup.Progress) {
+	if b.progress != nil {
+		b.progress(progress)
 	}
-	err = b.launch()
-	if err != nil {
+}
+
+// Init 初始化浏览器，首先调用Download方法下载浏览器（如果尚未安装）
+func (b *Browser) Init() error {
+	if err := b.Download(); err != nil {
 		return err
 	}
-	return nil
+	return b.Launch()
+}
+
+// Download 下载 playwright 驱动和 Chromium（如果尚未安装），失败后可以再次调用
+func (b *Browser) Download() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.download()
+}
+
+// Launch 连接浏览器，已连接时什么也不做，失败后可以再次调用
+func (b *Browser) Launch() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.launch()
 }
 
 // Download 下载
@@ -56,15 +90,25 @@ func (b *Browser) download() error {
 	nodeExecutable := getNodeExecutable(b.driverDirectory)
 	if _, err := os.Stat(nodeExecutable); err == nil {
 		// 已安装，跳过重复安装
-		log.Println("Playwright is already installed, skipping installation")
+		logger.Info("playwright is already installed, skipping installation")
 		return nil
 	}
 
 	// 未安装，执行安装
+	b.report(startup.Progress{Stage: startup.StageDownloadDriver, Message: "正在下载 playwright 驱动", Percent: -1})
+	restore := countDriverDownload(func(read, total int64) {
+		b.report(startup.Progress{Stage: startup.StageDownloadDriver, Bytes: read, Total: total, Percent: -1})
+	})
+	defer restore()
+	output := newInstallOutput(func(read, total int64, message string) {
+		b.report(startup.Progress{Stage: startup.StageDownloadBrowser, Message: message, Bytes: read, Total: total, Percent: -1})
+	})
 	err = playwright.Install(&playwright.RunOptions{
 		DriverDirectory: b.driverDirectory,
 		Browsers:        []string{"chromium"},
 		Verbose:         true,
+		Stdout:          output,
+		Stderr:          output,
 	})
 
 	if err != nil {
@@ -79,6 +123,7 @@ func (b *Browser) launch() error {
 	if b.initialized {
 		return nil
 	}
+	b.report(startup.Progress{Stage: startup.StageLaunchBrowser, Message: "正在连接浏览器 " + b.cdpURL, Percent: -1})
 	// 初始化playwright
 	pw, err := playwright.Run(&playwright.RunOptions{DriverDirectory: b.driverDirectory})
 	if err != nil {
@@ -99,8 +144,11 @@ func (b *Browser) launch() error {
 	//		"--disable-features=IsolateOrigins,site-per-process",
 	//	},
 	//}
-	b.browser, err = pw.Chromium.ConnectOverCDP("http://localhost:9222")
+	b.browser, err = pw.Chromium.ConnectOverCDP(b.cdpURL)
 	if err != nil {
+		// 停止驱动，重试时重新启动
+		_ = pw.Stop()
+		b.pw = nil
 		return err
 	}
 	// 启动Chrome浏览器
@@ -112,25 +160,30 @@ func (b *Browser) launch() error {
 	// 标记浏览器已初始化
 	b.initialized = true
 
-	log.Println("Chrome browser launched successfully")
+	logger.Info("browser launched")
 	return nil
 }
 
-// Close 关闭浏览器和playwright实例
+// Close 关闭浏览器和playwright实例，浏览器关闭失败时仍会停止 playwright 驱动
 func (b *Browser) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var errs []error
 	if b.browser != nil {
 		if err := b.browser.Close(); err != nil {
-			return fmt.Errorf("failed to close browser: %w", err)
+			errs = append(errs, fmt.Errorf("failed to close browser: %w", err))
 		}
+		b.browser = nil
 	}
 
 	if b.pw != nil {
 		if err := b.pw.Stop(); err != nil {
-			return fmt.Errorf("failed to stop playwright: %w", err)
+			errs = append(errs, fmt.Errorf("failed to stop playwright: %w", err))
 		}
+		b.pw = nil
 	}
-
-	return nil
+	b.initialized = false
+	return errors.Join(errs...)
 }
 
 // GetBrowser 返回底层的playwright浏览器实例
@@ -0,0 +1,100 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// countDriverDownload 统计 playwright 驱动的下载进度，返回恢复原设置的函数
+// playwright-go 使用 http.DefaultClient 下载驱动，只能在下载期间替换它的 Transport
+func countDriverDownload(report func(read, total int64)) (restore func()) {
+	previous := http.DefaultClient.Transport
+	base := previous
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	http.DefaultClient.Transport = &countingTransport{base: base, report: report}
+	return func() {
+		http.DefaultClient.Transport = previous
+	}
+}
+
+type countingTransport struct {
+	base   http.RoundTripper
+	report func(read, total int64)
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	resp.Body = &countingReader{ReadCloser: resp.Body, total: resp.ContentLength, report: t.report}
+	return resp, nil
+}
+
+type countingReader struct {
+	io.ReadCloser
+	read   int64
+	total  int64
+	report func(read, total int64)
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	r.report(r.read, r.total)
+	return n, err
+}
+
+// installProgress 匹配 playwright install 输出的进度，例如 "|■■■■      |  40% of 171.6 MiB"
+var installProgress = regexp.MustCompile(`(\d+)% of ([\d.]+) (KiB|MiB|GiB)`)
+
+var units = map[string]float64{"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30}
+
+// installOutput 解析 playwright install 的输出，按行回调下载进度和提示信息
+type installOutput struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	report func(read, total int64, message string)
+}
+
+func newInstallOutput(report func(read, total int64, message string)) *installOutput {
+	return &installOutput{report: report}
+}
+
+func (o *installOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buf.Write(p)
+	for {
+		data := o.buf.Bytes()
+		i := bytes.IndexAny(data, "\r\n")
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimSpace(data[:i]))
+		o.buf.Next(i + 1)
+		if line != "" {
+			o.line(line)
+		}
+	}
+	return len(p), nil
+}
+
+func (o *installOutput) line(line string) {
+	match := installProgress.FindStringSubmatch(line)
+	if match == nil {
+		logger.Debug("playwright install", "output", line)
+		o.report(0, 0, line)
+		return
+	}
+	percent, _ := strconv.ParseFloat(match[1], 64)
+	size, _ := strconv.ParseFloat(match[2], 64)
+	total := int64(size * units[match[3]])
+	o.report(int64(float64(total)*percent/100), total, "")
+}
@@ -0,0 +1,279 @@
+// Package config 应用配置。
+// 配置从根目录下的 config.yaml 读取，未设置的项使用默认值，再由 XIAOHONGSHU_ 开头的环境变量覆盖，
+// 例如 browser.cdpUrl 对应 XIAOHONGSHU_BROWSER_CDPURL。
+// 带 restart:"true" 标签的配置项修改后需要重启才能生效，其余配置项立即生效。
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"xiaohongshu/app/infra/logging"
+)
+
+// FileName 配置文件名
+const FileName = "config.yaml"
+
+// EnvPrefix 环境变量前缀
+const EnvPrefix = "XIAOHONGSHU"
+
+type Config struct {
+	Browser  Browser  `yaml:"browser" json:"browser"`
+	Site     Site     `yaml:"site" json:"site"`
+	Media    Media    `yaml:"media" json:"media"`
+	Database Database `yaml:"database" json:"database"`
+	Log      Log      `yaml:"log" json:"log"`
+	Har      Har      `yaml:"har" json:"har"`
+}
+
+// Browser 浏览器配置
+type Browser struct {
+	CDPURL         string `yaml:"cdpUrl" json:"cdpUrl" restart:"true"` // 连接已启动 Chrome 的 CDP 地址
+	ViewportWidth  int    `yaml:"viewportWidth" json:"viewportWidth"`  // 新建页面的视口宽度
+	ViewportHeight int    `yaml:"viewportHeight" json:"viewportHeight"`
+}
+
+// Site 小红书站点配置
+type Site struct {
+	StartURL  string `yaml:"startUrl" json:"startUrl" restart:"true"`   // 启动后打开的页面
+	NoteClass string `yaml:"noteClass" json:"noteClass" restart:"true"` // 笔记详情弹窗的类名，用于监听笔记打开和关闭
+}
+
+// Media 媒体采集配置
+type Media struct {
+	Audio Audio `yaml:"audio" json:"audio"`
+	OCR   OCR   `yaml:"ocr" json:"ocr"`
+}
+
+// Audio 视频音频采集配置，下次开始采集时生效
+type Audio struct {
+	SampleRate    int     `yaml:"sampleRate" json:"sampleRate"`
+	BitDepth      int     `yaml:"bitDepth" json:"bitDepth"`
+	EnableVAD     bool    `yaml:"enableVad" json:"enableVAD"` // 语音活动检测
+	VADThreshold  float64 `yaml:"vadThreshold" json:"vadThreshold"`
+	SilenceFrames int     `yaml:"silenceFrames" json:"silenceFrames"` // 连续静音帧数阈值
+	BufferSize    int     `yaml:"bufferSize" json:"bufferSize"`
+}
+
+// OCR 笔记图片文字识别配置，使用本地安装的 tesseract
+type OCR struct {
+	Enabled   bool   `yaml:"enabled" json:"enabled" restart:"true"`
+	Tesseract string `yaml:"tesseract" json:"tesseract" restart:"true"` // tesseract 可执行文件路径，默认从 PATH 查找
+	Languages string `yaml:"languages" json:"languages" restart:"true"` // 识别语言，需要安装对应的语言包
+}
+
+// Database 数据库连接池配置
+type Database struct {
+	MaxIdleConns int `yaml:"maxIdleConns" json:"maxIdleConns"`
+	MaxOpenConns int `yaml:"maxOpenConns" json:"maxOpenConns"`
+}
+
+// Log 日志配置
+type Log struct {
+	Level     string `yaml:"level" json:"level"`
+	MaxSizeMB int    `yaml:"maxSizeMb" json:"maxSizeMb" restart:"true"` // 单个日志文件的最大大小
+	MaxFiles  int    `yaml:"maxFiles" json:"maxFiles" restart:"true"`   // 保留的历史日志文件数
+}
+
+// Har 会话的 HAR 录制与回放，启动参数 -har 和 -har-file 优先
+type Har struct {
+	Mode string `yaml:"mode" json:"mode" restart:"true"` // off、record 或 replay
+	File string `yaml:"file" json:"file" restart:"true"` // 回放使用的 HAR 文件，录制时为输出路径，为空时写入缓存目录
+}
+
+// HarModes 可用的 HAR 模式
+var HarModes = []string{"off", "record", "replay"}
+
+// Default 默认配置
+func Default() Config {
+	return Config{
+		Browser: Browser{
+			CDPURL:         "http://localhost:9222",
+			ViewportWidth:  1366,
+			ViewportHeight: 768,
+		},
+		Site: Site{
+			StartURL:  "https://www.xiaohongshu.com",
+			NoteClass: "note-detail-mask",
+		},
+		Media: Media{
+			Audio: Audio{
+				SampleRate:    24000,
+				BitDepth:      16,
+				EnableVAD:     true,
+				VADThreshold:  0.001,
+				SilenceFrames: 10,
+				BufferSize:    2048,
+			},
+			OCR: OCR{
+				Enabled:   true,
+				Tesseract: "tesseract",
+				Languages: "chi_sim+eng",
+			},
+		},
+		Database: Database{
+			MaxIdleConns: 10,
+			MaxOpenConns: 100,
+		},
+		Log: Log{
+			Level:     "info",
+			MaxSizeMB: 10,
+			MaxFiles:  5,
+		},
+		Har: Har{
+			Mode: "off",
+		},
+	}
+}
+
+// Validate 检查配置是否有效
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	for key, raw := range map[string]string{"browser.cdpUrl": c.Browser.CDPURL, "site.startUrl": c.Site.StartURL} {
+		u, err := url.Parse(raw)
+		check(err == nil && u.Scheme != "" && u.Host != "", "%s must be an absolute url, got %q", key, raw)
+	}
+	check(c.Browser.ViewportWidth > 0 && c.Browser.ViewportHeight > 0, "browser viewport must be positive")
+	check(strings.TrimSpace(c.Site.NoteClass) != "", "site.noteClass is required")
+	audio := c.Media.Audio
+	check(audio.SampleRate >= 8000 && audio.SampleRate <= 96000, "media.audio.sampleRate must be between 8000 and 96000")
+	check(audio.BitDepth == 16 || audio.BitDepth == 32, "media.audio.bitDepth must be 16 or 32")
+	check(audio.VADThreshold >= 0, "media.audio.vadThreshold must not be negative")
+	check(audio.SilenceFrames >= 0, "media.audio.silenceFrames must not be negative")
+	check(audio.BufferSize > 0 && audio.BufferSize&(audio.BufferSize-1) == 0, "media.audio.bufferSize must be a power of two")
+	check(!c.Media.OCR.Enabled || strings.TrimSpace(c.Media.OCR.Languages) != "", "media.ocr.languages is required when ocr is enabled")
+	check(c.Database.MaxOpenConns > 0, "database.maxOpenConns must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.maxIdleConns must be between 0 and maxOpenConns")
+	_, err := logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.MaxSizeMB > 0, "log.maxSizeMb must be positive")
+	check(c.Log.MaxFiles >= 0, "log.maxFiles must not be negative")
+	check(c.Har.Mode == "" || slices.Contains(HarModes, c.Har.Mode), "har.mode must be off, record or replay, got %q", c.Har.Mode)
+	check(c.Har.Mode != "replay" || strings.TrimSpace(c.Har.File) != "", "har.file is required when har.mode is replay")
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Field 配置项的说明，供设置页面使用
+type Field struct {
+	Key             string `json:"key"` // 如 browser.cdpUrl
+	Env             string `json:"env"` // 覆盖该项的环境变量
+	RestartRequired bool   `json:"restartRequired"`
+}
+
+// Fields 所有配置项
+func Fields() []Field {
+	var fields []Field
+	walk(reflect.ValueOf(&Config{}).Elem(), "", func(key string, _ reflect.Value, restart bool) {
+		fields = append(fields, Field{Key: key, Env: envName(key), RestartRequired: restart})
+	})
+	return fields
+}
+
+// ApplyEnv 用环境变量覆盖配置，返回被覆盖的配置项
+func ApplyEnv(c *Config, lookup func(string) (string, bool)) ([]string, error) {
+	var overridden []string
+	var err error
+	walk(reflect.ValueOf(c).Elem(), "", func(key string, value reflect.Value, _ bool) {
+		raw, ok := lookup(envName(key))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := setValue(value, raw); setErr != nil {
+			err = fmt.Errorf("invalid %s: %w", envName(key), setErr)
+			return
+		}
+		overridden = append(overridden, key)
+	})
+	return overridden, err
+}
+
+// Diff 返回 a 和 b 中值不同的配置项
+func Diff(a, b Config) []string {
+	values := make(map[string]interface{})
+	walk(reflect.ValueOf(&a).Elem(), "", func(key string, value reflect.Value, _ bool) {
+		values[key] = value.Interface()
+	})
+	var changed []string
+	walk(reflect.ValueOf(&b).Elem(), "", func(key string, value reflect.Value, _ bool) {
+		if values[key] != value.Interface() {
+			changed = append(changed, key)
+		}
+	})
+	return changed
+}
+
+// RestartRequired 需要重启才能生效的配置项
+func RestartRequired(keys []string) []string {
+	restart := make(map[string]bool)
+	for _, field := range Fields() {
+		restart[field.Key] = field.RestartRequired
+	}
+	var result []string
+	for _, key := range keys {
+		if restart[key] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// walk 遍历所有叶子配置项，key 由 yaml 标签以点连接
+func walk(v reflect.Value, prefix string, fn func(key string, value reflect.Value, restart bool)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), key, fn)
+			continue
+		}
+		fn(key, v.Field(i), field.Tag.Get("restart") == "true")
+	}
+}
+
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"xiaohongshu/app/infra/eventbus"
+)
+
+func TestStoreLoadOverrideAndUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("browser:\n  viewportWidth: 1280\nlog:\n  level: debug\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XIAOHONGSHU_BROWSER_CDPURL", "http://127.0.0.1:9333")
+
+	store, err := NewStore(path, eventbus.New())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	current := store.Get()
+	if current.Browser.ViewportWidth != 1280 || current.Browser.ViewportHeight != Default().Browser.ViewportHeight {
+		t.Fatalf("unexpected viewport: %+v", current.Browser)
+	}
+	if current.Log.Level != "debug" {
+		t.Fatalf("log.level = %q, want file value", current.Log.Level)
+	}
+	if current.Browser.CDPURL != "http://127.0.0.1:9333" || store.File().Browser.CDPURL != Default().Browser.CDPURL {
+		t.Fatalf("env override should only apply to effective config")
+	}
+	if got := store.Overridden(); !reflect.DeepEqual(got, []string{"browser.cdpUrl"}) {
+		t.Fatalf("Overridden = %v", got)
+	}
+
+	next := store.File()
+	next.Log.Level = "warn"
+	next.Site.StartURL = "https://www.xiaohongshu.com/explore"
+	change, err := store.Update(next)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !reflect.DeepEqual(change.Changed, []string{"site.startUrl", "log.level"}) {
+		t.Fatalf("Changed = %v", change.Changed)
+	}
+	if !reflect.DeepEqual(change.RestartRequired, []string{"site.startUrl"}) {
+		t.Fatalf("RestartRequired = %v", change.RestartRequired)
+	}
+
+	reloaded, err := NewStore(path, eventbus.New())
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded.File() != next {
+		t.Fatalf("saved config was not reloaded: %+v", reloaded.File())
+	}
+
+	invalid := store.File()
+	invalid.Media.Audio.BufferSize = 1000
+	if _, err := store.Update(invalid); err == nil {
+		t.Fatalf("expected invalid buffer size to be rejected")
+	}
+	if store.Get().Media.Audio.BufferSize != Default().Media.Audio.BufferSize {
+		t.Fatalf("invalid config must not replace current config")
+	}
+}
+
+func TestInvalidFileFallsBackToDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("log:\n  level: verbose\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewStore(path, eventbus.New())
+	if err == nil {
+		t.Fatalf("expected invalid log level to be rejected")
+	}
+	if store == nil || store.Get() != Default() {
+		t.Fatalf("expected default config on error")
+	}
+}
+
+func TestHarValidationAndEnv(t *testing.T) {
+	c := Default()
+	overridden, err := ApplyEnv(&c, func(key string) (string, bool) {
+		value, ok := map[string]string{"XIAOHONGSHU_HAR_MODE": "replay"}[key]
+		return value, ok
+	})
+	if err != nil || !reflect.DeepEqual(overridden, []string{"har.mode"}) {
+		t.Fatalf("ApplyEnv = %v, %v", overridden, err)
+	}
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected replay without har.file to be rejected")
+	}
+	c.Har.File = "session.har"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	c.Har.Mode = "bogus"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected unknown har mode to be rejected")
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"xiaohongshu/app/infra/eventbus"
+
+	"github.com/asaskevich/EventBus"
+	"gopkg.in/yaml.v3"
+)
+
+// Change 配置修改后在事件总线上发布的载荷
+type Change struct {
+	Config          Config   `json:"config"`
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restartRequired"` // 修改的配置项中需要重启才能生效的部分
+}
+
+// Store 读取、保存配置文件
+type Store struct {
+	mu         sync.RWMutex
+	path       string
+	file       Config   // 配置文件中的值（含默认值），保存时写回
+	current    Config   // 应用环境变量覆盖后实际生效的值
+	overridden []string // 被环境变量覆盖的配置项
+	lookupEnv  func(string) (string, bool)
+	bus        EventBus.Bus
+}
+
+// NewStore 读取 path 指向的配置文件，文件不存在时使用默认配置，修改后在 bus 上发布 TopicConfigChanged
+// 配置无效时返回错误，同时返回使用默认配置的 Store，保证应用仍能启动
+func NewStore(path string, bus EventBus.Bus) (*Store, error) {
+	s := &Store{path: path, lookupEnv: os.LookupEnv, file: Default(), current: Default(), bus: bus}
+	if err := s.load(); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	file := Default()
+	data, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		// 在默认值上解析，文件中没有的配置项保持默认值
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", s.path, err)
+		}
+	}
+	current := file
+	overridden, err := ApplyEnv(&current, s.lookupEnv)
+	if err != nil {
+		return err
+	}
+	if err := current.Validate(); err != nil {
+		return err
+	}
+	s.file = file
+	s.current = current
+	s.overridden = overridden
+	return nil
+}
+
+// Path 配置文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get 当前生效的配置
+func (s *Store) Get() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// File 配置文件中的值，不含环境变量覆盖，设置页面编辑的是这份配置
+func (s *Store) File() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.file
+}
+
+// Overridden 被环境变量覆盖的配置项，设置页面中这些项的修改不会生效
+func (s *Store) Overridden() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.overridden...)
+}
+
+// Update 校验并保存配置，发布 TopicConfigChanged
+// 被环境变量覆盖的配置项保存到文件，但生效的仍是环境变量的值
+// 写文件和替换当前配置在同一把锁内完成，并发保存时文件内容与生效的配置保持一致
+func (s *Store) Update(next Config) (Change, error) {
+	current := next
+	overridden, err := ApplyEnv(&current, s.lookupEnv)
+	if err != nil {
+		return Change{}, err
+	}
+	if err := current.Validate(); err != nil {
+		return Change{}, err
+	}
+
+	s.mu.Lock()
+	if err := s.write(next); err != nil {
+		s.mu.Unlock()
+		return Change{}, err
+	}
+	changed := Diff(s.current, current)
+	s.file = next
+	s.current = current
+	s.overridden = overridden
+	s.mu.Unlock()
+
+	change := Change{Config: current, Changed: changed, RestartRequired: RestartRequired(changed)}
+	if len(changed) > 0 {
+		s.bus.Publish(eventbus.TopicConfigChanged, change)
+	}
+	return change, nil
+}
+
+// write 先写临时文件再替换，避免写入中断导致配置文件损坏
+func (s *Store) write(c Config) error {
+	var buf bytes.Buffer
+	buf.WriteString("# 小红书助手配置，修改后部分配置项需要重启才能生效\n")
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,223 @@
+// Package container 应用的依赖容器。
+// 事件总线、配置、数据库、浏览器以及依赖它们的模块都由容器创建并通过容器获取，
+// 同一进程中可以创建多个互不影响的容器，例如多个账号或多个测试用例。
+package container
+
+import (
+	"embed"
+	"fmt"
+	"path/filepath"
+	"time"
+	"xiaohongshu/app/infra/browser"
+	"xiaohongshu/app/infra/config"
+	"xiaohongshu/app/infra/db"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/eventlog"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services"
+	"xiaohongshu/app/services/account"
+	"xiaohongshu/app/services/analytics"
+	"xiaohongshu/app/services/archive"
+	"xiaohongshu/app/services/imagehash"
+	"xiaohongshu/app/services/keyframe"
+	"xiaohongshu/app/services/ocr"
+	"xiaohongshu/app/services/scheduler"
+	"xiaohongshu/app/services/search"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+	"xiaohongshu/app/services/xiaohongshu/site"
+
+	"github.com/asaskevich/EventBus"
+	"gorm.io/gorm"
+)
+
+// Container 持有应用的依赖，依赖数据库的模块在 OpenDatabase 之前为 nil
+type Container struct {
+	Bus       EventBus.Bus
+	Logger    *logging.Logger // 容器的日志级别、输出和最近日志
+	Config    *config.Store   // LoadConfig 之前为 nil，此时使用默认配置
+	Browser   *browser.Browser
+	Selectors *selectors.Registry // 页面选择器，WatchSelectors 之前为内置选择器
+	Metrics   *selectors.Metrics  // 选择器命中统计
+	Pacer     *pacing.Pacer       // 浏览器交互的节奏控制，限流和配额按登录账号计算
+	DB        *gorm.DB
+	EventLog  *eventlog.Store
+	Scheduler *scheduler.Scheduler
+	Accounts  *account.Store
+	Keyframes *keyframe.Store
+	Images    *imagehash.Store
+	Search    *search.Index
+	Texts     *ocr.Store
+	Archive   *archive.Store
+	Analytics *analytics.Service
+
+	dataDir string // 数据库所在的目录，关键帧等文件保存在这里
+}
+
+// New 创建只包含事件总线、日志、浏览器、选择器和节奏控制的容器
+// 每个容器的日志、选择器、命中统计和节奏控制相互独立
+func New() *Container {
+	bus := eventbus.New()
+	registry := selectors.MustNewRegistry()
+	registry.SetBus(bus)
+	metrics := selectors.NewMetrics(registry)
+	metrics.SetBus(bus)
+	return &Container{
+		Bus:       bus,
+		Logger:    logging.New(),
+		Browser:   browser.NewBrowser(),
+		Selectors: registry,
+		Metrics:   metrics,
+		Pacer:     pacing.NewPacer(pacing.DefaultConfig()),
+	}
+}
+
+// Site 页面操作使用的选择器、命中统计和节奏控制
+func (c *Container) Site() *site.Site {
+	return site.New(c.Selectors, c.Metrics, c.Pacer)
+}
+
+// Settings 当前生效的配置，未加载配置时为默认配置
+func (c *Container) Settings() config.Config {
+	if c.Config == nil {
+		return config.Default()
+	}
+	return c.Config.Get()
+}
+
+// LoadConfig 读取配置文件，并把可以立即生效的配置项应用到运行中的模块
+// 配置无效时返回错误，此时仍使用默认配置
+func (c *Container) LoadConfig(path string) error {
+	store, err := config.NewStore(path, c.Bus)
+	c.Config = store
+	subscribeErr := c.Bus.Subscribe(eventbus.TopicConfigChanged, func(payload interface{}) {
+		change, ok := payload.(config.Change)
+		if !ok {
+			return
+		}
+		if level, err := logging.ParseLevel(change.Config.Log.Level); err == nil {
+			c.Logger.SetLevel(level)
+		}
+		if c.DB != nil {
+			if err := db.ApplyPool(c.DB, databasePool(change.Config.Database)); err != nil {
+				c.Logger.Error("failed to apply database pool", "error", err)
+			}
+		}
+		c.Logger.Info("config changed", "changed", change.Changed, "restartRequired", change.RestartRequired)
+	})
+	if err != nil {
+		return err
+	}
+	return subscribeErr
+}
+
+// WatchSelectors 加载选择器覆盖文件并在修改后自动重新加载，选择器的事件发布到容器的事件总线
+func (c *Container) WatchSelectors(path string, interval time.Duration) error {
+	// 文件格式错误时仍然监听，修正后即可生效
+	err := c.Selectors.LoadFile(path)
+	c.Selectors.Watch(interval)
+	return err
+}
+
+// OpenDatabase 打开数据库，已打开时什么也不做
+func (c *Container) OpenDatabase(path string) error {
+	if c.DB != nil {
+		return nil
+	}
+	database, err := db.Open(path, databasePool(c.Settings().Database))
+	if err != nil {
+		return err
+	}
+	c.DB = database
+	c.dataDir = filepath.Dir(path)
+	return nil
+}
+
+// Migrate 迁移表结构并创建事件日志、任务调度器、关键帧、图片哈希、全文索引、图片文字、采集数据、评论分析和账号存储，已创建的模块跳过，失败后可以再次调用
+func (c *Container) Migrate() error {
+	if c.DB == nil {
+		return fmt.Errorf("database is not opened")
+	}
+	// 启动事件日志，记录总线上发布的事件
+	if c.EventLog == nil {
+		if err := c.initEventLog(); err != nil {
+			return err
+		}
+	}
+	// 创建任务调度器，任务处理函数在服务启动后注册
+	if c.Scheduler == nil {
+		sch, err := scheduler.NewScheduler(c.DB, c.Bus)
+		if err != nil {
+			return err
+		}
+		c.Scheduler = sch
+	}
+	// 视频笔记的关键帧缩略图
+	if c.Keyframes == nil {
+		store, err := keyframe.NewStore(c.DB, filepath.Join(c.dataDir, "keyframes"))
+		if err != nil {
+			return err
+		}
+		c.Keyframes = store
+	}
+	// 笔记图片的感知哈希，用于查找搬运和盗图的笔记
+	if c.Images == nil {
+		store, err := imagehash.NewStore(c.DB)
+		if err != nil {
+			return err
+		}
+		c.Images = store
+	}
+	// 本地全文索引，收录笔记正文和图片中的文字
+	if c.Search == nil {
+		index, err := search.NewIndex(c.DB)
+		if err != nil {
+			return err
+		}
+		c.Search = index
+	}
+	// 笔记图片的文字识别结果
+	if c.Texts == nil {
+		store, err := ocr.NewStore(c.DB, c.Search)
+		if err != nil {
+			return err
+		}
+		c.Texts = store
+	}
+	// 采集到的推荐条目、笔记、评论和作者，用于导出
+	if c.Archive == nil {
+		store, err := archive.NewStore(c.DB)
+		if err != nil {
+			return err
+		}
+		c.Archive = store
+	}
+	// 基于采集到的评论的分析
+	if c.Analytics == nil {
+		c.Analytics = analytics.NewService(c.Archive)
+	}
+	// 账号状态，触发风控时暂停该账号的任务
+	if c.Accounts == nil {
+		return c.initAccounts()
+	}
+	return nil
+}
+
+// NewService 创建使用容器中浏览器、事件总线和配置的小红书服务，浏览器未连接时返回错误
+func (c *Container) NewService(scriptsPath embed.FS) (*services.XiaohongshuService, error) {
+	if c.Browser.GetBrowser() == nil {
+		return nil, fmt.Errorf("browser is not connected")
+	}
+	service, err := services.NewXiaohongshuService(c.Browser.GetBrowser(), c.Bus, c.Site(), scriptsPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.SetConfig(c.Config); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+func databasePool(settings config.Database) db.Pool {
+	return db.Pool{MaxIdleConns: settings.MaxIdleConns, MaxOpenConns: settings.MaxOpenConns}
+}
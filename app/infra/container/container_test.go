@@ -0,0 +1,105 @@
+package container
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"xiaohongshu/app/infra/config"
+	"xiaohongshu/app/infra/db"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/eventlog"
+	"xiaohongshu/app/services/account"
+	"xiaohongshu/app/services/scheduler"
+	"xiaohongshu/app/services/xiaohongshu/risk"
+)
+
+func newTestContainer(t *testing.T) *Container {
+	return openTestContainer(t, t.TempDir())
+}
+
+// openTestContainer 在 dir 中创建容器，同一个 dir 再次打开相当于重启应用
+func openTestContainer(t *testing.T, dir string) *Container {
+	c := New()
+	if err := c.LoadConfig(filepath.Join(dir, config.FileName)); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if err := c.OpenDatabase(filepath.Join(dir, "app.db")); err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+	if err := c.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		c.Scheduler.Stop()
+		c.EventLog.Close()
+		_ = db.Close(c.DB)
+	})
+	return c
+}
+
+func TestContainersAreIsolated(t *testing.T) {
+	a := newTestContainer(t)
+	b := newTestContainer(t)
+
+	a.Bus.Publish(eventbus.TopicJobFinished, scheduler.RunFinished{JobID: 1, Status: "succeeded"})
+	a.Bus.Publish(eventbus.TopicRiskChallenged, risk.Challenge{Kind: "captcha"})
+
+	events, err := a.EventLog.Query(eventlog.Query{Topics: []string{eventbus.TopicJobFinished}})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event in a, got %d", len(events))
+	}
+	events, err = b.EventLog.Query(eventlog.Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("events leaked into b: %+v", events)
+	}
+	if !a.Scheduler.AccountPaused(scheduler.DefaultAccount) {
+		t.Fatalf("expected challenge to pause the account in a")
+	}
+	if b.Scheduler.AccountPaused(scheduler.DefaultAccount) {
+		t.Fatalf("challenge in a must not pause the account in b")
+	}
+	a.Logger.SetLevel(slog.LevelDebug)
+	if b.Logger.Level() != slog.LevelInfo {
+		t.Fatalf("log level of a leaked into b")
+	}
+}
+
+func TestResolveChallengedAccountAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	first := openTestContainer(t, dir)
+	first.Bus.Publish(eventbus.TopicRiskChallenged, risk.Challenge{Account: "u1", Kind: "captcha"})
+	if !first.Scheduler.AccountPaused("u1") {
+		t.Fatalf("expected challenge to pause u1")
+	}
+
+	restarted := openTestContainer(t, dir)
+	if !restarted.Scheduler.AccountPaused("u1") {
+		t.Fatalf("challenged account must stay paused after restart")
+	}
+	if err := restarted.ResolveAccount("u1"); err != nil {
+		t.Fatalf("ResolveAccount: %v", err)
+	}
+	if restarted.Scheduler.AccountPaused("u1") {
+		t.Fatalf("expected u1 to be resumed")
+	}
+	a, err := restarted.Accounts.Get("u1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if a.State != account.StateActive {
+		t.Fatalf("expected active state, got %q", a.State)
+	}
+	// 默认账号仍在等待处理
+	if !restarted.Scheduler.AccountPaused(scheduler.DefaultAccount) {
+		t.Fatalf("default account must stay paused")
+	}
+	if err := restarted.ResolveAccount("missing"); err == nil {
+		t.Fatalf("expected error for unknown account")
+	}
+}
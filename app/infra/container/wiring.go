@@ -0,0 +1,172 @@
+package container
+
+import (
+	"fmt"
+	"time"
+	"xiaohongshu/app/entities"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/eventlog"
+	"xiaohongshu/app/services/account"
+	"xiaohongshu/app/services/scheduler"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/profile"
+	"xiaohongshu/app/services/xiaohongshu/risk"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+)
+
+// initAccounts 创建账号存储，根据风控事件更新账号状态并暂停/恢复任务
+func (c *Container) initAccounts() error {
+	store, err := account.NewStore(c.DB)
+	if err != nil {
+		return err
+	}
+	// 上次退出时仍处于风控状态的账号保持暂停
+	challenged, err := store.ListByState(account.StateChallenged)
+	if err != nil {
+		return err
+	}
+	for _, a := range challenged {
+		c.Scheduler.PauseAccount(a.ID)
+	}
+
+	bus := c.Bus
+	err = bus.Subscribe(eventbus.TopicUserLoggedIn, func(userInfo interface{}) {
+		if info, ok := userInfo.(entities.UserInfo); ok && info.UserId != "" {
+			if err := store.Touch(info.UserId, info.Nickname); err != nil {
+				c.Logger.Error("failed to save account", "account", info.UserId, "error", err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(eventbus.TopicUserLoggedOut, func(payload interface{}) {
+		if id, ok := payload.(string); ok && id != "" {
+			if err := store.SetState(id, account.StateLoggedOut, ""); err != nil {
+				c.Logger.Error("failed to update account", "account", id, "error", err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(eventbus.TopicRiskChallenged, func(payload interface{}) {
+		challenge, ok := payload.(risk.Challenge)
+		if !ok {
+			return
+		}
+		for _, id := range challengeAccounts(challenge) {
+			if err := store.SetState(id, account.StateChallenged, challenge.Kind+": "+challenge.Reason); err != nil {
+				c.Logger.Error("failed to update account", "account", id, "error", err)
+			}
+			c.Scheduler.PauseAccount(id)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = bus.Subscribe(eventbus.TopicRiskResolved, func(payload interface{}) {
+		challenge, ok := payload.(risk.Challenge)
+		if !ok {
+			return
+		}
+		for _, id := range challengeAccounts(challenge) {
+			if err := store.SetState(id, account.StateActive, ""); err != nil {
+				c.Logger.Error("failed to update account", "account", id, "error", err)
+			}
+			c.Scheduler.ResumeAccount(id)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	c.Accounts = store
+	return nil
+}
+
+// ResolveAccount 人工确认账号的风控已处理，恢复账号状态和账号的任务
+// 重启后仍处于风控状态的账号没有检测器中的风控记录，不会自动解除，需要调用此方法
+func (c *Container) ResolveAccount(id string) error {
+	if c.Accounts == nil || c.Scheduler == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	if _, err := c.Accounts.Get(id); err != nil {
+		return fmt.Errorf("account %s not found: %w", id, err)
+	}
+	if err := c.Accounts.SetState(id, account.StateActive, ""); err != nil {
+		return err
+	}
+	c.Scheduler.ResumeAccount(id)
+	c.Logger.Info("account resolved", "account", id)
+	return nil
+}
+
+// challengeAccounts 风控影响的账号：当前登录账号以及使用同一浏览器会话的默认账号
+func challengeAccounts(challenge risk.Challenge) []string {
+	if challenge.Account == "" || challenge.Account == scheduler.DefaultAccount {
+		return []string{scheduler.DefaultAccount}
+	}
+	return []string{challenge.Account, scheduler.DefaultAccount}
+}
+
+// initEventLog 创建事件日志并订阅需要审计的主题
+func (c *Container) initEventLog() error {
+	store, err := eventlog.NewStore(c.DB)
+	if err != nil {
+		return err
+	}
+	bus := c.Bus
+	// 登录事件决定之后事件归属的账号
+	err = bus.Subscribe(eventbus.TopicUserLoggedIn, func(userInfo interface{}) {
+		if info, ok := userInfo.(entities.UserInfo); ok {
+			store.SetAccount(info.UserId)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	err = store.Record(bus, eventbus.TopicUserLoggedIn, func() interface{} { return &entities.UserInfo{} })
+	if err != nil {
+		return err
+	}
+	err = store.Record(bus, eventbus.TopicUserLoggedOut, func() interface{} { return new(string) })
+	if err != nil {
+		return err
+	}
+	err = store.Record(bus, eventbus.TopicFeedsSeen, func() interface{} { return &[]map[string]interface{}{} })
+	if err != nil {
+		return err
+	}
+	err = store.Record(bus, eventbus.TopicJobFinished, func() interface{} { return &scheduler.RunFinished{} })
+	if err != nil {
+		return err
+	}
+	for _, topic := range []string{eventbus.TopicRiskChallenged, eventbus.TopicRiskResolved} {
+		err = store.Record(bus, topic, func() interface{} { return &risk.Challenge{} })
+		if err != nil {
+			return err
+		}
+	}
+	err = store.Record(bus, eventbus.TopicSelectorsAlert, func() interface{} { return &selectors.Alert{} })
+	if err != nil {
+		return err
+	}
+	for _, topic := range []string{eventbus.TopicNoteOpened, eventbus.TopicNoteClosed} {
+		err = store.Record(bus, topic, func() interface{} { return &note.NoteDetail{} })
+		if err != nil {
+			return err
+		}
+	}
+	err = store.Record(bus, eventbus.TopicCommentsCrawled, func() interface{} { return &note.CommentsCrawled{} })
+	if err != nil {
+		return err
+	}
+	err = store.Record(bus, eventbus.TopicProfileRefreshed, func() interface{} { return &profile.ProfileDetail{} })
+	if err != nil {
+		return err
+	}
+	store.AddRetention(eventlog.DefaultRetention)
+	store.StartRetention(time.Hour)
+	c.EventLog = store
+	return nil
+}
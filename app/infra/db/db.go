@@ -1,63 +1,50 @@
 package db
 
 import (
-	"log"
-	"sync"
-
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 )
 
-var (
-	instance *gorm.DB
-	once     sync.Once
-)
-
-// GetDBInstance 返回全局唯一的数据库连接实例
-func Init(dbPath string) (*gorm.DB, error) {
-	var initErr error
-	once.Do(func() {
-		var err error
-		instance, err = connectDatabase(dbPath)
-		if err != nil {
-			initErr = err
-		}
-	})
-
-	if initErr != nil {
-		return nil, initErr
-	}
-
-	return instance, nil
+// Pool 连接池配置
+type Pool struct {
+	MaxIdleConns int // 空闲连接池中连接的最大数量
+	MaxOpenConns int // 打开数据库连接的最大数量
 }
 
-// connectDatabase 创建并配置数据库连接
-func connectDatabase(dbPath string) (*gorm.DB, error) {
+// Open 打开 dbPath 指向的数据库，每次调用都创建新的连接，由调用方负责 Close
+func Open(dbPath string, pool Pool) (*gorm.DB, error) {
 	// 使用 github.com/glebarez/sqlite 驱动连接 SQLite 数据库
 	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ApplyPool(db, pool); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// ApplyPool 修改连接池配置，可以在运行中调用
+func ApplyPool(db *gorm.DB, pool Pool) error {
 	// 获取通用数据库对象 sql.DB 以进行连接池配置
 	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	// 配置连接池
-	// 设置空闲连接池中连接的最大数量
-	sqlDB.SetMaxIdleConns(10)
-	// 设置打开数据库连接的最大数量
-	sqlDB.SetMaxOpenConns(100)
-
-	return db, nil
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	return nil
 }
 
-// GetDB 获取数据库实例（不带路径参数，适用于已初始化后获取实例）
-func GetDB() *gorm.DB {
-	if instance == nil {
-		log.Fatal("Database instance is not initialized. Call GetDBInstance first.")
+// Close 关闭数据库连接，db 为 nil 时什么也不做
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
-	return instance
+	return sqlDB.Close()
 }
@@ -0,0 +1,22 @@
+// Package dbtest 测试使用的临时数据库。
+package dbtest
+
+import (
+	"path/filepath"
+	"testing"
+	"xiaohongshu/app/infra/db"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// Open 在测试的临时目录中创建 SQLite 数据库，测试结束时关闭
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close(conn) })
+	return conn
+}
@@ -4,8 +4,44 @@ import (
 	"github.com/asaskevich/EventBus"
 )
 
-var GlobalEventBus EventBus.Bus
+// 事件主题
+const (
+	// TopicUserLoggedIn 用户登录成功，载荷为 entities.UserInfo
+	TopicUserLoggedIn = "user-logged-in"
+	// TopicUserLoggedOut 用户退出登录，载荷为账号 ID
+	TopicUserLoggedOut = "user-logged-out"
+	// TopicLoginStatus 扫码登录状态变化，载荷为 login.Status
+	TopicLoginStatus = "login:status"
+	// TopicFeedsSeen 浏览到的推荐列表，载荷为 []map[string]interface{}
+	// 频道抓取和关键词搜索的条目带有 channel 或 keyword 字段
+	TopicFeedsSeen = "explore:feeds-seen"
+	// TopicJobFinished 定时任务执行结束，载荷为 scheduler.RunFinished
+	TopicJobFinished = "scheduler:job-finished"
+	// TopicSessionRestarted 浏览器会话重新打开（例如导出 HAR 后），载荷为新的 playwright.Page
+	TopicSessionRestarted = "session:restarted"
+	// TopicConfigChanged 配置修改并保存，载荷为 config.Change
+	TopicConfigChanged = "config:changed"
+	// TopicSelectorsReloaded 选择器覆盖文件重新加载，载荷为 selectors.Info
+	TopicSelectorsReloaded = "selectors:reloaded"
+	// TopicSelectorsAlert 选择器命中率过低或健康检查失败，载荷为 selectors.Alert
+	TopicSelectorsAlert = "selectors:alert"
+	// TopicRiskChallenged 检测到风控（验证码、登录墙、访问频繁），载荷为 risk.Challenge
+	TopicRiskChallenged = "risk:challenged"
+	// TopicRiskResolved 风控已被人工解除，载荷为 risk.Challenge
+	TopicRiskResolved = "risk:resolved"
+	// TopicNoteOpened 笔记详情打开并提取完成，载荷为 note.NoteDetail
+	TopicNoteOpened = "note:opened"
+	// TopicNoteClosed 笔记详情关闭，载荷为 note.NoteDetail
+	TopicNoteClosed = "note:closed"
+	// TopicCommentsCrawled 笔记评论抓取完成，载荷为 note.CommentsCrawled
+	TopicCommentsCrawled = "note:comments-crawled"
+	// TopicProfileRefreshed 用户主页刷新完成，载荷为 profile.ProfileDetail
+	TopicProfileRefreshed = "profile:refreshed"
+	// TopicOCRProgress 笔记图片的文字识别进度，载荷为 ocr.Progress
+	TopicOCRProgress = "ocr:progress"
+)
 
-func init() {
-	GlobalEventBus = EventBus.New()
+// New 创建事件总线，应用的事件总线由 container.Container 创建并传给各个模块
+func New() EventBus.Bus {
+	return EventBus.New()
 }
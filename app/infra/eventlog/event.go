@@ -0,0 +1,34 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event 事件日志中的一条记录，只追加不修改
+type Event struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Topic     string    `gorm:"size:128;not null;index:idx_event_logs_topic_time,priority:1" json:"topic"`
+	Account   string    `gorm:"size:64;index" json:"account"`
+	Payload   string    `gorm:"type:text" json:"payload"`
+	CreatedAt time.Time `gorm:"not null;index:idx_event_logs_topic_time,priority:2" json:"createdAt"`
+}
+
+// TableName 指定事件日志表名
+func (Event) TableName() string {
+	return "event_logs"
+}
+
+// Decode 将事件载荷解析到 v 中
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(e.Payload), v)
+}
+
+// Query 事件查询条件，零值字段表示不过滤
+type Query struct {
+	Topics  []string  // 主题列表
+	Account string    // 账号
+	Since   time.Time // 起始时间（包含）
+	Until   time.Time // 结束时间（不包含）
+	Limit   int       // 最大返回条数
+}
@@ -0,0 +1,81 @@
+package eventlog
+
+import (
+	"fmt"
+	"time"
+	"xiaohongshu/app/infra/logging"
+)
+
+var logger = logging.Component("eventlog")
+
+// RetentionPolicy 事件保留策略
+type RetentionPolicy struct {
+	Topic    string        // 作用的主题，为空表示所有主题
+	MaxAge   time.Duration // 超过该时长的事件会被删除，0 表示不限制
+	MaxCount int           // 最多保留的条数（保留最新的），0 表示不限制
+}
+
+// DefaultRetention 默认保留最近 90 天的事件
+var DefaultRetention = RetentionPolicy{MaxAge: 90 * 24 * time.Hour}
+
+// AddRetention 添加一条保留策略
+func (s *Store) AddRetention(policy RetentionPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies = append(s.policies, policy)
+}
+
+// Prune 按所有保留策略清理过期事件，返回删除的条数
+func (s *Store) Prune() (int64, error) {
+	s.mu.RLock()
+	policies := make([]RetentionPolicy, len(s.policies))
+	copy(policies, s.policies)
+	s.mu.RUnlock()
+
+	var total int64
+	for _, policy := range policies {
+		if policy.MaxAge > 0 {
+			tx := s.db.Where("created_at < ?", time.Now().Add(-policy.MaxAge))
+			if policy.Topic != "" {
+				tx = tx.Where("topic = ?", policy.Topic)
+			}
+			result := tx.Delete(&Event{})
+			if result.Error != nil {
+				return total, fmt.Errorf("failed to prune events older than %s: %w", policy.MaxAge, result.Error)
+			}
+			total += result.RowsAffected
+		}
+		if policy.MaxCount > 0 {
+			keep := s.db.Model(&Event{}).Select("id").Order("created_at DESC, id DESC").Limit(policy.MaxCount)
+			tx := s.db.Model(&Event{})
+			if policy.Topic != "" {
+				keep = keep.Where("topic = ?", policy.Topic)
+				tx = tx.Where("topic = ?", policy.Topic)
+			}
+			result := tx.Where("id NOT IN (?)", keep).Delete(&Event{})
+			if result.Error != nil {
+				return total, fmt.Errorf("failed to prune events beyond %d: %w", policy.MaxCount, result.Error)
+			}
+			total += result.RowsAffected
+		}
+	}
+	return total, nil
+}
+
+// StartRetention 立即执行一次清理，之后每隔 interval 执行一次，直到 Close
+func (s *Store) StartRetention(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Prune(); err != nil {
+				logger.Error("failed to prune event log", "error", err)
+			}
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
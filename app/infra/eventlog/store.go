@@ -0,0 +1,158 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/asaskevich/EventBus"
+	"gorm.io/gorm"
+)
+
+// PayloadFactory 返回用于解析载荷的新指针，例如 func() interface{} { return &entities.UserInfo{} }
+type PayloadFactory func() interface{}
+
+// Store 基于 SQLite 的只追加事件存储
+type Store struct {
+	db        *gorm.DB
+	mu        sync.RWMutex
+	account   string
+	factories map[string]PayloadFactory
+	policies  []RetentionPolicy
+	stop      chan struct{}
+	stopOnce  sync.Once
+	closed    bool // 关闭后不再记录总线上的事件
+}
+
+// NewStore 创建事件存储并迁移表结构
+func NewStore(db *gorm.DB) (*Store, error) {
+	if err := db.AutoMigrate(&Event{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate event log: %w", err)
+	}
+	return &Store{
+		db:        db,
+		factories: make(map[string]PayloadFactory),
+		stop:      make(chan struct{}),
+	}, nil
+}
+
+// SetAccount 设置当前账号，之后记录的事件都会归属到该账号
+func (s *Store) SetAccount(account string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account = account
+}
+
+// Account 返回当前账号
+func (s *Store) Account() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.account
+}
+
+// Append 追加一条事件
+func (s *Store) Append(topic, account string, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload of %s: %w", topic, err)
+	}
+	event := &Event{
+		Topic:     topic,
+		Account:   account,
+		Payload:   string(data),
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.Create(event).Error; err != nil {
+		return nil, fmt.Errorf("failed to append event %s: %w", topic, err)
+	}
+	return event, nil
+}
+
+// Record 订阅 bus 上的 topic，并把每次发布的载荷写入事件日志
+// factory 用于回放时还原载荷类型，为 nil 时回放发布 Event 本身
+func (s *Store) Record(bus EventBus.Bus, topic string, factory PayloadFactory) error {
+	s.mu.Lock()
+	s.factories[topic] = factory
+	s.mu.Unlock()
+	return bus.Subscribe(topic, func(payload interface{}) {
+		s.mu.RLock()
+		closed := s.closed
+		s.mu.RUnlock()
+		if closed {
+			return
+		}
+		_, _ = s.Append(topic, s.Account(), payload)
+	})
+}
+
+// Query 按主题、账号和时间范围查询事件，按时间正序返回
+func (s *Store) Query(q Query) ([]Event, error) {
+	tx := s.db.Model(&Event{})
+	if len(q.Topics) > 0 {
+		tx = tx.Where("topic IN ?", q.Topics)
+	}
+	if q.Account != "" {
+		tx = tx.Where("account = ?", q.Account)
+	}
+	if !q.Since.IsZero() {
+		tx = tx.Where("created_at >= ?", q.Since)
+	}
+	if !q.Until.IsZero() {
+		tx = tx.Where("created_at < ?", q.Until)
+	}
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+	var events []Event
+	if err := tx.Order("created_at ASC, id ASC").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	return events, nil
+}
+
+// ReplayFunc 按时间顺序把查询到的事件交给 handler，handler 返回错误时中止
+func (s *Store) ReplayFunc(q Query, handler func(Event) error) (int, error) {
+	events, err := s.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range events {
+		if err := handler(event); err != nil {
+			return i, fmt.Errorf("replay stopped at event %d: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// Replay 把时间窗口内的事件重新发布到 bus 上
+// 载荷按 Record 时登记的类型还原；不要传入正在被 Record 的总线，否则事件会被重复记录
+func (s *Store) Replay(bus EventBus.Bus, q Query) (int, error) {
+	return s.ReplayFunc(q, func(event Event) error {
+		s.mu.RLock()
+		factory := s.factories[event.Topic]
+		s.mu.RUnlock()
+		if factory == nil {
+			bus.Publish(event.Topic, event)
+			return nil
+		}
+		payload := factory()
+		if err := event.Decode(payload); err != nil {
+			return err
+		}
+		bus.Publish(event.Topic, reflect.Indirect(reflect.ValueOf(payload)).Interface())
+		return nil
+	})
+}
+
+// Close 停止后台保留策略任务，之后不再记录总线上的事件
+// 事件是同步写入的，Close 返回时已记录的事件都已落盘
+func (s *Store) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package eventlog
+
+import (
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+
+	"github.com/asaskevich/EventBus"
+)
+
+type payload struct {
+	Name string `json:"name"`
+}
+
+func newTestStore(t *testing.T) *Store {
+	db := dbtest.Open(t)
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return store
+}
+
+func TestRecordQueryAndReplay(t *testing.T) {
+	store := newTestStore(t)
+	bus := EventBus.New()
+	if err := store.Record(bus, "login", func() interface{} { return &payload{} }); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	store.SetAccount("a1")
+	bus.Publish("login", payload{Name: "first"})
+	store.SetAccount("a2")
+	bus.Publish("login", payload{Name: "second"})
+	if _, err := store.Append("other", "a1", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	events, err := store.Query(Query{Topics: []string{"login"}})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 2 || events[0].Account != "a1" || events[1].Account != "a2" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	events, err = store.Query(Query{Account: "a1", Until: time.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events for a1, got %d", len(events))
+	}
+
+	replayBus := EventBus.New()
+	var names []string
+	_ = replayBus.Subscribe("login", func(p interface{}) {
+		if v, ok := p.(payload); ok {
+			names = append(names, v.Name)
+		}
+	})
+	n, err := store.Replay(replayBus, Query{Topics: []string{"login"}})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if n != 2 || len(names) != 2 || names[0] != "first" || names[1] != "second" {
+		t.Fatalf("unexpected replay: n=%d names=%v", n, names)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store := newTestStore(t)
+	old := Event{Topic: "feed", Payload: "{}", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	if err := store.db.Create(&old).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := store.Append("feed", "", i); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	store.AddRetention(RetentionPolicy{MaxAge: 24 * time.Hour})
+	store.AddRetention(RetentionPolicy{Topic: "feed", MaxCount: 2})
+	deleted, err := store.Prune()
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("expected 2 deleted, got %d", deleted)
+	}
+	events, _ := store.Query(Query{})
+	if len(events) != 2 || events[1].Payload != "2" {
+		t.Fatalf("unexpected remaining events: %+v", events)
+	}
+}
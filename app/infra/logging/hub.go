@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+)
+
+// Entry 一条解析后的 JSON 日志，包含 time、level、msg 以及各个字段
+type Entry map[string]interface{}
+
+// Level 日志级别，无法解析时视为 info
+func (e Entry) Level() slog.Level {
+	text, _ := e[slog.LevelKey].(string)
+	l, err := ParseLevel(text)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// Hub 保存最近的日志并推送给订阅者
+type Hub struct {
+	mu          sync.Mutex
+	entries     []Entry
+	capacity    int
+	subscribers map[int]chan Entry
+	nextID      int
+}
+
+func NewHub(capacity int) *Hub {
+	return &Hub{
+		capacity:    capacity,
+		subscribers: make(map[int]chan Entry),
+	}
+}
+
+// Write 接收 JSON 处理器输出的一行日志
+func (h *Hub) Write(p []byte) (int, error) {
+	var entry Entry
+	if err := json.Unmarshal(p, &entry); err != nil {
+		return len(p), nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.capacity {
+		h.entries = h.entries[len(h.entries)-h.capacity:]
+	}
+	for _, ch := range h.subscribers {
+		// 订阅者处理不过来时丢弃，避免阻塞日志输出
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Recent 最近 limit 条不低于 minLevel 的日志，按时间先后排列；limit 不大于 0 时返回全部
+func (h *Hub) Recent(limit int, minLevel slog.Level) []Entry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make([]Entry, 0)
+	for i := len(h.entries) - 1; i >= 0 && (limit <= 0 || len(result) < limit); i-- {
+		if h.entries[i].Level() >= minLevel {
+			result = append(result, h.entries[i])
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// Subscribe 订阅新日志，fn 在独立的 goroutine 中调用，返回取消订阅的函数
+func (h *Hub) Subscribe(fn func(Entry)) func() {
+	ch := make(chan Entry, 256)
+	h.mu.Lock()
+	id := h.nextID
+	h.nextID++
+	h.subscribers[id] = ch
+	h.mu.Unlock()
+
+	go func() {
+		for entry := range ch {
+			fn(entry)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, id)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package logging 基于 log/slog 的结构化日志。
+// 日志以 JSON 格式同时写入标准错误、缓存目录下按大小轮转的文件，以及供前端日志面板读取的内存缓冲。
+// 每个容器创建自己的 Logger，级别和内存缓冲互不影响；各模块通过 Component 获取带 component 字段的日志器，
+// 同一次用户操作的日志通过 correlation 字段关联。
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Options 日志配置
+type Options struct {
+	Dir      string     // 日志目录，为空时不写文件
+	Level    slog.Level // 最低输出级别
+	MaxSize  int64      // 单个日志文件的最大字节数
+	MaxFiles int        // 保留的历史日志文件数
+}
+
+// DefaultOptions 默认配置，dir 为日志目录
+func DefaultOptions(dir string) Options {
+	return Options{
+		Dir:      dir,
+		Level:    slog.LevelInfo,
+		MaxSize:  10 * 1024 * 1024,
+		MaxFiles: 5,
+	}
+}
+
+// FileName 日志文件名，历史文件依次为 app.log.1、app.log.2……
+const FileName = "app.log"
+
+// Logger 一组日志输出：最低级别、输出目标和最近日志的缓冲，由容器创建，容器之间互不影响
+// 嵌入的 *slog.Logger 带有 component=app 字段，各模块使用 Component 获取自己的日志器
+type Logger struct {
+	*slog.Logger
+	level   *slog.LevelVar
+	hub     *Hub
+	current atomic.Pointer[slog.Handler]
+}
+
+// New 创建输出到标准错误的日志，Open 之后同时写入日志文件
+func New() *Logger {
+	l := &Logger{
+		level: new(slog.LevelVar),
+		hub:   NewHub(1000),
+	}
+	l.setOutput(os.Stderr)
+	l.Logger = l.Component("app")
+	return l
+}
+
+// setOutput 替换根处理器，已创建的组件日志器随之生效
+func (l *Logger) setOutput(w io.Writer) {
+	var handler slog.Handler = slog.NewJSONHandler(io.MultiWriter(w, l.hub), &slog.HandlerOptions{Level: l.level})
+	handler = &contextHandler{Handler: handler}
+	l.current.Store(&handler)
+}
+
+func (l *Logger) root() slog.Handler {
+	return *l.current.Load()
+}
+
+// Open 按配置设置日志输出，返回的 Closer 用于关闭日志文件
+func (l *Logger) Open(options Options) (io.Closer, error) {
+	l.level.Set(options.Level)
+	if options.Dir == "" {
+		l.setOutput(os.Stderr)
+		return io.NopCloser(nil), nil
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return nil, err
+	}
+	file, err := OpenRotatingFile(filepath.Join(options.Dir, FileName), options.MaxSize, options.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	l.setOutput(io.MultiWriter(os.Stderr, file))
+	return file, nil
+}
+
+// SetLevel 修改最低输出级别，立即生效
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// Level 当前最低输出级别
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
+// Logs 最近日志的内存缓冲，供前端日志面板使用
+func (l *Logger) Logs() *Hub {
+	return l.hub
+}
+
+// Component 返回写入这组输出、带 component 字段的日志器
+func (l *Logger) Component(name string) *slog.Logger {
+	return slog.New(&lazyHandler{root: l.root}).With("component", name)
+}
+
+// SetDefault 把 slog 的默认日志器指向这组输出，包级的 Component 日志器随之写入这里
+// 由创建主容器的应用调用，同一进程中的其他容器（例如测试）不调用
+func (l *Logger) SetDefault() {
+	slog.SetDefault(slog.New(&lazyHandler{root: l.root}))
+}
+
+// ParseLevel 解析 debug、info、warn、error（不区分大小写）
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return l, err
+}
+
+// Component 返回带 component 字段的日志器，可以在包级变量中创建
+// 日志写入 slog 的默认日志器，应用调用 Logger.SetDefault 后即写入主容器的日志
+func Component(name string) *slog.Logger {
+	return slog.New(&lazyHandler{root: defaultRoot}).With("component", name)
+}
+
+func defaultRoot() slog.Handler {
+	return slog.Default().Handler()
+}
+
+type correlationKey struct{}
+
+// WithCorrelation 为一次用户操作或任务执行分配关联 id，ctx 已有 id 时原样返回
+func WithCorrelation(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationKey{}, newID())
+}
+
+// CorrelationID 返回 ctx 中的关联 id，没有时为空
+func CorrelationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+func newID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// contextHandler 把 ctx 中的关联 id 写入每条日志
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		record.AddAttrs(slog.String("correlation", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// lazyHandler 每次输出时使用当前的根处理器，
+// 使包级变量中创建的日志器在 Open 或 SetDefault 之后也写入新的输出
+type lazyHandler struct {
+	root func() slog.Handler
+	wrap []func(slog.Handler) slog.Handler
+}
+
+func (h *lazyHandler) handler() slog.Handler {
+	handler := h.root()
+	for _, wrap := range h.wrap {
+		handler = wrap(handler)
+	}
+	return handler
+}
+
+func (h *lazyHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.root().Enabled(ctx, l)
+}
+
+func (h *lazyHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler().Handle(ctx, record)
+}
+
+func (h *lazyHandler) with(wrap func(slog.Handler) slog.Handler) *lazyHandler {
+	next := &lazyHandler{root: h.root, wrap: make([]func(slog.Handler) slog.Handler, 0, len(h.wrap)+1)}
+	next.wrap = append(append(next.wrap, h.wrap...), wrap)
+	return next
+}
+
+func (h *lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *lazyHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	file, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 backups to be kept")
+	}
+}
+
+func TestComponentLoggerWritesToFileAndHub(t *testing.T) {
+	// 在 Open 之前创建，验证包级日志器也会写入日志文件
+	logger := Component("test")
+	dir := t.TempDir()
+	l := New()
+	closer, err := l.Open(DefaultOptions(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	l.SetDefault()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		_ = closer.Close()
+	})
+
+	ctx := WithCorrelation(context.Background())
+	id := CorrelationID(ctx)
+	if id == "" || CorrelationID(WithCorrelation(ctx)) != id {
+		t.Fatalf("expected stable correlation id, got %q", id)
+	}
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "navigate", "url", "https://example.com")
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Contains(content, "hidden") {
+		t.Errorf("debug log should be filtered at info level: %s", content)
+	}
+	for _, want := range []string{`"msg":"navigate"`, `"component":"test"`, `"correlation":"` + id + `"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %s in %s", want, content)
+		}
+	}
+
+	recent := l.Logs().Recent(1, slog.LevelInfo)
+	if len(recent) != 1 || recent[0]["msg"] != "navigate" || recent[0]["correlation"] != id {
+		t.Fatalf("unexpected recent logs: %+v", recent)
+	}
+	if len(l.Logs().Recent(0, slog.LevelError)) != 0 {
+		t.Fatalf("expected no error logs")
+	}
+}
+
+func TestLoggersAreIsolated(t *testing.T) {
+	a, b := New(), New()
+	a.SetLevel(slog.LevelDebug)
+	if b.Level() != slog.LevelInfo {
+		t.Fatalf("level leaked into b: %v", b.Level())
+	}
+	a.Component("a").Debug("only in a")
+	b.Debug("filtered in b")
+	if recent := a.Logs().Recent(0, slog.LevelDebug); len(recent) != 1 || recent[0]["msg"] != "only in a" {
+		t.Fatalf("unexpected logs in a: %+v", recent)
+	}
+	if recent := b.Logs().Recent(0, slog.LevelDebug); len(recent) != 0 {
+		t.Fatalf("logs leaked into b: %+v", recent)
+	}
+}
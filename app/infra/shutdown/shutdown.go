@@ -0,0 +1,100 @@
+// Package shutdown 按顺序停止应用的各个模块，并汇总未能正常停止的部分。
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSkipped 总超时后未执行的步骤
+var ErrSkipped = errors.New("skipped: shutdown timed out")
+
+// Step 一个停止步骤，Run 应在 ctx 结束后尽快返回
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result 一个步骤的执行结果
+type Result struct {
+	Name     string        `json:"name"`
+	Error    string        `json:"error,omitempty"`
+	TimedOut bool          `json:"timedOut"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report 停止过程的汇总
+type Report struct {
+	Results []Result      `json:"results"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Failed 出错、超时或被跳过的步骤
+func (r Report) Failed() []Result {
+	var failed []Result
+	for _, result := range r.Results {
+		if result.Error != "" || result.TimedOut {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Options 超时设置
+type Options struct {
+	Timeout     time.Duration // 整个停止过程的超时，超时后剩余的步骤不再执行
+	StepTimeout time.Duration // 单个步骤的超时，超时后放弃等待并继续下一步
+}
+
+// DefaultOptions 默认超时
+var DefaultOptions = Options{Timeout: 15 * time.Second, StepTimeout: 5 * time.Second}
+
+// Run 依次执行 steps，单个步骤出错或超时不影响后续步骤
+func Run(ctx context.Context, options Options, steps []Step) Report {
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	report := Report{Results: make([]Result, 0, len(steps))}
+	for _, step := range steps {
+		if ctx.Err() != nil {
+			report.Results = append(report.Results, Result{Name: step.Name, Error: ErrSkipped.Error(), TimedOut: true})
+			continue
+		}
+		report.Results = append(report.Results, runStep(ctx, options.StepTimeout, step))
+	}
+	report.Elapsed = time.Since(started)
+	return report
+}
+
+// runStep 在独立的 goroutine 中执行，超时后不再等待，避免卡住的步骤阻塞退出
+func runStep(ctx context.Context, timeout time.Duration, step Step) Result {
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- step.Run(ctx)
+	}()
+
+	result := Result{Name: step.Name}
+	select {
+	case err := <-done:
+		if err != nil {
+			result.Error = err.Error()
+		}
+	case <-ctx.Done():
+		result.TimedOut = true
+		result.Error = ctx.Err().Error()
+	}
+	result.Duration = time.Since(started)
+	return result
+}
@@ -0,0 +1,51 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunReportsFailuresAndTimeouts(t *testing.T) {
+	var order []string
+	step := func(name string, fn func(ctx context.Context) error) Step {
+		return Step{Name: name, Run: func(ctx context.Context) error {
+			order = append(order, name)
+			return fn(ctx)
+		}}
+	}
+	hang := make(chan struct{})
+	defer close(hang)
+
+	report := Run(context.Background(), Options{Timeout: time.Second, StepTimeout: 50 * time.Millisecond}, []Step{
+		step("jobs", func(context.Context) error { return nil }),
+		step("browser", func(context.Context) error { <-hang; return nil }),
+		step("database", func(context.Context) error { return errors.New("locked") }),
+		step("panic", func(context.Context) error { panic("boom") }),
+	})
+
+	if len(order) != 4 || order[0] != "jobs" || order[2] != "database" {
+		t.Fatalf("steps ran out of order: %v", order)
+	}
+	failed := report.Failed()
+	if len(failed) != 3 {
+		t.Fatalf("expected 3 failed steps, got %+v", failed)
+	}
+	if !failed[0].TimedOut || failed[0].Name != "browser" {
+		t.Fatalf("expected browser to time out, got %+v", failed[0])
+	}
+	if failed[1].Error != "locked" || failed[2].Error != "panic: boom" {
+		t.Fatalf("unexpected errors: %+v", failed[1:])
+	}
+}
+
+func TestRunSkipsStepsAfterTimeout(t *testing.T) {
+	report := Run(context.Background(), Options{Timeout: 30 * time.Millisecond, StepTimeout: time.Second}, []Step{
+		{Name: "slow", Run: func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }},
+		{Name: "after", Run: func(context.Context) error { t.Error("step after timeout should not run"); return nil }},
+	})
+	if len(report.Results) != 2 || report.Results[1].Error != ErrSkipped.Error() {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
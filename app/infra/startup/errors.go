@@ -0,0 +1,62 @@
+package startup
+
+// Code 启动错误码
+type Code string
+
+const (
+	CodePathUnavailable    Code = "path-unavailable"    // 无法确定或创建应用目录
+	CodeConfigInvalid      Code = "config-invalid"      // 配置文件无效，已使用默认配置
+	CodeDownloadFailed     Code = "download-failed"     // 驱动或浏览器下载失败，通常是网络问题
+	CodeBrowserUnreachable Code = "browser-unreachable" // 无法连接 CDP 地址上的 Chrome
+	CodeDatabaseFailed     Code = "database-failed"     // 数据库无法打开或迁移失败
+	CodeServiceFailed      Code = "service-failed"      // 服务启动失败
+)
+
+// Action 建议的处理方式
+type Action string
+
+const (
+	ActionRetry        Action = "retry"         // 调用 RetryInitialization 重试
+	ActionOpenSettings Action = "open-settings" // 在设置页面修改配置后重试
+	ActionRestart      Action = "restart"       // 需要重启应用
+)
+
+// Error 带错误码的启动错误
+type Error struct {
+	Stage   Stage  `json:"stage"`
+	Code    Code   `json:"code"`
+	Action  Action `json:"action"`
+	Message string `json:"message"`
+	Hint    string `json:"hint"` // 给用户的处理建议
+}
+
+func (e Error) Error() string {
+	return string(e.Stage) + ": " + e.Message
+}
+
+// Retryable 是否可以不重启应用直接重试
+func (e Error) Retryable() bool {
+	return e.Action != ActionRestart
+}
+
+var stageErrors = map[Stage]Error{
+	StageResolvePaths:    {Code: CodePathUnavailable, Action: ActionRestart, Hint: "检查用户目录的读写权限后重启应用"},
+	StageLoadConfig:      {Code: CodeConfigInvalid, Action: ActionOpenSettings, Hint: "配置文件无效，已使用默认配置，可在设置页面修改"},
+	StageDownloadDriver:  {Code: CodeDownloadFailed, Action: ActionRetry, Hint: "检查网络连接后重试"},
+	StageDownloadBrowser: {Code: CodeDownloadFailed, Action: ActionRetry, Hint: "检查网络连接后重试"},
+	StageLaunchBrowser:   {Code: CodeBrowserUnreachable, Action: ActionRetry, Hint: "使用 --remote-debugging-port=9222 启动 Chrome，或在设置页面修改 browser.cdpUrl 后重试"},
+	StageOpenDatabase:    {Code: CodeDatabaseFailed, Action: ActionRetry, Hint: "确认没有其他实例正在运行后重试"},
+	StageMigrate:         {Code: CodeDatabaseFailed, Action: ActionRetry, Hint: "确认没有其他实例正在运行后重试"},
+	StageStartService:    {Code: CodeServiceFailed, Action: ActionRetry, Hint: "确认浏览器仍然可以访问后重试"},
+}
+
+// NewError 根据出错的阶段确定错误码和处理方式
+func NewError(stage Stage, err error) Error {
+	e, ok := stageErrors[stage]
+	if !ok {
+		e = Error{Code: CodeServiceFailed, Action: ActionRestart}
+	}
+	e.Stage = stage
+	e.Message = err.Error()
+	return e
+}
@@ -0,0 +1,198 @@
+// Package startup 启动进度和启动错误。
+// 启动分为若干阶段，每个阶段开始、下载进度变化和出错时通过 emit 通知前端，
+// 出错的阶段带有错误码和建议的处理方式，可重试的错误由前端调用 RetryInitialization 重新初始化。
+package startup
+
+import (
+	"math"
+	"sync"
+)
+
+// Stage 启动阶段
+type Stage string
+
+const (
+	StageResolvePaths    Stage = "resolve-paths"    // 确定根目录和缓存目录
+	StageLoadConfig      Stage = "load-config"      // 读取配置文件
+	StageDownloadDriver  Stage = "download-driver"  // 下载 playwright 驱动
+	StageDownloadBrowser Stage = "download-browser" // 下载 Chromium
+	StageLaunchBrowser   Stage = "launch-browser"   // 连接浏览器
+	StageOpenDatabase    Stage = "open-database"    // 打开数据库
+	StageMigrate         Stage = "migrate"          // 迁移表结构并创建依赖数据库的模块
+	StageStartService    Stage = "start-service"    // 启动小红书服务
+)
+
+// 发送给前端的事件
+const (
+	EventProgress = "startup-progress"        // 载荷为 Progress
+	EventError    = "startup-error"           // 载荷为 Error
+	EventComplete = "initialization-complete" // 载荷为 Status
+)
+
+// Progress 一个阶段的进度，Total 为 0 表示总量未知
+type Progress struct {
+	Stage   Stage   `json:"stage"`
+	Message string  `json:"message"`
+	Bytes   int64   `json:"bytes"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"` // 0-100，总量未知时为 -1
+	Done    bool    `json:"done"`
+}
+
+// Reporter 记录各阶段的进度和错误并通知前端
+type Reporter struct {
+	mu       sync.Mutex
+	stages   []Progress // 按开始顺序
+	errors   []Error
+	running  bool
+	done     bool
+	emit     func(event string, payload interface{})
+	lastEmit map[Stage]float64 // 上次通知的百分比，用于减少下载进度的通知次数
+}
+
+// Status 当前的启动状态，前端晚于事件加载时用于补齐进度
+type Status struct {
+	Stages  []Progress `json:"stages"`
+	Errors  []Error    `json:"errors"`
+	Running bool       `json:"running"`
+	Done    bool       `json:"done"`
+	Ready   bool       `json:"ready"` // 服务已启动，配置无效等不影响使用的错误不妨碍进入应用
+}
+
+func NewReporter() *Reporter {
+	return &Reporter{lastEmit: make(map[Stage]float64)}
+}
+
+// SetEmitter 设置通知前端的函数，设置之前的进度只记录不通知
+func (r *Reporter) SetEmitter(emit func(event string, payload interface{})) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit = emit
+}
+
+// Begin 开始一轮初始化，清除上一轮的错误，已完成的阶段保留
+// 已有一轮在进行时返回 false
+func (r *Reporter) Begin() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running {
+		return false
+	}
+	r.running = true
+	r.done = false
+	r.errors = nil
+	return true
+}
+
+// Start 开始一个阶段
+func (r *Reporter) Start(stage Stage, message string) {
+	r.Update(Progress{Stage: stage, Message: message, Percent: -1})
+}
+
+// Complete 一个阶段完成，未开始的阶段忽略
+func (r *Reporter) Complete(stage Stage) {
+	r.mu.Lock()
+	i := r.index(stage)
+	if i < 0 {
+		r.mu.Unlock()
+		return
+	}
+	progress := r.stages[i]
+	progress.Done = true
+	progress.Percent = 100
+	r.mu.Unlock()
+	r.Update(progress)
+}
+
+// Started 阶段是否已经开始
+func (r *Reporter) Started(stage Stage) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.index(stage) >= 0
+}
+
+// Update 更新一个阶段的进度，Total 大于 0 时根据 Bytes 计算百分比
+// 下载进度每增加 1% 才通知一次
+func (r *Reporter) Update(progress Progress) {
+	if progress.Total > 0 && !progress.Done {
+		progress.Percent = math.Min(100, float64(progress.Bytes)*100/float64(progress.Total))
+	}
+	r.mu.Lock()
+	if i := r.index(progress.Stage); i >= 0 {
+		if progress.Message == "" {
+			progress.Message = r.stages[i].Message
+		}
+		r.stages[i] = progress
+	} else {
+		r.stages = append(r.stages, progress)
+	}
+	last, seen := r.lastEmit[progress.Stage]
+	if seen && !progress.Done && progress.Percent >= 0 && math.Floor(progress.Percent) <= math.Floor(last) {
+		r.mu.Unlock()
+		return
+	}
+	r.lastEmit[progress.Stage] = progress.Percent
+	emit := r.emit
+	r.mu.Unlock()
+	if emit != nil {
+		emit(EventProgress, progress)
+	}
+}
+
+// Fail 记录阶段出错，返回带错误码的错误
+func (r *Reporter) Fail(stage Stage, err error) Error {
+	e := NewError(stage, err)
+	r.mu.Lock()
+	r.errors = append(r.errors, e)
+	emit := r.emit
+	r.mu.Unlock()
+	if emit != nil {
+		emit(EventError, e)
+	}
+	return e
+}
+
+// Finish 结束一轮初始化并通知前端
+func (r *Reporter) Finish() Status {
+	r.mu.Lock()
+	r.running = false
+	r.done = true
+	emit := r.emit
+	r.mu.Unlock()
+	status := r.Status()
+	if emit != nil {
+		emit(EventComplete, status)
+	}
+	return status
+}
+
+// Status 当前的启动状态
+func (r *Reporter) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(StageStartService)
+	return Status{
+		Stages:  append([]Progress{}, r.stages...),
+		Errors:  append([]Error{}, r.errors...),
+		Running: r.running,
+		Done:    r.done,
+		Ready:   r.done && i >= 0 && r.stages[i].Done,
+	}
+}
+
+// Completed 阶段是否已经完成，重试时跳过已完成的阶段
+func (r *Reporter) Completed(stage Stage) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(stage)
+	return i >= 0 && r.stages[i].Done
+}
+
+func (r *Reporter) index(stage Stage) int {
+	for i, progress := range r.stages {
+		if progress.Stage == stage {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,90 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReporterThrottlesDownloadProgress(t *testing.T) {
+	var events []Progress
+	r := NewReporter()
+	r.SetEmitter(func(event string, payload interface{}) {
+		if event == EventProgress {
+			events = append(events, payload.(Progress))
+		}
+	})
+	r.Begin()
+	r.Start(StageDownloadBrowser, "下载浏览器")
+	for bytes := int64(0); bytes <= 1000; bytes += 2 {
+		r.Update(Progress{Stage: StageDownloadBrowser, Bytes: bytes, Total: 1000})
+	}
+	r.Complete(StageDownloadBrowser)
+
+	// 开始、每 1% 一次、完成
+	if len(events) != 1+101+1 {
+		t.Fatalf("expected 103 progress events, got %d", len(events))
+	}
+	last := events[len(events)-1]
+	if !last.Done || last.Percent != 100 || last.Message != "下载浏览器" {
+		t.Fatalf("unexpected final progress: %+v", last)
+	}
+	if !r.Completed(StageDownloadBrowser) || r.Completed(StageLaunchBrowser) {
+		t.Fatalf("unexpected completed stages: %+v", r.Status().Stages)
+	}
+}
+
+func TestReporterRetryKeepsCompletedStages(t *testing.T) {
+	r := NewReporter()
+	if !r.Begin() || r.Begin() {
+		t.Fatal("expected only one round to run at a time")
+	}
+	r.Start(StageOpenDatabase, "打开数据库")
+	r.Complete(StageOpenDatabase)
+	r.Start(StageStartService, "启动服务")
+	r.Fail(StageStartService, errors.New("browser closed"))
+	// 未开始的阶段不会被标记为完成
+	r.Complete(StageMigrate)
+
+	status := r.Finish()
+	if !status.Done || status.Running || status.Ready || len(status.Errors) != 1 {
+		t.Fatalf("unexpected status after failure: %+v", status)
+	}
+	if r.Started(StageMigrate) {
+		t.Fatal("complete must not start a stage")
+	}
+
+	if !r.Begin() {
+		t.Fatal("expected retry to begin")
+	}
+	if status := r.Status(); len(status.Errors) != 0 || !r.Completed(StageOpenDatabase) {
+		t.Fatalf("retry should clear errors and keep completed stages: %+v", status)
+	}
+	r.Complete(StageStartService)
+	if status := r.Finish(); !status.Ready {
+		t.Fatalf("expected ready after service started: %+v", status)
+	}
+}
+
+func TestNewErrorMapsStages(t *testing.T) {
+	cases := []struct {
+		stage  Stage
+		code   Code
+		action Action
+	}{
+		{StageResolvePaths, CodePathUnavailable, ActionRestart},
+		{StageLoadConfig, CodeConfigInvalid, ActionOpenSettings},
+		{StageDownloadDriver, CodeDownloadFailed, ActionRetry},
+		{StageLaunchBrowser, CodeBrowserUnreachable, ActionRetry},
+		{StageMigrate, CodeDatabaseFailed, ActionRetry},
+		{Stage("unknown"), CodeServiceFailed, ActionRestart},
+	}
+	for _, c := range cases {
+		e := NewError(c.stage, errors.New("failed"))
+		if e.Code != c.code || e.Action != c.action || e.Stage != c.stage || e.Message != "failed" {
+			t.Errorf("%s: unexpected error %+v", c.stage, e)
+		}
+		if e.Retryable() != (c.action != ActionRestart) {
+			t.Errorf("%s: unexpected retryable", c.stage)
+		}
+	}
+}
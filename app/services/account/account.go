@@ -0,0 +1,80 @@
+package account
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 账号状态
+const (
+	StateActive     = "active"     // 正常
+	StateChallenged = "challenged" // 触发风控，等待人工处理
+	StateLoggedOut  = "logged-out" // 已退出登录
+)
+
+// Account 账号及其当前状态
+type Account struct {
+	ID          string    `gorm:"primaryKey;size:64" json:"id"`
+	Nickname    string    `gorm:"size:128" json:"nickname"`
+	State       string    `gorm:"size:16;not null" json:"state"`
+	StateReason string    `gorm:"type:text" json:"stateReason"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// TableName 指定账号表名
+func (Account) TableName() string {
+	return "accounts"
+}
+
+// Store 账号状态存储
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore 创建账号存储并迁移表结构
+func NewStore(db *gorm.DB) (*Store, error) {
+	if err := db.AutoMigrate(&Account{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate accounts: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Get 获取账号，不存在时返回错误
+func (s *Store) Get(id string) (Account, error) {
+	var account Account
+	err := s.db.First(&account, "id = ?", id).Error
+	return account, err
+}
+
+// Touch 登录后记录账号，已存在时更新昵称，已退出的账号恢复为正常状态
+func (s *Store) Touch(id, nickname string) error {
+	account := Account{ID: id, Nickname: nickname, State: StateActive}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"nickname", "updated_at"}),
+	}).Create(&account).Error
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&Account{}).Where("id = ? AND state = ?", id, StateLoggedOut).
+		Updates(map[string]interface{}{"state": StateActive, "state_reason": ""}).Error
+}
+
+// SetState 更新账号状态，账号不存在时创建
+func (s *Store) SetState(id, state, reason string) error {
+	account := Account{ID: id, State: state, StateReason: reason}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "state_reason", "updated_at"}),
+	}).Create(&account).Error
+}
+
+// ListByState 列出处于某个状态的账号
+func (s *Store) ListByState(state string) ([]Account, error) {
+	var accounts []Account
+	err := s.db.Where("state = ?", state).Find(&accounts).Error
+	return accounts, err
+}
@@ -0,0 +1,314 @@
+// Package analytics 笔记评论分析：评论量随时间的变化、评论最多的用户、点赞最多的评论、
+// 关键词和话题词频以及情感分布。数据来自 archive 中保存的评论树。
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"xiaohongshu/app/services/archive"
+)
+
+// 排行榜的默认长度
+const (
+	DefaultTopCommenters = 10
+	DefaultTopLiked      = 10
+	DefaultTopKeywords   = 30
+	DefaultTopHashtags   = 20
+)
+
+// maxFilledDays 评论量按天补零的最大天数，跨度更大时只返回有评论的日期
+const maxFilledDays = 366
+
+// dateLayout 评论量的日期格式
+const dateLayout = "2006-01-02"
+
+// VolumePoint 一天的评论量
+type VolumePoint struct {
+	Date     string `json:"date"`
+	Comments int    `json:"comments"` // 一级评论
+	Replies  int    `json:"replies"`
+}
+
+// Commenter 评论最多的用户
+type Commenter struct {
+	Author   string `json:"author"`
+	Comments int    `json:"comments"`
+	Likes    int    `json:"likes"` // 评论获得的点赞总数
+}
+
+// LikedComment 点赞最多的评论
+type LikedComment struct {
+	ID        uint      `json:"id"`
+	ParentID  uint      `json:"parentId"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Likes     int       `json:"likes"`
+	Sentiment float64   `json:"sentiment"`
+	Time      time.Time `json:"time"` // 无法解析时为零值
+}
+
+// Term 关键词或话题及出现次数
+type Term struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// SentimentSummary 评论的情感分布
+type SentimentSummary struct {
+	Positive int     `json:"positive"`
+	Neutral  int     `json:"neutral"`
+	Negative int     `json:"negative"`
+	Average  float64 `json:"average"`
+}
+
+// Report 一篇笔记的评论分析结果
+type Report struct {
+	NoteID        string           `json:"noteId"`
+	Title         string           `json:"title"`
+	Author        string           `json:"author"`
+	Comments      int              `json:"comments"` // 所有评论，包括回复
+	TopLevel      int              `json:"topLevel"`
+	Replies       int              `json:"replies"`
+	Commenters    int              `json:"commenters"` // 不同的评论用户数
+	Likes         int              `json:"likes"`      // 评论获得的点赞总数
+	Undated       int              `json:"undated"`    // 无法解析时间的评论数，不计入 Volume
+	CrawledAt     time.Time        `json:"crawledAt"`
+	Volume        []VolumePoint    `json:"volume"`
+	TopCommenters []Commenter      `json:"topCommenters"`
+	TopLiked      []LikedComment   `json:"topLiked"`
+	Keywords      []Term           `json:"keywords"`
+	Hashtags      []Term           `json:"hashtags"`
+	Sentiment     SentimentSummary `json:"sentiment"`
+}
+
+// Service 评论分析服务
+type Service struct {
+	store     *archive.Store
+	segmenter *Segmenter
+	stopwords map[string]bool
+
+	mu     sync.RWMutex
+	scorer SentimentScorer
+}
+
+// NewService 创建评论分析服务，默认使用内置词典分词和情感打分
+func NewService(store *archive.Store) *Service {
+	segmenter := NewSegmenter()
+	stopwords := make(map[string]bool)
+	for _, word := range readLines("stopwords") {
+		stopwords[word] = true
+	}
+	return &Service{
+		store:     store,
+		segmenter: segmenter,
+		stopwords: stopwords,
+		scorer:    NewLexiconScorer(segmenter),
+	}
+}
+
+// Segmenter 分词器，可以用 AddWords 添加领域词汇
+func (s *Service) Segmenter() *Segmenter {
+	return s.segmenter
+}
+
+// SetScorer 替换情感打分器
+func (s *Service) SetScorer(scorer SentimentScorer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scorer = scorer
+}
+
+// NoteAnalytics 分析笔记已保存的评论
+func (s *Service) NoteAnalytics(noteID string) (Report, error) {
+	n, _, err := s.store.Note(noteID)
+	if err != nil {
+		return Report{}, err
+	}
+	comments, err := s.store.Comments(noteID)
+	if err != nil {
+		return Report{}, err
+	}
+	if len(comments) == 0 {
+		return Report{}, fmt.Errorf("笔记 %s 没有已抓取的评论", noteID)
+	}
+	n.ID = noteID
+	return s.Analyze(n, comments), nil
+}
+
+// Analyze 分析一组评论，评论按 archive.Store.Comments 的顺序排列
+func (s *Service) Analyze(n archive.Note, comments []archive.Comment) Report {
+	s.mu.RLock()
+	scorer := s.scorer
+	s.mu.RUnlock()
+
+	report := Report{NoteID: n.ID, Title: n.Title, Author: n.Author}
+	volume := make(map[string]*VolumePoint)
+	commenters := make(map[string]*Commenter)
+	keywords := make(map[string]int)
+	hashtags := make(map[string]int)
+	liked := make([]LikedComment, 0, len(comments))
+	var sentimentSum float64
+
+	for _, c := range comments {
+		report.Comments++
+		if c.ParentID == 0 {
+			report.TopLevel++
+		} else {
+			report.Replies++
+		}
+		if c.CrawledAt.After(report.CrawledAt) {
+			report.CrawledAt = c.CrawledAt
+		}
+		likes := ParseCount(c.Likes)
+		report.Likes += likes
+
+		at, ok := ParseCommentTime(c.DateAddress, c.CrawledAt)
+		if ok {
+			day := at.Format(dateLayout)
+			point := volume[day]
+			if point == nil {
+				point = &VolumePoint{Date: day}
+				volume[day] = point
+			}
+			if c.ParentID == 0 {
+				point.Comments++
+			} else {
+				point.Replies++
+			}
+		} else {
+			report.Undated++
+		}
+
+		if c.Author != "" {
+			commenter := commenters[c.Author]
+			if commenter == nil {
+				commenter = &Commenter{Author: c.Author}
+				commenters[c.Author] = commenter
+			}
+			commenter.Comments++
+			commenter.Likes += likes
+		}
+
+		score := scorer.Score(c.Content)
+		sentimentSum += score
+		switch Classify(score) {
+		case SentimentPositive:
+			report.Sentiment.Positive++
+		case SentimentNegative:
+			report.Sentiment.Negative++
+		default:
+			report.Sentiment.Neutral++
+		}
+
+		for _, tag := range Hashtags(c.Content) {
+			hashtags[tag]++
+		}
+		for _, word := range s.segmenter.Cut(hashtag.ReplaceAllString(c.Content, " ")) {
+			if s.keyword(word) {
+				keywords[word]++
+			}
+		}
+
+		comment := LikedComment{ID: c.ID, ParentID: c.ParentID, Author: c.Author, Content: c.Content, Likes: likes, Sentiment: score}
+		if ok {
+			comment.Time = at
+		}
+		liked = append(liked, comment)
+	}
+
+	report.Commenters = len(commenters)
+	if report.Comments > 0 {
+		report.Sentiment.Average = sentimentSum / float64(report.Comments)
+	}
+	report.Volume = fillDays(volume)
+	report.TopCommenters = topCommenters(commenters, DefaultTopCommenters)
+	sort.SliceStable(liked, func(i, j int) bool { return liked[i].Likes > liked[j].Likes })
+	report.TopLiked = top(liked, DefaultTopLiked)
+	report.Keywords = topTerms(keywords, DefaultTopKeywords)
+	report.Hashtags = topTerms(hashtags, DefaultTopHashtags)
+	return report
+}
+
+// keyword 是否计入关键词：至少两个字，不是停用词、表情代码或纯数字
+func (s *Service) keyword(word string) bool {
+	if utf8.RuneCountInString(word) < 2 || s.stopwords[word] || emojiCode.MatchString(word) {
+		return false
+	}
+	for _, r := range word {
+		if r < '0' || r > '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// fillDays 按日期排列评论量，跨度不超过 maxFilledDays 时补上没有评论的日期
+func fillDays(volume map[string]*VolumePoint) []VolumePoint {
+	days := make([]string, 0, len(volume))
+	for day := range volume {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	points := make([]VolumePoint, 0, len(days))
+	if len(days) == 0 {
+		return points
+	}
+	first, _ := time.Parse(dateLayout, days[0])
+	last, _ := time.Parse(dateLayout, days[len(days)-1])
+	if last.Sub(first) > maxFilledDays*24*time.Hour {
+		for _, day := range days {
+			points = append(points, *volume[day])
+		}
+		return points
+	}
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		day := d.Format(dateLayout)
+		if point, ok := volume[day]; ok {
+			points = append(points, *point)
+		} else {
+			points = append(points, VolumePoint{Date: day})
+		}
+	}
+	return points
+}
+
+func topCommenters(commenters map[string]*Commenter, n int) []Commenter {
+	list := make([]Commenter, 0, len(commenters))
+	for _, c := range commenters {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Comments != list[j].Comments {
+			return list[i].Comments > list[j].Comments
+		}
+		if list[i].Likes != list[j].Likes {
+			return list[i].Likes > list[j].Likes
+		}
+		return list[i].Author < list[j].Author
+	})
+	return top(list, n)
+}
+
+func topTerms(counts map[string]int, n int) []Term {
+	terms := make([]Term, 0, len(counts))
+	for text, c := range counts {
+		terms = append(terms, Term{Text: text, Count: c})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Text < terms[j].Text
+	})
+	return top(terms, n)
+}
+
+func top[T any](list []T, n int) []T {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
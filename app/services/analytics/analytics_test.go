@@ -0,0 +1,127 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/services/archive"
+)
+
+func TestSegmenterCut(t *testing.T) {
+	s := NewSegmenter()
+	got := s.Cut("这个口红颜色太好看了[赞R] YSL 真的绝了")
+	want := []string{"这个", "口红", "颜色", "太", "好看", "了", "[赞r]", "ysl", "真的", "绝了"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Cut = %q, want %q", got, want)
+	}
+	if tags := Hashtags("#秋冬穿搭[话题]# 求链接 #显瘦"); !reflect.DeepEqual(tags, []string{"秋冬穿搭", "显瘦"}) {
+		t.Fatalf("Hashtags = %q", tags)
+	}
+}
+
+func TestLexiconScorer(t *testing.T) {
+	scorer := NewLexiconScorer(nil)
+	cases := map[string]string{
+		"这个颜色太好看了": SentimentPositive,
+		"质量不好，很失望": SentimentNegative,
+		"不太喜欢这个味道": SentimentNegative,
+		"请问在哪里买的":  SentimentNeutral,
+	}
+	for text, want := range cases {
+		if got := Classify(scorer.Score(text)); got != want {
+			t.Errorf("%s: got %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"刚刚":          now,
+		"5分钟前 上海":     now.Add(-5 * time.Minute),
+		"3天前":         now.AddDate(0, 0, -3),
+		"昨天 12:30 北京": time.Date(2026, 3, 9, 12, 30, 0, 0, time.UTC),
+		"02-14":       time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC),
+		"12-25 广东":    time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
+		"2024-05-01":  time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for text, want := range cases {
+		if got, ok := ParseCommentTime(text, now); !ok || !got.Equal(want) {
+			t.Errorf("ParseCommentTime(%q) = %v, %v, want %v", text, got, ok, want)
+		}
+	}
+	if _, ok := ParseCommentTime("北京", now); ok {
+		t.Errorf("ParseCommentTime should fail without a date")
+	}
+	for text, want := range map[string]int{"123": 123, "1.2万": 12000, "10w+": 100000, "赞": 0} {
+		if got := ParseCount(text); got != want {
+			t.Errorf("ParseCount(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestNoteAnalytics(t *testing.T) {
+	db := dbtest.Open(t)
+	store, err := archive.NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	crawledAt := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	comments := []archive.Comment{
+		{NoteID: "n1", Author: "小红", Content: "口红颜色太好看了 #秋冬穿搭[话题]#", DateAddress: "03-07 上海", Likes: "1.2万", CrawledAt: crawledAt},
+		{NoteID: "n1", Author: "小蓝", Content: "口红质量不好，很失望", DateAddress: "昨天 10:00", Likes: "3", CrawledAt: crawledAt},
+		{NoteID: "n1", Author: "小红", Content: "请问口红色号", DateAddress: "IP属地", Likes: "赞", CrawledAt: crawledAt},
+	}
+	if err := db.Create(&comments).Error; err != nil {
+		t.Fatalf("create comments: %v", err)
+	}
+	reply := archive.Comment{NoteID: "n1", ParentID: comments[1].ID, Author: "作者", Content: "抱歉，已经反馈了", DateAddress: "刚刚", Likes: "1", CrawledAt: crawledAt}
+	if err := db.Create(&reply).Error; err != nil {
+		t.Fatalf("create reply: %v", err)
+	}
+
+	service := NewService(store)
+	report, err := service.NoteAnalytics("n1")
+	if err != nil {
+		t.Fatalf("NoteAnalytics: %v", err)
+	}
+	if report.Comments != 4 || report.TopLevel != 3 || report.Replies != 1 || report.Commenters != 3 || report.Undated != 1 {
+		t.Fatalf("counts = %+v", report)
+	}
+	// 03-07 到 03-10 补齐为连续的四天
+	wantVolume := []VolumePoint{{"2026-03-07", 1, 0}, {"2026-03-08", 0, 0}, {"2026-03-09", 1, 0}, {"2026-03-10", 0, 1}}
+	if !reflect.DeepEqual(report.Volume, wantVolume) {
+		t.Fatalf("Volume = %+v", report.Volume)
+	}
+	if report.TopCommenters[0].Author != "小红" || report.TopCommenters[0].Comments != 2 {
+		t.Fatalf("TopCommenters = %+v", report.TopCommenters)
+	}
+	if report.TopLiked[0].Likes != 12000 || report.TopLiked[0].Author != "小红" {
+		t.Fatalf("TopLiked = %+v", report.TopLiked)
+	}
+	if report.Keywords[0] != (Term{Text: "口红", Count: 3}) {
+		t.Fatalf("Keywords = %+v", report.Keywords)
+	}
+	if !reflect.DeepEqual(report.Hashtags, []Term{{Text: "秋冬穿搭", Count: 1}}) {
+		t.Fatalf("Hashtags = %+v", report.Hashtags)
+	}
+	if report.Sentiment.Positive != 1 || report.Sentiment.Negative < 1 {
+		t.Fatalf("Sentiment = %+v", report.Sentiment)
+	}
+
+	// 替换打分器
+	service.SetScorer(constScorer(-1))
+	if report = service.Analyze(archive.Note{ID: "n1"}, comments); report.Sentiment.Negative != 3 {
+		t.Fatalf("Sentiment with custom scorer = %+v", report.Sentiment)
+	}
+	if _, err := service.NoteAnalytics("missing"); err == nil {
+		t.Fatalf("NoteAnalytics should fail without comments")
+	}
+}
+
+type constScorer float64
+
+func (s constScorer) Score(string) float64 {
+	return float64(s)
+}
@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	relativeTime = regexp.MustCompile(`^(\d+)\s*(秒|分钟|小时|天|周)前`)
+	dayTime      = regexp.MustCompile(`^(今天|昨天|前天)(?:\s*(\d{1,2}):(\d{2}))?`)
+	fullDate     = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})`)
+	shortDate    = regexp.MustCompile(`^(\d{1,2})-(\d{1,2})`)
+	count        = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(万|w|千|k)?\+?$`)
+)
+
+// ParseCommentTime 解析评论的日期和地址中的时间，now 为抓取时间
+// 支持 刚刚、5分钟前、3天前、昨天 12:30、05-12、2023-05-12，后面的地址被忽略
+// 只精确到天的时间取当天 0 点
+func ParseCommentTime(text string, now time.Time) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "刚刚") {
+		return now, true
+	}
+	if m := relativeTime.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[string]time.Duration{
+			"秒": time.Second, "分钟": time.Minute, "小时": time.Hour, "天": 24 * time.Hour, "周": 7 * 24 * time.Hour,
+		}[m[2]]
+		return now.Add(-time.Duration(n) * unit), true
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if m := dayTime.FindStringSubmatch(text); m != nil {
+		day := midnight.AddDate(0, 0, -map[string]int{"今天": 0, "昨天": 1, "前天": 2}[m[1]])
+		if m[2] != "" {
+			hour, _ := strconv.Atoi(m[2])
+			minute, _ := strconv.Atoi(m[3])
+			day = day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		}
+		return day, true
+	}
+	if m := fullDate.FindStringSubmatch(text); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location()), true
+	}
+	if m := shortDate.FindStringSubmatch(text); m != nil {
+		month, _ := strconv.Atoi(m[1])
+		day, _ := strconv.Atoi(m[2])
+		t := time.Date(now.Year(), time.Month(month), day, 0, 0, 0, 0, now.Location())
+		// 没有年份的日期不会晚于抓取时间，否则是去年的
+		if t.After(now) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// ParseCount 解析页面上的点赞数、回复数，如 123、1.2万、10w+，"赞"、"回复" 等没有数字的文本为 0
+func ParseCount(text string) int {
+	m := count.FindStringSubmatch(strings.ToLower(strings.TrimSpace(text)))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	switch m[2] {
+	case "万", "w":
+		n *= 10000
+	case "千", "k":
+		n *= 1000
+	}
+	return int(n + 0.5)
+}
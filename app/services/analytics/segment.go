@@ -0,0 +1,198 @@
+package analytics
+
+import (
+	"bufio"
+	"embed"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed dict/*.txt
+var dictFS embed.FS
+
+// emojiCode 小红书的表情代码，如 [赞R]、[哭惹R]
+var emojiCode = regexp.MustCompile(`^\[[^\[\]\s]{1,8}\]`)
+
+// hashtag 话题标签，如 #穿搭[话题]# 或 #穿搭
+var hashtag = regexp.MustCompile(`#([^#\s\[\]@]+)(?:\[话题\])?#?`)
+
+// Segmenter 基于词典的中文分词，对正向和逆向最大匹配的结果取单字较少的一个
+// 词典外连续的 2-4 个单字合并为一个词，英文和数字按空白和标点切分
+type Segmenter struct {
+	words  map[string]bool
+	maxLen int // 词典中最长词的字数
+}
+
+// NewSegmenter 创建使用内置词典的分词器，words 为额外的词
+func NewSegmenter(words ...string) *Segmenter {
+	s := &Segmenter{words: make(map[string]bool)}
+	for _, name := range []string{"words", "stopwords", "positive", "negative", "negators"} {
+		s.AddWords(readLines(name)...)
+	}
+	for _, line := range readLines("intensifiers") {
+		s.AddWords(strings.Fields(line)[0])
+	}
+	s.AddWords(words...)
+	return s
+}
+
+// AddWords 向词典中添加词
+func (s *Segmenter) AddWords(words ...string) {
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		s.words[word] = true
+		if n := utf8.RuneCountInString(word); n > s.maxLen {
+			s.maxLen = n
+		}
+	}
+}
+
+// Cut 把文本切分为词，英文转为小写，表情代码作为一个词，标点和空白被丢弃
+func (s *Segmenter) Cut(text string) []string {
+	var tokens []string
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '[':
+			if m := emojiCode.FindString(string(runes[i:])); m != "" {
+				tokens = append(tokens, m)
+				i += utf8.RuneCountInString(m)
+				continue
+			}
+			i++
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, s.cutCJK(runes[i:j])...)
+			i = j
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			j := i
+			for j < len(runes) && !isCJK(runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsNumber(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// cutCJK 切分一段连续的中日韩文字
+func (s *Segmenter) cutCJK(runes []rune) []string {
+	forward := s.forward(runes)
+	backward := s.backward(runes)
+	if singles(backward) < singles(forward) ||
+		singles(backward) == singles(forward) && len(backward) < len(forward) {
+		return mergeUnknown(backward, s.words)
+	}
+	return mergeUnknown(forward, s.words)
+}
+
+func (s *Segmenter) forward(runes []rune) []string {
+	var tokens []string
+	for i := 0; i < len(runes); {
+		n := s.maxLen
+		if n > len(runes)-i {
+			n = len(runes) - i
+		}
+		for ; n > 1 && !s.words[string(runes[i:i+n])]; n-- {
+		}
+		tokens = append(tokens, string(runes[i:i+n]))
+		i += n
+	}
+	return tokens
+}
+
+func (s *Segmenter) backward(runes []rune) []string {
+	var tokens []string
+	for j := len(runes); j > 0; {
+		n := s.maxLen
+		if n > j {
+			n = j
+		}
+		for ; n > 1 && !s.words[string(runes[j-n:j])]; n-- {
+		}
+		tokens = append(tokens, string(runes[j-n:j]))
+		j -= n
+	}
+	for l, r := 0, len(tokens)-1; l < r; l, r = l+1, r-1 {
+		tokens[l], tokens[r] = tokens[r], tokens[l]
+	}
+	return tokens
+}
+
+// mergeUnknown 把词典外连续的 2-4 个单字合并为一个词，通常是新词或名称
+func mergeUnknown(tokens []string, words map[string]bool) []string {
+	result := make([]string, 0, len(tokens))
+	var run []string
+	flush := func() {
+		if len(run) >= 2 && len(run) <= 4 {
+			result = append(result, strings.Join(run, ""))
+		} else {
+			result = append(result, run...)
+		}
+		run = run[:0]
+	}
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) == 1 && !words[token] {
+			run = append(run, token)
+			continue
+		}
+		flush()
+		result = append(result, token)
+	}
+	flush()
+	return result
+}
+
+func singles(tokens []string) int {
+	n := 0
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// Hashtags 取出文本中的话题标签，不含 # 和 [话题]
+func Hashtags(text string) []string {
+	var tags []string
+	for _, m := range hashtag.FindAllStringSubmatch(text, -1) {
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// readLines 读取内置词典文件，忽略空行和 # 开头的注释
+func readLines(name string) []string {
+	file, err := dictFS.Open("dict/" + name + ".txt")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
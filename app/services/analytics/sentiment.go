@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 情感分类
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+// neutralBand 分数的绝对值小于该值时认为是中性
+const neutralBand = 0.1
+
+// SentimentScorer 情感打分，返回 -1（负面）到 1（正面）之间的分数
+type SentimentScorer interface {
+	Score(text string) float64
+}
+
+// Classify 把分数归为正面、中性或负面
+func Classify(score float64) string {
+	switch {
+	case score >= neutralBand:
+		return SentimentPositive
+	case score <= -neutralBand:
+		return SentimentNegative
+	}
+	return SentimentNeutral
+}
+
+// LexiconScorer 基于情感词典的打分，处理否定词和程度词
+// 否定词和程度词作用于后面两个词以内的第一个情感词
+type LexiconScorer struct {
+	segmenter    *Segmenter
+	polarity     map[string]float64
+	negators     map[string]bool
+	intensifiers map[string]float64
+}
+
+// NewLexiconScorer 创建使用内置情感词典的打分器，segmenter 为 nil 时使用内置词典分词
+func NewLexiconScorer(segmenter *Segmenter) *LexiconScorer {
+	if segmenter == nil {
+		segmenter = NewSegmenter()
+	}
+	s := &LexiconScorer{
+		segmenter:    segmenter,
+		polarity:     make(map[string]float64),
+		negators:     make(map[string]bool),
+		intensifiers: make(map[string]float64),
+	}
+	for _, word := range readLines("positive") {
+		s.polarity[strings.ToLower(word)] = 1
+	}
+	for _, word := range readLines("negative") {
+		s.polarity[strings.ToLower(word)] = -1
+	}
+	for _, word := range readLines("negators") {
+		s.negators[word] = true
+	}
+	for _, line := range readLines("intensifiers") {
+		fields := strings.Fields(line)
+		weight := 1.5
+		if len(fields) > 1 {
+			if w, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				weight = w
+			}
+		}
+		s.intensifiers[fields[0]] = weight
+	}
+	return s
+}
+
+// Score 对文本打分，没有情感词时为 0
+func (s *LexiconScorer) Score(text string) float64 {
+	tokens := s.segmenter.Cut(text)
+	var sum float64
+	var hits int
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		modifier := 1.0
+		if s.negators[token] || s.intensifiers[token] != 0 {
+			if j, ok := s.nextSentiment(tokens, i); ok {
+				// 修饰词和情感词之间的词也可能是修饰词，如 不太好、很不好
+				for _, t := range tokens[i:j] {
+					if s.negators[t] {
+						modifier *= -0.8
+					} else if w := s.intensifiers[t]; w != 0 {
+						modifier *= w
+					}
+				}
+				i = j
+				token = tokens[j]
+			}
+		}
+		polarity, ok := s.polarity[token]
+		if !ok {
+			continue
+		}
+		sum += polarity * modifier
+		hits++
+	}
+	if hits == 0 {
+		return 0
+	}
+	// 情感词越多越确定，用 tanh 压缩到 -1 到 1
+	return math.Tanh(sum / math.Sqrt(float64(hits)))
+}
+
+// nextSentiment 修饰词后面两个词以内的第一个情感词
+func (s *LexiconScorer) nextSentiment(tokens []string, i int) (int, bool) {
+	for j := i + 1; j < len(tokens) && j <= i+2; j++ {
+		if _, ok := s.polarity[tokens[j]]; ok {
+			return j, true
+		}
+		if !s.negators[tokens[j]] && s.intensifiers[tokens[j]] == 0 {
+			return 0, false
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,389 @@
+// Package archive 保存浏览和抓取到的推荐条目、笔记、评论和作者，供导出和分析使用。
+// 数据来自事件总线：explore:feeds-seen、note:opened、note:comments-crawled 和 profile:refreshed。
+package archive
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/profile"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/spf13/cast"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var logger = logging.Component("archive")
+
+// Feed 推荐页或搜索结果中的一个条目，同一条目多次出现时更新点赞数和最后出现时间
+type Feed struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"size:40;uniqueIndex" json:"key"` // 标题、作者、封面、频道和关键词的摘要
+	Title       string    `gorm:"type:text" json:"title"`
+	Author      string    `gorm:"size:128;index" json:"author"`
+	AvatarURL   string    `gorm:"type:text" json:"avatarUrl"`
+	CoverURL    string    `gorm:"type:text" json:"coverUrl"`
+	Likes       string    `gorm:"size:32" json:"likes"`
+	Channel     string    `gorm:"size:64;index" json:"channel"`
+	Keyword     string    `gorm:"size:128;index" json:"keyword"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	LastSeenAt  time.Time `gorm:"index" json:"lastSeenAt"`
+}
+
+// TableName 指定推荐条目表名
+func (Feed) TableName() string {
+	return "archive_feeds"
+}
+
+// Note 打开过或抓取过评论的笔记
+type Note struct {
+	ID           string    `gorm:"primaryKey;size:64" json:"id"`
+	URL          string    `gorm:"type:text" json:"url"`
+	Type         string    `gorm:"size:16" json:"type"`
+	Author       string    `gorm:"size:128;index" json:"author"`
+	Title        string    `gorm:"type:text" json:"title"`
+	Desc         string    `gorm:"type:text" json:"desc"`
+	DateAddress  string    `gorm:"size:128" json:"dateAddress"`
+	CommentCount string    `gorm:"size:32" json:"commentCount"`
+	Images       []string  `gorm:"type:text;serializer:json" json:"images"`
+	FirstSeenAt  time.Time `json:"firstSeenAt"`
+	UpdatedAt    time.Time `gorm:"index" json:"updatedAt"`
+}
+
+// TableName 指定笔记表名
+func (Note) TableName() string {
+	return "archive_notes"
+}
+
+// Comment 笔记的一条评论，ParentID 为 0 的是一级评论
+// 同一篇笔记重新抓取时替换之前的评论
+type Comment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	NoteID      string    `gorm:"size:64;index;not null" json:"noteId"`
+	ParentID    uint      `gorm:"index" json:"parentId"`
+	Position    int       `json:"position"` // 在同级评论中的顺序
+	Author      string    `gorm:"size:128;index" json:"author"`
+	Content     string    `gorm:"type:text" json:"content"`
+	Images      []string  `gorm:"type:text;serializer:json" json:"images"`
+	DateAddress string    `gorm:"size:128" json:"dateAddress"`
+	Likes       string    `gorm:"size:32" json:"likes"`
+	ReplyCount  string    `gorm:"size:32" json:"replyCount"`
+	CrawledAt   time.Time `gorm:"index" json:"crawledAt"`
+}
+
+// TableName 指定评论表名
+func (Comment) TableName() string {
+	return "archive_comments"
+}
+
+// Author 出现在条目、笔记或评论中的作者，刷新过主页的作者带有主页信息
+// 页面上只有昵称，作者按昵称区分
+type Author struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Name        string     `gorm:"size:128;uniqueIndex;not null" json:"name"`
+	UserID      string     `gorm:"size:64" json:"userId"`
+	RedID       string     `gorm:"size:64" json:"redId"`
+	AvatarURL   string     `gorm:"type:text" json:"avatarUrl"`
+	Desc        string     `gorm:"type:text" json:"desc"`
+	Follows     string     `gorm:"size:32" json:"follows"`
+	Fans        string     `gorm:"size:32" json:"fans"`
+	Likes       string     `gorm:"size:32" json:"likes"`
+	RefreshedAt *time.Time `json:"refreshedAt"` // 最近一次刷新主页的时间
+	FirstSeenAt time.Time  `json:"firstSeenAt"`
+	LastSeenAt  time.Time  `gorm:"index" json:"lastSeenAt"`
+}
+
+// TableName 指定作者表名
+func (Author) TableName() string {
+	return "archive_authors"
+}
+
+// Store 采集数据存储
+type Store struct {
+	db  *gorm.DB
+	bus EventBus.Bus
+}
+
+// NewStore 创建采集数据存储并迁移表结构
+func NewStore(db *gorm.DB) (*Store, error) {
+	if err := db.AutoMigrate(&Feed{}, &Note{}, &Comment{}, &Author{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate archive: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// DB 存储使用的数据库，用于导出和分析时查询
+func (s *Store) DB() *gorm.DB {
+	return s.db
+}
+
+// Start 订阅采集事件并保存
+func (s *Store) Start(bus EventBus.Bus) error {
+	subscriptions := map[string]interface{}{
+		eventbus.TopicFeedsSeen:        s.onFeedsSeen,
+		eventbus.TopicNoteOpened:       s.onNoteOpened,
+		eventbus.TopicCommentsCrawled:  s.onCommentsCrawled,
+		eventbus.TopicProfileRefreshed: s.onProfileRefreshed,
+	}
+	for topic, fn := range subscriptions {
+		if err := bus.Subscribe(topic, fn); err != nil {
+			return err
+		}
+	}
+	s.bus = bus
+	return nil
+}
+
+// Stop 取消订阅
+func (s *Store) Stop() {
+	if s.bus == nil {
+		return
+	}
+	_ = s.bus.Unsubscribe(eventbus.TopicFeedsSeen, s.onFeedsSeen)
+	_ = s.bus.Unsubscribe(eventbus.TopicNoteOpened, s.onNoteOpened)
+	_ = s.bus.Unsubscribe(eventbus.TopicCommentsCrawled, s.onCommentsCrawled)
+	_ = s.bus.Unsubscribe(eventbus.TopicProfileRefreshed, s.onProfileRefreshed)
+	s.bus = nil
+}
+
+func (s *Store) onFeedsSeen(items []map[string]interface{}) {
+	if err := s.SaveFeeds(items, time.Now()); err != nil {
+		logger.Warn("failed to save feeds", "error", err)
+	}
+}
+
+func (s *Store) onNoteOpened(detail note.NoteDetail) {
+	if err := s.SaveNote(detail); err != nil {
+		logger.Warn("failed to save note", "note", detail.ID, "error", err)
+	}
+}
+
+func (s *Store) onCommentsCrawled(crawled note.CommentsCrawled) {
+	if err := s.SaveComments(crawled); err != nil {
+		logger.Warn("failed to save comments", "note", crawled.Note.ID, "error", err)
+	}
+}
+
+func (s *Store) onProfileRefreshed(detail profile.ProfileDetail) {
+	if err := s.SaveProfile(detail); err != nil {
+		logger.Warn("failed to save profile", "user", detail.UserID, "error", err)
+	}
+}
+
+// SaveFeeds 保存一页条目，条目为 explore.FeedsInfo.Map 的结果
+func (s *Store) SaveFeeds(items []map[string]interface{}, seenAt time.Time) error {
+	if len(items) == 0 {
+		return nil
+	}
+	feeds := make([]Feed, 0, len(items))
+	authors := make([]Author, 0, len(items))
+	for _, item := range items {
+		feed := Feed{
+			Title:       cast.ToString(item["title"]),
+			Author:      cast.ToString(item["username"]),
+			AvatarURL:   cast.ToString(item["avatarUrl"]),
+			CoverURL:    cast.ToString(item["coverImageUrl"]),
+			Likes:       cast.ToString(item["likes"]),
+			Channel:     cast.ToString(item["channel"]),
+			Keyword:     cast.ToString(item["keyword"]),
+			FirstSeenAt: seenAt,
+			LastSeenAt:  seenAt,
+		}
+		feed.Key = digest(feed.Title, feed.Author, feed.CoverURL, feed.Channel, feed.Keyword)
+		feeds = append(feeds, feed)
+		authors = append(authors, Author{Name: feed.Author, AvatarURL: feed.AvatarURL})
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"likes", "avatar_url", "last_seen_at"}),
+		}).Create(&feeds).Error
+		if err != nil {
+			return fmt.Errorf("failed to save feeds: %w", err)
+		}
+		return seeAuthors(tx, authors, seenAt)
+	})
+}
+
+// SaveNote 保存笔记详情，已保存的笔记更新内容
+func (s *Store) SaveNote(detail note.NoteDetail) error {
+	if detail.ID == "" {
+		return fmt.Errorf("note id is required")
+	}
+	seenAt := detail.OpenedAt
+	if seenAt.IsZero() {
+		seenAt = time.Now()
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveNote(tx, detail, seenAt); err != nil {
+			return err
+		}
+		return seeAuthors(tx, []Author{{Name: detail.Author}}, seenAt)
+	})
+}
+
+// SaveComments 保存一次评论抓取的结果，替换该笔记之前的评论
+func (s *Store) SaveComments(crawled note.CommentsCrawled) error {
+	noteID := crawled.Note.ID
+	if noteID == "" {
+		return fmt.Errorf("note id is required")
+	}
+	crawledAt := crawled.CrawledAt
+	if crawledAt.IsZero() {
+		crawledAt = time.Now()
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveNote(tx, crawled.Note, crawledAt); err != nil {
+			return err
+		}
+		if err := tx.Where("note_id = ?", noteID).Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+		authors := []Author{{Name: crawled.Note.Author}}
+		var insert func(details []note.CommentDetail, parentID uint) error
+		insert = func(details []note.CommentDetail, parentID uint) error {
+			for i, detail := range details {
+				comment := Comment{
+					NoteID:      noteID,
+					ParentID:    parentID,
+					Position:    i,
+					Author:      detail.Author,
+					Content:     detail.Content,
+					Images:      detail.Images,
+					DateAddress: detail.DateAddress,
+					Likes:       detail.Likes,
+					ReplyCount:  detail.ReplyCount,
+					CrawledAt:   crawledAt,
+				}
+				if comment.Images == nil {
+					comment.Images = []string{}
+				}
+				if err := tx.Create(&comment).Error; err != nil {
+					return fmt.Errorf("failed to save comment: %w", err)
+				}
+				authors = append(authors, Author{Name: detail.Author})
+				if err := insert(detail.Replies, comment.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := insert(crawled.Comments, 0); err != nil {
+			return err
+		}
+		return seeAuthors(tx, authors, crawledAt)
+	})
+}
+
+// SaveProfile 保存作者的主页信息
+func (s *Store) SaveProfile(detail profile.ProfileDetail) error {
+	if detail.Nickname == "" {
+		return fmt.Errorf("nickname is required")
+	}
+	refreshedAt := detail.RefreshedAt
+	if refreshedAt.IsZero() {
+		refreshedAt = time.Now()
+	}
+	author := Author{
+		Name:        detail.Nickname,
+		UserID:      detail.UserID,
+		RedID:       detail.RedID,
+		Desc:        detail.Desc,
+		Follows:     detail.Follows,
+		Fans:        detail.Fans,
+		Likes:       detail.Likes,
+		RefreshedAt: &refreshedAt,
+		FirstSeenAt: refreshedAt,
+		LastSeenAt:  refreshedAt,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"user_id", "red_id", "desc", "follows", "fans", "likes", "refreshed_at", "last_seen_at",
+		}),
+	}).Create(&author).Error
+}
+
+// Comments 笔记的所有评论，一级评论在前，回复紧跟在所属评论之后
+func (s *Store) Comments(noteID string) ([]Comment, error) {
+	var comments []Comment
+	if err := s.db.Where("note_id = ?", noteID).Order("id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Note 按 id 查找笔记
+func (s *Store) Note(noteID string) (Note, bool, error) {
+	var n Note
+	err := s.db.Where("id = ?", noteID).Limit(1).Find(&n).Error
+	return n, err == nil && n.ID != "", err
+}
+
+func saveNote(tx *gorm.DB, detail note.NoteDetail, seenAt time.Time) error {
+	row := Note{
+		ID:           detail.ID,
+		URL:          detail.URL,
+		Type:         detail.Type,
+		Author:       detail.Author,
+		Title:        detail.Title,
+		Desc:         detail.Desc,
+		DateAddress:  detail.DateAddress,
+		CommentCount: detail.CommentCount,
+		Images:       detail.Images,
+		FirstSeenAt:  seenAt,
+		UpdatedAt:    seenAt,
+	}
+	if row.Images == nil {
+		row.Images = []string{}
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"url", "type", "author", "title", "desc", "date_address", "comment_count", "images", "updated_at",
+		}),
+	}).Create(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to save note %s: %w", detail.ID, err)
+	}
+	return nil
+}
+
+// seeAuthors 记录作者出现，新作者插入，已有作者更新最后出现时间和非空的头像
+func seeAuthors(tx *gorm.DB, authors []Author, seenAt time.Time) error {
+	seen := make(map[string]bool, len(authors))
+	rows := make([]Author, 0, len(authors))
+	for _, author := range authors {
+		if author.Name == "" || seen[author.Name] {
+			continue
+		}
+		seen[author.Name] = true
+		author.FirstSeenAt = seenAt
+		author.LastSeenAt = seenAt
+		rows = append(rows, author)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "name"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "last_seen_at"}, Value: gorm.Expr("excluded.last_seen_at")},
+			{Column: clause.Column{Name: "avatar_url"}, Value: gorm.Expr("COALESCE(NULLIF(excluded.avatar_url, ''), avatar_url)")},
+		},
+	}).Create(&rows).Error
+}
+
+func digest(parts ...string) string {
+	h := sha1.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/profile"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	db := dbtest.Open(t)
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return store
+}
+
+func TestStoreSavesAndFilters(t *testing.T) {
+	store := newStore(t)
+	day := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	feeds := []map[string]interface{}{
+		{"title": "秋冬穿搭", "username": "小红", "avatarUrl": "a.png", "coverImageUrl": "c1.jpg", "likes": "10", "channel": "穿搭"},
+		{"title": "早餐食谱", "username": "小蓝", "coverImageUrl": "c2.jpg", "likes": "3"},
+	}
+	if err := store.SaveFeeds(feeds, day); err != nil {
+		t.Fatalf("SaveFeeds: %v", err)
+	}
+	// 同一条目再次出现时更新点赞数
+	feeds[0]["likes"] = "12"
+	if err := store.SaveFeeds(feeds[:1], day.Add(time.Hour)); err != nil {
+		t.Fatalf("SaveFeeds: %v", err)
+	}
+	err := store.SaveComments(note.CommentsCrawled{
+		Note: note.NoteDetail{ID: "n1", Title: "秋冬穿搭", Author: "小红"},
+		Comments: []note.CommentDetail{
+			{Author: "路人甲", Content: "好看", Likes: "5", Replies: []note.CommentDetail{{Author: "小红", Content: "谢谢"}}},
+			{Author: "路人乙", Content: "链接"},
+		},
+		CrawledAt: day,
+	})
+	if err != nil {
+		t.Fatalf("SaveComments: %v", err)
+	}
+	if err := store.SaveProfile(profile.ProfileDetail{UserID: "u1", Nickname: "小红", Fans: "1万", RefreshedAt: day}); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+
+	var got []Feed
+	collect := func(f Feed) error { got = append(got, f); return nil }
+	if err := store.EachFeed(Filter{Channel: "穿搭"}, collect); err != nil {
+		t.Fatalf("EachFeed: %v", err)
+	}
+	if len(got) != 1 || got[0].Likes != "12" || !got[0].LastSeenAt.Equal(day.Add(time.Hour)) || !got[0].FirstSeenAt.Equal(day) {
+		t.Fatalf("unexpected feeds %+v", got)
+	}
+	got = nil
+	if err := store.EachFeed(Filter{Keyword: "食谱", Until: day.Add(time.Minute)}, collect); err != nil || len(got) != 1 {
+		t.Fatalf("unexpected feeds %+v, %v", got, err)
+	}
+
+	comments, err := store.Comments("n1")
+	if err != nil || len(comments) != 3 {
+		t.Fatalf("unexpected comments %+v, %v", comments, err)
+	}
+	if comments[1].ParentID != comments[0].ID || comments[2].ParentID != 0 || comments[2].Position != 1 {
+		t.Fatalf("comment tree is not preserved: %+v", comments)
+	}
+	var notes []Note
+	if err := store.EachNote(Filter{Keyword: "穿搭"}, func(n Note) error { notes = append(notes, n); return nil }); err != nil || len(notes) != 1 {
+		t.Fatalf("unexpected notes %+v, %v", notes, err)
+	}
+	if err := store.EachNote(Filter{Channel: "穿搭"}, func(Note) error { return nil }); err == nil {
+		t.Fatal("expected error for channel filter on notes")
+	}
+
+	var authors []AuthorStats
+	err = store.EachAuthor(Filter{Author: "小红"}, func(a AuthorStats) error { authors = append(authors, a); return nil })
+	if err != nil || len(authors) != 1 {
+		t.Fatalf("unexpected authors %+v, %v", authors, err)
+	}
+	a := authors[0]
+	if a.UserID != "u1" || a.Fans != "1万" || a.AvatarURL != "a.png" || a.Feeds != 1 || a.Notes != 1 || a.Comments != 1 {
+		t.Fatalf("unexpected author %+v", a)
+	}
+}
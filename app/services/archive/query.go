@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter 查询条件，零值表示不限制
+type Filter struct {
+	Since   time.Time // 包含
+	Until   time.Time // 不包含
+	Keyword string    // 匹配标题、正文、评论内容或作者简介
+	Author  string    // 作者昵称，完全匹配
+	Channel string    // 频道，只对推荐条目有效
+}
+
+// AuthorStats 作者及其在采集数据中出现的次数
+type AuthorStats struct {
+	Author
+	Feeds    int `json:"feeds"`
+	Notes    int `json:"notes"`
+	Comments int `json:"comments"`
+}
+
+// EachFeed 按最后出现时间遍历符合条件的推荐条目
+func (s *Store) EachFeed(f Filter, fn func(Feed) error) error {
+	tx := s.db.Model(&Feed{})
+	tx = f.apply(tx, "last_seen_at", "author", "title")
+	if f.Channel != "" {
+		tx = tx.Where("channel = ?", f.Channel)
+	}
+	return each(tx.Order("last_seen_at, id"), fn)
+}
+
+// EachNote 按更新时间遍历符合条件的笔记
+func (s *Store) EachNote(f Filter, fn func(Note) error) error {
+	if err := f.withoutChannel("notes"); err != nil {
+		return err
+	}
+	tx := f.apply(s.db.Model(&Note{}), "updated_at", "author", "title", "desc")
+	return each(tx.Order("updated_at, id"), fn)
+}
+
+// EachComment 遍历符合条件的评论，同一篇笔记的评论在一起，回复紧跟在所属评论之后
+// 按关键词或作者过滤时只返回匹配的评论，ParentID 仍然指向原来的评论
+func (s *Store) EachComment(f Filter, fn func(Comment) error) error {
+	if err := f.withoutChannel("comments"); err != nil {
+		return err
+	}
+	tx := f.apply(s.db.Model(&Comment{}), "crawled_at", "author", "content")
+	return each(tx.Order("note_id, id"), fn)
+}
+
+// EachAuthor 按最后出现时间遍历符合条件的作者
+func (s *Store) EachAuthor(f Filter, fn func(AuthorStats) error) error {
+	if err := f.withoutChannel("authors"); err != nil {
+		return err
+	}
+	tx := s.db.Model(&Author{}).Select(`archive_authors.*,
+		(SELECT COUNT(*) FROM archive_feeds WHERE archive_feeds.author = archive_authors.name) AS feeds,
+		(SELECT COUNT(*) FROM archive_notes WHERE archive_notes.author = archive_authors.name) AS notes,
+		(SELECT COUNT(*) FROM archive_comments WHERE archive_comments.author = archive_authors.name) AS comments`)
+	tx = f.apply(tx, "archive_authors.last_seen_at", "archive_authors.name", "archive_authors.name", "archive_authors.desc")
+	return each(tx.Order("archive_authors.last_seen_at, archive_authors.id"), fn)
+}
+
+// apply 添加时间、作者和关键词条件，关键词匹配 textColumns 中的任意一列
+func (f Filter) apply(tx *gorm.DB, timeColumn, authorColumn string, textColumns ...string) *gorm.DB {
+	if !f.Since.IsZero() {
+		tx = tx.Where(timeColumn+" >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		tx = tx.Where(timeColumn+" < ?", f.Until)
+	}
+	if f.Author != "" {
+		tx = tx.Where(authorColumn+" = ?", f.Author)
+	}
+	if f.Keyword != "" && len(textColumns) > 0 {
+		pattern := "%" + f.Keyword + "%"
+		cond := tx.Session(&gorm.Session{NewDB: true}).Where(quote(textColumns[0])+" LIKE ?", pattern)
+		for _, column := range textColumns[1:] {
+			cond = cond.Or(quote(column)+" LIKE ?", pattern)
+		}
+		tx = tx.Where(cond)
+	}
+	return tx
+}
+
+func (f Filter) withoutChannel(kind string) error {
+	if f.Channel != "" {
+		return fmt.Errorf("channel filter is not supported for %s", kind)
+	}
+	return nil
+}
+
+// quote 给 desc 等关键字列名加引号
+func quote(column string) string {
+	if column == "desc" {
+		return "`desc`"
+	}
+	if column == "archive_authors.desc" {
+		return "archive_authors.`desc`"
+	}
+	return column
+}
+
+// each 逐行读取查询结果交给 fn，不把结果全部载入内存，fn 返回错误时停止
+func each[T any](tx *gorm.DB, fn func(T) error) error {
+	rows, err := tx.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row T
+		if err := tx.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
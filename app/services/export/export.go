@@ -0,0 +1,149 @@
+// Package export 把采集到的推荐条目、笔记、评论和作者导出为 CSV、JSON Lines 或 Excel 文件。
+// 数据逐行从数据库读出并写入临时文件，写完后再替换目标文件，导出大量数据时不会全部载入内存。
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"xiaohongshu/app/services/archive"
+
+	"github.com/spf13/cast"
+)
+
+// 导出的数据类型
+const (
+	KindFeeds    = "feeds"
+	KindNotes    = "notes"
+	KindComments = "comments"
+	KindAuthors  = "authors"
+)
+
+// 导出格式
+const (
+	FormatCSV   = "csv"   // UTF-8 带 BOM，Excel 可以直接打开
+	FormatJSONL = "jsonl" // 每行一个 JSON 对象
+	FormatXLSX  = "xlsx"
+)
+
+// Kinds 所有数据类型
+var Kinds = []string{KindFeeds, KindNotes, KindComments, KindAuthors}
+
+// Formats 所有导出格式
+var Formats = []string{FormatCSV, FormatJSONL, FormatXLSX}
+
+// Result 一次导出的结果
+type Result struct {
+	Kind   string `json:"kind"`
+	Format string `json:"format"`
+	Path   string `json:"path"`
+	Rows   int    `json:"rows"`
+}
+
+// writer 按列写入一种格式的文件
+type writer interface {
+	Write(values []interface{}) error
+	// Close 写完剩余内容并关闭文件，失败时文件可能不完整
+	Close() error
+}
+
+func newWriter(format, path, kind string, columns []string) (writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(path, columns)
+	case FormatJSONL:
+		return newJSONLWriter(path, columns)
+	case FormatXLSX:
+		return newXLSXWriter(path, kind, columns)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// Export 按 filter 导出 kind 类型的数据到 path，格式由 format 指定
+// 写入失败时不会留下不完整的文件，原有的文件保持不变
+func Export(store *archive.Store, kind string, filter archive.Filter, format string, path string) (Result, error) {
+	result := Result{Kind: kind, Format: format, Path: path}
+	t, ok := tables[kind]
+	if !ok {
+		return result, fmt.Errorf("unsupported export kind %q", kind)
+	}
+	if path == "" {
+		return result, fmt.Errorf("export path is required")
+	}
+	// 保留扩展名，excelize 按扩展名检查文件格式
+	ext := filepath.Ext(path)
+	tmp := filepath.Join(filepath.Dir(path), "."+strings.TrimSuffix(filepath.Base(path), ext)+".tmp"+ext)
+	w, err := newWriter(format, tmp, kind, t.columns)
+	if err != nil {
+		return result, err
+	}
+	err = t.rows(store, filter, func(values []interface{}) error {
+		result.Rows++
+		return w.Write(values)
+	})
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return result, fmt.Errorf("failed to export %s: %w", kind, err)
+	}
+	return result, nil
+}
+
+// Extension 格式对应的文件扩展名
+func Extension(format string) string {
+	return "." + format
+}
+
+// ParseFilter 解析前端传入的过滤条件：since、until、keyword、author、channel
+// since 和 until 为毫秒时间戳或 2006-01-02 格式的日期，until 为日期时包含当天
+func ParseFilter(values map[string]interface{}) (archive.Filter, error) {
+	var f archive.Filter
+	var err error
+	if f.Since, err = parseTime(values["since"], false); err != nil {
+		return f, fmt.Errorf("invalid since: %w", err)
+	}
+	if f.Until, err = parseTime(values["until"], true); err != nil {
+		return f, fmt.Errorf("invalid until: %w", err)
+	}
+	f.Keyword = strings.TrimSpace(cast.ToString(values["keyword"]))
+	f.Author = strings.TrimSpace(cast.ToString(values["author"]))
+	f.Channel = strings.TrimSpace(cast.ToString(values["channel"]))
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until) {
+		return f, fmt.Errorf("since must be before until")
+	}
+	return f, nil
+}
+
+func parseTime(value interface{}, endOfDay bool) (time.Time, error) {
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return time.Time{}, nil
+		}
+		if day, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+			if endOfDay {
+				day = day.AddDate(0, 0, 1)
+			}
+			return day, nil
+		}
+		return time.Parse(time.RFC3339, v)
+	}
+	ms, err := cast.ToInt64E(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ms <= 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(ms), nil
+}
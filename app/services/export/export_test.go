@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/services/archive"
+	"xiaohongshu/app/services/xiaohongshu/note"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func newArchive(t *testing.T) *archive.Store {
+	t.Helper()
+	db := dbtest.Open(t)
+	store, err := archive.NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	err = store.SaveComments(note.CommentsCrawled{
+		Note: note.NoteDetail{ID: "n1", Title: "标题", Author: "作者"},
+		Comments: []note.CommentDetail{
+			{Author: "甲", Content: "第一条, 带逗号", Replies: []note.CommentDetail{
+				{Author: "乙", Content: "回复", Replies: []note.CommentDetail{{Author: "丙", Content: "再回复"}}},
+			}},
+		},
+		CrawledAt: time.Date(2026, 5, 1, 8, 0, 0, 0, time.Local),
+	})
+	if err != nil {
+		t.Fatalf("SaveComments: %v", err)
+	}
+	return store
+}
+
+func TestExportComments(t *testing.T) {
+	store := newArchive(t)
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "comments.csv")
+	result, err := Export(store, KindComments, archive.Filter{}, FormatCSV, csvPath)
+	if err != nil || result.Rows != 3 {
+		t.Fatalf("Export csv: %+v, %v", result, err)
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.HasPrefix(lines[0], utf8BOM+"id,note_id,parent_id,depth,") || len(lines) != 4 {
+		t.Fatalf("unexpected csv:\n%s", data)
+	}
+	if !strings.Contains(lines[1], `,,0,0,甲,"第一条, 带逗号",`) || !strings.Contains(lines[3], ",2,2,0,丙,") {
+		t.Fatalf("unexpected csv rows:\n%s", data)
+	}
+
+	jsonlPath := filepath.Join(dir, "comments.jsonl")
+	if _, err := Export(store, KindComments, archive.Filter{Keyword: "再"}, FormatJSONL, jsonlPath); err != nil {
+		t.Fatalf("Export jsonl: %v", err)
+	}
+	file, err := os.Open(jsonlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var rows []map[string]interface{}
+	for scanner.Scan() {
+		var row map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("invalid json line %q: %v", scanner.Text(), err)
+		}
+		rows = append(rows, row)
+	}
+	// 过滤后父评论不在结果中，仍然保留 parent_id
+	if len(rows) != 1 || rows[0]["author"] != "丙" || rows[0]["parent_id"] != float64(2) || rows[0]["depth"] != float64(1) {
+		t.Fatalf("unexpected jsonl rows %+v", rows)
+	}
+
+	xlsxPath := filepath.Join(dir, "comments.xlsx")
+	if _, err := Export(store, KindComments, archive.Filter{}, FormatXLSX, xlsxPath); err != nil {
+		t.Fatalf("Export xlsx: %v", err)
+	}
+	book, err := excelize.OpenFile(xlsxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer book.Close()
+	sheet, err := book.GetRows(KindComments)
+	if err != nil || len(sheet) != 4 || sheet[0][0] != "id" || sheet[2][5] != "乙" {
+		t.Fatalf("unexpected sheet %v, %v", sheet, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestExportFailureKeepsExistingFile(t *testing.T) {
+	store := newArchive(t)
+	path := filepath.Join(t.TempDir(), "notes.csv")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Export(store, KindNotes, archive.Filter{Channel: "穿搭"}, FormatCSV, path); err == nil {
+		t.Fatal("expected error for channel filter on notes")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Fatalf("existing file was modified: %q", data)
+	}
+	if _, err := Export(store, "videos", archive.Filter{}, FormatCSV, path); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(map[string]interface{}{"since": "2026-05-01", "until": "2026-05-01", "keyword": " 穿搭 "})
+	if err != nil {
+		t.Fatalf("ParseFilter: %v", err)
+	}
+	if f.Until.Sub(f.Since) != 24*time.Hour || f.Keyword != "穿搭" {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+	f, err = ParseFilter(map[string]interface{}{"since": float64(1777593600000)})
+	if err != nil || f.Since.UnixMilli() != 1777593600000 || !f.Until.IsZero() {
+		t.Fatalf("unexpected filter %+v, %v", f, err)
+	}
+	if _, err := ParseFilter(map[string]interface{}{"since": "2026-05-02", "until": "2026-05-01"}); err == nil {
+		t.Fatal("expected error for inverted range")
+	}
+}
@@ -0,0 +1,69 @@
+package export
+
+import "xiaohongshu/app/services/archive"
+
+// table 一种数据类型的列和逐行读取方法，emit 的值与 columns 一一对应
+type table struct {
+	columns []string
+	rows    func(store *archive.Store, filter archive.Filter, emit func([]interface{}) error) error
+}
+
+var tables = map[string]table{
+	KindFeeds: {
+		columns: []string{"id", "title", "author", "avatar_url", "cover_url", "likes", "channel", "keyword", "first_seen_at", "last_seen_at"},
+		rows: func(store *archive.Store, filter archive.Filter, emit func([]interface{}) error) error {
+			return store.EachFeed(filter, func(f archive.Feed) error {
+				return emit([]interface{}{f.ID, f.Title, f.Author, f.AvatarURL, f.CoverURL, f.Likes, f.Channel, f.Keyword, f.FirstSeenAt, f.LastSeenAt})
+			})
+		},
+	},
+	KindNotes: {
+		columns: []string{"id", "url", "type", "author", "title", "desc", "date_address", "comment_count", "images", "first_seen_at", "updated_at"},
+		rows: func(store *archive.Store, filter archive.Filter, emit func([]interface{}) error) error {
+			return store.EachNote(filter, func(n archive.Note) error {
+				return emit([]interface{}{n.ID, n.URL, n.Type, n.Author, n.Title, n.Desc, n.DateAddress, n.CommentCount, n.Images, n.FirstSeenAt, n.UpdatedAt})
+			})
+		},
+	},
+	// 评论树展开为一行一条评论，parent_id 为空的是一级评论，depth 从 0 开始
+	KindComments: {
+		columns: []string{"id", "note_id", "parent_id", "depth", "position", "author", "content", "likes", "reply_count", "date_address", "images", "crawled_at"},
+		rows: func(store *archive.Store, filter archive.Filter, emit func([]interface{}) error) error {
+			var (
+				noteID string
+				depths map[uint]int
+			)
+			return store.EachComment(filter, func(c archive.Comment) error {
+				// 评论按笔记分组，只需要记住当前笔记的层级
+				if c.NoteID != noteID {
+					noteID, depths = c.NoteID, make(map[uint]int)
+				}
+				var parent interface{}
+				depth := 0
+				if c.ParentID != 0 {
+					parent = c.ParentID
+					// 过滤后父评论可能不在结果中
+					if d, ok := depths[c.ParentID]; ok {
+						depth = d + 1
+					} else {
+						depth = 1
+					}
+				}
+				depths[c.ID] = depth
+				return emit([]interface{}{c.ID, c.NoteID, parent, depth, c.Position, c.Author, c.Content, c.Likes, c.ReplyCount, c.DateAddress, c.Images, c.CrawledAt})
+			})
+		},
+	},
+	KindAuthors: {
+		columns: []string{"id", "name", "user_id", "red_id", "avatar_url", "desc", "follows", "fans", "likes", "feeds", "notes", "comments", "refreshed_at", "first_seen_at", "last_seen_at"},
+		rows: func(store *archive.Store, filter archive.Filter, emit func([]interface{}) error) error {
+			return store.EachAuthor(filter, func(a archive.AuthorStats) error {
+				var refreshedAt interface{}
+				if a.RefreshedAt != nil {
+					refreshedAt = *a.RefreshedAt
+				}
+				return emit([]interface{}{a.ID, a.Name, a.UserID, a.RedID, a.AvatarURL, a.Desc, a.Follows, a.Fans, a.Likes, a.Feeds, a.Notes, a.Comments, refreshedAt, a.FirstSeenAt, a.LastSeenAt})
+			})
+		},
+	},
+}
@@ -0,0 +1,194 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// timeLayout CSV 中的时间格式，使用本地时区
+const timeLayout = "2006-01-02 15:04:05"
+
+// utf8BOM 让 Excel 按 UTF-8 打开 CSV
+const utf8BOM = "\ufeff"
+
+// maxXLSXRows Excel 工作表的最大行数，包括表头
+const maxXLSXRows = 1048576
+
+// text 把值转换为 CSV 单元格，列表以空格分隔
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Local().Format(timeLayout)
+	case []string:
+		return strings.Join(v, " ")
+	}
+	return fmt.Sprint(value)
+}
+
+type csvWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	csv  *csv.Writer
+	row  []string
+}
+
+func newCSVWriter(path string, columns []string) (*csvWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	w := &csvWriter{file: file, buf: buf, csv: csv.NewWriter(buf), row: make([]string, len(columns))}
+	if _, err := buf.WriteString(utf8BOM); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := w.csv.Write(columns); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	for i, value := range values {
+		w.row[i] = text(value)
+	}
+	return w.csv.Write(w.row)
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	err := w.csv.Error()
+	if err == nil {
+		err = w.buf.Flush()
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type jsonlWriter struct {
+	file    *os.File
+	buf     *bufio.Writer
+	enc     *json.Encoder
+	columns []string
+}
+
+func newJSONLWriter(path string, columns []string) (*jsonlWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{file: file, buf: buf, enc: enc, columns: columns}, nil
+}
+
+// Write 写入一个以列名为键的对象，时间为 RFC 3339 格式，零值时间为 null
+func (w *jsonlWriter) Write(values []interface{}) error {
+	row := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		if t, ok := value.(time.Time); ok && t.IsZero() {
+			value = nil
+		}
+		row[w.columns[i]] = value
+	}
+	return w.enc.Encode(row)
+}
+
+func (w *jsonlWriter) Close() error {
+	err := w.buf.Flush()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type xlsxWriter struct {
+	path   string
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+// newXLSXWriter 创建只有一个工作表的 Excel 文件，工作表以数据类型命名，冻结表头
+// 行数据由 excelize 写入临时文件，Close 时生成 path
+func newXLSXWriter(path, sheet string, columns []string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &xlsxWriter{path: path, file: file, stream: stream}
+	err = stream.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	if err == nil {
+		header := make([]interface{}, len(columns))
+		for i, column := range columns {
+			header[i] = column
+		}
+		err = w.Write(header)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *xlsxWriter) Write(values []interface{}) error {
+	if w.row >= maxXLSXRows {
+		return fmt.Errorf("too many rows for xlsx, the limit is %d", maxXLSXRows)
+	}
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			if !v.IsZero() {
+				cells[i] = v.Local()
+			}
+		case []string:
+			cells[i] = strings.Join(v, " ")
+		default:
+			cells[i] = value
+		}
+	}
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	return w.stream.SetRow(cell, cells)
+}
+
+func (w *xlsxWriter) Close() error {
+	err := w.stream.Flush()
+	if err == nil {
+		err = w.file.SaveAs(w.path)
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package imagehash
+
+// BKTree 按汉明距离组织的 BK 树，查找与给定哈希距离不超过阈值的所有条目时只访问一部分节点
+// 不是并发安全的，由调用方加锁
+type BKTree struct {
+	root *bkNode
+	size int
+}
+
+type bkNode struct {
+	hash     uint64
+	values   []uint // 哈希相同的条目
+	children map[int]*bkNode
+}
+
+// Match BK 树的查找结果
+type Match struct {
+	Hash     uint64
+	Value    uint
+	Distance int
+}
+
+// Add 加入一个条目，value 通常为数据库中的记录 id
+func (t *BKTree) Add(hash uint64, value uint) {
+	t.size++
+	if t.root == nil {
+		t.root = &bkNode{hash: hash, values: []uint{value}}
+		return
+	}
+	node := t.root
+	for {
+		d := Distance(node.hash, hash)
+		if d == 0 {
+			node.values = append(node.values, value)
+			return
+		}
+		child, ok := node.children[d]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[d] = &bkNode{hash: hash, values: []uint{value}}
+			return
+		}
+		node = child
+	}
+}
+
+// Len 条目数量
+func (t *BKTree) Len() int {
+	return t.size
+}
+
+// Search 查找与 hash 的距离不超过 threshold 的条目
+func (t *BKTree) Search(hash uint64, threshold int) []Match {
+	if t.root == nil {
+		return nil
+	}
+	var matches []Match
+	stack := []*bkNode{t.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d := Distance(node.hash, hash)
+		if d <= threshold {
+			for _, value := range node.values {
+				matches = append(matches, Match{Hash: node.hash, Value: value, Distance: d})
+			}
+		}
+		// 三角不等式：只有距离在 [d-threshold, d+threshold] 的子树可能有结果
+		for childDistance, child := range node.children {
+			if childDistance >= d-threshold && childDistance <= d+threshold {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return matches
+}
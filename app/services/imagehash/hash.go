@@ -0,0 +1,144 @@
+// Package imagehash 计算图片的感知哈希（aHash、dHash、pHash），按汉明距离查找近似重复的图片，
+// 用于发现搬运和盗图的笔记
+package imagehash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Hashes 一张图片的三种 64 位感知哈希
+type Hashes struct {
+	A uint64 `json:"aHash"` // 均值哈希，对整体亮度分布敏感
+	D uint64 `json:"dHash"` // 差值哈希，对水平方向的梯度敏感
+	P uint64 `json:"pHash"` // DCT 哈希，对缩放、压缩和轻微调色最稳定，用于索引
+}
+
+// Compute 计算图片的三种哈希
+func Compute(img image.Image) Hashes {
+	return Hashes{A: AHash(img), D: DHash(img), P: PHash(img)}
+}
+
+// Distance 两个哈希的汉明距离，0 为相同，64 为完全相反
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// AHash 缩小到 8x8 灰度图，像素大于平均值的位为 1
+func AHash(img image.Image) uint64 {
+	pixels := grayscale(img, 8, 8)
+	var sum float64
+	for _, p := range pixels {
+		sum += p
+	}
+	mean := sum / float64(len(pixels))
+	var hash uint64
+	for i, p := range pixels {
+		if p > mean {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash
+}
+
+// DHash 缩小到 9x8 灰度图，每个像素比右侧像素亮的位为 1
+func DHash(img image.Image) uint64 {
+	pixels := grayscale(img, 9, 8)
+	var hash uint64
+	bit := 63
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] > pixels[y*9+x+1] {
+				hash |= 1 << uint(bit)
+			}
+			bit--
+		}
+	}
+	return hash
+}
+
+// pHashSize pHash 计算 DCT 的灰度图边长
+const pHashSize = 32
+
+// PHash 缩小到 32x32 灰度图做二维 DCT，取左上角 8x8 的低频系数，大于中位数的位为 1
+// 中位数不含直流分量，避免整体亮度影响结果
+func PHash(img image.Image) uint64 {
+	pixels := grayscale(img, pHashSize, pHashSize)
+	coefficients := dct2(pixels, pHashSize)
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			low = append(low, coefficients[y*pHashSize+x])
+		}
+	}
+	sorted := append([]float64(nil), low[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	var hash uint64
+	for i, c := range low {
+		if c > median {
+			hash |= 1 << uint(63-i)
+		}
+	}
+	return hash
+}
+
+// grayscale 按区域平均缩放到 w x h 的灰度图，按行排列
+func grayscale(img image.Image, w, h int) []float64 {
+	bounds := img.Bounds()
+	pixels := make([]float64, w*h)
+	if bounds.Empty() {
+		return pixels
+	}
+	sw, sh := bounds.Dx(), bounds.Dy()
+	for y := 0; y < h; y++ {
+		y0 := bounds.Min.Y + y*sh/h
+		y1 := bounds.Min.Y + max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0 := bounds.Min.X + x*sw/w
+			x1 := bounds.Min.X + max((x+1)*sw/w, x*sw/w+1)
+			var sum float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+				}
+			}
+			pixels[y*w+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return pixels
+}
+
+// dct2 n x n 矩阵的二维 DCT-II，先对每行再对每列做一维变换
+func dct2(pixels []float64, n int) []float64 {
+	cos := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cos[k*n+i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += pixels[y*n+i] * cos[k*n+i]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for i := 0; i < n; i++ {
+				sum += rows[i*n+x] * cos[k*n+i]
+			}
+			out[k*n+x] = sum
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package imagehash
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// pattern 生成带有对角渐变和方块的测试图片，seed 不同时图案不同
+func pattern(w, h int, seed int64) image.Image {
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*255/w + y*255/h) / 2)
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	for i := 0; i < 6; i++ {
+		x0, y0 := rng.Intn(w*3/4), rng.Intn(h*3/4)
+		c := uint8(rng.Intn(256))
+		for y := y0; y < y0+h/4; y++ {
+			for x := x0; x < x0+w/4; x++ {
+				img.Set(x, y, color.RGBA{R: c, G: 255 - c, B: c / 2, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// resize 最近邻缩放，模拟同一张图片的不同尺寸
+func resize(src image.Image, w, h int) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return dst
+}
+
+func TestHashesSurviveResize(t *testing.T) {
+	original := pattern(400, 300, 1)
+	small := Compute(resize(original, 160, 120))
+	hashes := Compute(original)
+	other := Compute(pattern(400, 300, 2))
+
+	for name, pair := range map[string][3]uint64{
+		"aHash": {hashes.A, small.A, other.A},
+		"dHash": {hashes.D, small.D, other.D},
+		"pHash": {hashes.P, small.P, other.P},
+	} {
+		if d := Distance(pair[0], pair[1]); d > 6 {
+			t.Errorf("%s: resized copy distance %d, want <= 6", name, d)
+		}
+		if d := Distance(pair[0], pair[2]); d <= DefaultThreshold {
+			t.Errorf("%s: different image distance %d, want > %d", name, d, DefaultThreshold)
+		}
+	}
+}
+
+func TestBKTreeMatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	var tree BKTree
+	hashes := make([]uint64, 500)
+	for i := range hashes {
+		hashes[i] = rng.Uint64()
+		// 加入一些彼此接近的哈希
+		if i%5 == 0 && i > 0 {
+			hashes[i] = hashes[i-1] ^ (1 << uint(rng.Intn(64)))
+		}
+		tree.Add(hashes[i], uint(i))
+	}
+	if tree.Len() != len(hashes) {
+		t.Fatalf("Len = %d, want %d", tree.Len(), len(hashes))
+	}
+	for _, threshold := range []int{0, 3, 12} {
+		query := hashes[rng.Intn(len(hashes))]
+		var want []uint
+		for i, h := range hashes {
+			if Distance(h, query) <= threshold {
+				want = append(want, uint(i))
+			}
+		}
+		var got []uint
+		for _, m := range tree.Search(query, threshold) {
+			got = append(got, m.Value)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if len(got) != len(want) {
+			t.Fatalf("threshold %d: got %v, want %v", threshold, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("threshold %d: got %v, want %v", threshold, got, want)
+			}
+		}
+	}
+}
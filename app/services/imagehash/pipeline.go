@@ -0,0 +1,108 @@
+package imagehash
+
+import (
+	"image"
+	"sync"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/note"
+
+	"github.com/asaskevich/EventBus"
+)
+
+var logger = logging.Component("imagehash")
+
+// queueSize 等待计算的图片数量上限，超出时丢弃并记录日志
+const queueSize = 256
+
+// Loader 下载并解码一张图片
+type Loader func(url string) (image.Image, error)
+
+type job struct {
+	noteID string
+	url    string
+}
+
+// Pipeline 笔记打开后在后台下载每张图片并计算哈希，已计算过的图片跳过
+type Pipeline struct {
+	store *Store
+	load  Loader
+	bus   EventBus.Bus
+	jobs  chan job
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewPipeline 创建图片哈希流水线，调用 Start 后开始处理
+func NewPipeline(store *Store, load Loader) *Pipeline {
+	return &Pipeline{
+		store: store,
+		load:  load,
+		jobs:  make(chan job, queueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start 订阅 note:opened 并启动后台处理
+func (p *Pipeline) Start(bus EventBus.Bus) error {
+	if err := bus.Subscribe(eventbus.TopicNoteOpened, p.onNoteOpened); err != nil {
+		return err
+	}
+	p.bus = bus
+	go p.run()
+	return nil
+}
+
+// Stop 取消订阅并停止后台处理，排队中的图片不再计算
+func (p *Pipeline) Stop() {
+	p.once.Do(func() {
+		if p.bus != nil {
+			_ = p.bus.Unsubscribe(eventbus.TopicNoteOpened, p.onNoteOpened)
+		}
+		close(p.done)
+	})
+}
+
+// Enqueue 把笔记的图片加入队列
+func (p *Pipeline) Enqueue(noteID string, urls ...string) {
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		select {
+		case p.jobs <- job{noteID: noteID, url: url}:
+		default:
+			logger.Warn("image hash queue is full", "note", noteID, "url", url)
+		}
+	}
+}
+
+func (p *Pipeline) onNoteOpened(detail note.NoteDetail) {
+	p.Enqueue(detail.ID, detail.Images...)
+}
+
+func (p *Pipeline) run() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case j := <-p.jobs:
+			if err := p.process(j); err != nil {
+				logger.Warn("failed to hash image", "note", j.noteID, "url", j.url, "error", err)
+			}
+		}
+	}
+}
+
+func (p *Pipeline) process(j job) error {
+	if exists, err := p.store.Has(j.noteID, j.url); err != nil || exists {
+		return err
+	}
+	img, err := p.load(j.url)
+	if err != nil {
+		return err
+	}
+	hashes := Compute(img)
+	logger.Debug("image hashed", "note", j.noteID, "url", j.url, "pHash", hashes.P)
+	return p.store.Add(j.noteID, j.url, hashes)
+}
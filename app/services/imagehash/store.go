@@ -0,0 +1,169 @@
+package imagehash
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultThreshold 认为两张图片近似重复的 pHash 最大汉明距离
+const DefaultThreshold = 10
+
+// ImageHash 一张笔记图片的感知哈希
+// SQLite 没有无符号整数，哈希按位存为 int64
+type ImageHash struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    string    `gorm:"size:64;not null;uniqueIndex:idx_image_hash_note_url" json:"noteId"`
+	URL       string    `gorm:"size:512;not null;uniqueIndex:idx_image_hash_note_url" json:"url"`
+	AHash     int64     `json:"aHash"`
+	DHash     int64     `json:"dHash"`
+	PHash     int64     `gorm:"index" json:"pHash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName 指定图片哈希表名
+func (ImageHash) TableName() string {
+	return "image_hashes"
+}
+
+// Hashes 记录中的三种哈希
+func (h ImageHash) Hashes() Hashes {
+	return Hashes{A: uint64(h.AHash), D: uint64(h.DHash), P: uint64(h.PHash)}
+}
+
+// ImageMatch 另一篇笔记中与某张图片近似重复的图片
+type ImageMatch struct {
+	URL       string `json:"url"`       // 另一篇笔记中的图片
+	SourceURL string `json:"sourceUrl"` // 查询笔记中的图片
+	Distance  int    `json:"distance"`  // pHash 汉明距离
+}
+
+// SimilarNote 含有近似重复图片的笔记，Distance 为其中最小的距离
+type SimilarNote struct {
+	NoteID   string       `json:"noteId"`
+	Distance int          `json:"distance"`
+	Matches  []ImageMatch `json:"matches"`
+}
+
+// Store 图片哈希存储，启动时把所有 pHash 载入内存中的 BK 树
+type Store struct {
+	db   *gorm.DB
+	mu   sync.RWMutex
+	tree BKTree
+	rows map[uint]indexed // BK 树中的记录，按 id 查找所属笔记和地址
+}
+
+type indexed struct {
+	noteID string
+	url    string
+}
+
+// NewStore 创建图片哈希存储，迁移表结构并建立索引
+func NewStore(db *gorm.DB) (*Store, error) {
+	if err := db.AutoMigrate(&ImageHash{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate image hashes: %w", err)
+	}
+	s := &Store{db: db, rows: make(map[uint]indexed)}
+	var rows []ImageHash
+	if err := db.Select("id", "note_id", "url", "p_hash").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load image hashes: %w", err)
+	}
+	for _, row := range rows {
+		s.index(row)
+	}
+	return s, nil
+}
+
+func (s *Store) index(row ImageHash) {
+	s.tree.Add(uint64(row.PHash), row.ID)
+	s.rows[row.ID] = indexed{noteID: row.NoteID, url: row.URL}
+}
+
+// Has 笔记的这张图片是否已经计算过
+func (s *Store) Has(noteID, url string) (bool, error) {
+	var count int64
+	err := s.db.Model(&ImageHash{}).Where("note_id = ? AND url = ?", noteID, url).Count(&count).Error
+	return count > 0, err
+}
+
+// Add 保存笔记图片的哈希，同一篇笔记的同一张图片只保存一次
+func (s *Store) Add(noteID, url string, hashes Hashes) error {
+	row := ImageHash{
+		NoteID: noteID,
+		URL:    url,
+		AHash:  int64(hashes.A),
+		DHash:  int64(hashes.D),
+		PHash:  int64(hashes.P),
+	}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save image hash: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	s.index(row)
+	s.mu.Unlock()
+	return nil
+}
+
+// ForNote 笔记所有图片的哈希
+func (s *Store) ForNote(noteID string) ([]ImageHash, error) {
+	var rows []ImageHash
+	err := s.db.Where("note_id = ?", noteID).Order("id").Find(&rows).Error
+	return rows, err
+}
+
+// Len 已索引的图片数量
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.Len()
+}
+
+// FindSimilar 查找其他笔记中与 noteID 的图片 pHash 距离不超过 threshold 的图片，按最小距离排列
+func (s *Store) FindSimilar(noteID string, threshold int) ([]SimilarNote, error) {
+	if threshold < 0 || threshold > 64 {
+		return nil, fmt.Errorf("threshold must be between 0 and 64, got %d", threshold)
+	}
+	sources, err := s.ForNote(noteID)
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]*SimilarNote)
+	s.mu.RLock()
+	for _, source := range sources {
+		for _, match := range s.tree.Search(uint64(source.PHash), threshold) {
+			row := s.rows[match.Value]
+			if row.noteID == noteID {
+				continue
+			}
+			note, ok := notes[row.noteID]
+			if !ok {
+				note = &SimilarNote{NoteID: row.noteID, Distance: match.Distance}
+				notes[row.noteID] = note
+			}
+			note.Distance = min(note.Distance, match.Distance)
+			note.Matches = append(note.Matches, ImageMatch{URL: row.url, SourceURL: source.URL, Distance: match.Distance})
+		}
+	}
+	s.mu.RUnlock()
+
+	result := make([]SimilarNote, 0, len(notes))
+	for _, note := range notes {
+		sort.Slice(note.Matches, func(i, j int) bool { return note.Matches[i].Distance < note.Matches[j].Distance })
+		result = append(result, *note)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Distance != result[j].Distance {
+			return result[i].Distance < result[j].Distance
+		}
+		return result[i].NoteID < result[j].NoteID
+	})
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package imagehash
+
+import (
+	"testing"
+	"xiaohongshu/app/infra/db/dbtest"
+)
+
+func TestStoreFindSimilar(t *testing.T) {
+	db := dbtest.Open(t)
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	const base = uint64(0xF0F0F0F0F0F0F0F0)
+	add := func(note, url string, p uint64) {
+		t.Helper()
+		if err := store.Add(note, url, Hashes{P: p}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	add("original", "a.jpg", base)
+	add("original", "a.jpg", base) // 重复添加被忽略
+	add("repost", "b.jpg", base^0b111)
+	add("repost", "c.jpg", base^0b1)
+	add("unrelated", "d.jpg", ^base)
+
+	notes, err := store.FindSimilar("original", DefaultThreshold)
+	if err != nil {
+		t.Fatalf("FindSimilar: %v", err)
+	}
+	if len(notes) != 1 || notes[0].NoteID != "repost" || notes[0].Distance != 1 || len(notes[0].Matches) != 2 {
+		t.Fatalf("unexpected result %+v", notes)
+	}
+	if notes[0].Matches[0].URL != "c.jpg" || notes[0].Matches[0].SourceURL != "a.jpg" {
+		t.Fatalf("matches should be sorted by distance: %+v", notes[0].Matches)
+	}
+
+	// 重新打开时从数据库重建索引，高位为 1 的哈希按位保存
+	reopened, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if reopened.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", reopened.Len())
+	}
+	if notes, _ := reopened.FindSimilar("unrelated", 0); len(notes) != 0 {
+		t.Fatalf("unexpected matches %+v", notes)
+	}
+	if notes, _ := reopened.FindSimilar("repost", 2); len(notes) != 1 || notes[0].NoteID != "original" {
+		t.Fatalf("unexpected matches %+v", notes)
+	}
+}
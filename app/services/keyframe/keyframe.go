@@ -0,0 +1,142 @@
+// Package keyframe 保存视频笔记的关键帧缩略图和联系表，图片写入磁盘，记录关联到笔记
+package keyframe
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/note"
+
+	"gorm.io/gorm"
+)
+
+// 缩略图类型
+const (
+	KindFrame = "frame" // 单张关键帧
+	KindSheet = "sheet" // 联系表
+)
+
+// Quality 保存 JPEG 的质量
+const Quality = 85
+
+// safeID 可以直接作为目录名的笔记 id
+var safeID = regexp.MustCompile(`^[0-9A-Za-z_-]+$`)
+
+// Thumbnail 保存在磁盘上的一张缩略图
+type Thumbnail struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    string    `gorm:"size:64;index;not null" json:"noteId"`
+	Kind      string    `gorm:"size:16;not null" json:"kind"`
+	Index     int       `gorm:"column:frame_index" json:"index"`
+	TimeMs    int64     `json:"timeMs"` // 关键帧在视频中的位置，联系表为 0
+	Score     float64   `json:"score"`
+	Path      string    `gorm:"type:text;not null" json:"path"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName 指定缩略图表名
+func (Thumbnail) TableName() string {
+	return "note_keyframes"
+}
+
+// Store 关键帧存储，图片保存在 dir/<笔记 id>/ 下
+type Store struct {
+	db  *gorm.DB
+	dir string
+}
+
+// NewStore 创建关键帧存储并迁移表结构
+func NewStore(db *gorm.DB, dir string) (*Store, error) {
+	if err := db.AutoMigrate(&Thumbnail{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate keyframes: %w", err)
+	}
+	return &Store{db: db, dir: dir}, nil
+}
+
+// Save 保存笔记的关键帧和联系表，sheet 为 nil 时不保存联系表
+// 同一篇笔记再次提取时替换之前的结果
+func (s *Store) Save(noteID string, frames []note.Keyframe, sheet image.Image) ([]Thumbnail, error) {
+	if !safeID.MatchString(noteID) {
+		return nil, fmt.Errorf("invalid note id %q", noteID)
+	}
+	if err := s.Delete(noteID); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(s.dir, noteID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	thumbnails := make([]Thumbnail, 0, len(frames)+1)
+	for _, frame := range frames {
+		path := filepath.Join(dir, fmt.Sprintf("%03d.jpg", frame.Index))
+		if err := writeJPEG(path, frame.Image); err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, Thumbnail{
+			NoteID: noteID,
+			Kind:   KindFrame,
+			Index:  frame.Index,
+			TimeMs: frame.Time.Milliseconds(),
+			Score:  frame.Score,
+			Path:   path,
+			Width:  frame.Image.Bounds().Dx(),
+			Height: frame.Image.Bounds().Dy(),
+		})
+	}
+	if sheet != nil {
+		path := filepath.Join(dir, "sheet.jpg")
+		if err := writeJPEG(path, sheet); err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, Thumbnail{
+			NoteID: noteID,
+			Kind:   KindSheet,
+			Path:   path,
+			Width:  sheet.Bounds().Dx(),
+			Height: sheet.Bounds().Dy(),
+		})
+	}
+	if len(thumbnails) == 0 {
+		return thumbnails, nil
+	}
+	if err := s.db.Create(&thumbnails).Error; err != nil {
+		return nil, fmt.Errorf("failed to save keyframes of %s: %w", noteID, err)
+	}
+	return thumbnails, nil
+}
+
+// List 笔记的所有缩略图，关键帧按序号排列，联系表在最后
+func (s *Store) List(noteID string) ([]Thumbnail, error) {
+	var thumbnails []Thumbnail
+	err := s.db.Where("note_id = ?", noteID).Order("kind = '" + KindSheet + "', frame_index").Find(&thumbnails).Error
+	return thumbnails, err
+}
+
+// Delete 删除笔记的缩略图记录和图片文件
+func (s *Store) Delete(noteID string) error {
+	if !safeID.MatchString(noteID) {
+		return fmt.Errorf("invalid note id %q", noteID)
+	}
+	if err := s.db.Where("note_id = ?", noteID).Delete(&Thumbnail{}).Error; err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.dir, noteID))
+}
+
+func writeJPEG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(file, img, &jpeg.Options{Quality: Quality}); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,44 @@
+package keyframe
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/services/xiaohongshu/note"
+)
+
+func TestStoreSaveReplacesPreviousResult(t *testing.T) {
+	dir := t.TempDir()
+	db := dbtest.Open(t)
+	store, err := NewStore(db, filepath.Join(dir, "keyframes"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	frame := func(index int) note.Keyframe {
+		return note.Keyframe{Index: index, Time: time.Duration(index) * 5 * time.Second, Image: image.NewRGBA(image.Rect(0, 0, 32, 18))}
+	}
+
+	if _, err := store.Save("note_video", []note.Keyframe{frame(0), frame(1), frame(2)}, image.NewRGBA(image.Rect(0, 0, 64, 36))); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if _, err := store.Save("note_video", []note.Keyframe{frame(0)}, nil); err != nil {
+		t.Fatalf("Save again: %v", err)
+	}
+	thumbnails, err := store.List("note_video")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(thumbnails) != 1 || thumbnails[0].Kind != KindFrame || thumbnails[0].Width != 32 {
+		t.Fatalf("expected only the latest frame, got %+v", thumbnails)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "keyframes", "note_video"))
+	if len(entries) != 1 {
+		t.Fatalf("expected old images to be removed, got %d files", len(entries))
+	}
+	if _, err := store.Save("../escape", nil, nil); err == nil {
+		t.Fatal("expected invalid note id to be rejected")
+	}
+}
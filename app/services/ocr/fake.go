@@ -0,0 +1,42 @@
+package ocr
+
+import (
+	"context"
+	"image"
+	"sync"
+)
+
+// Fake 返回固定结果的识别引擎，用于测试
+type Fake struct {
+	Blocks []Block
+	Err    error
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Name 引擎名称
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Recognize 返回 f.Blocks 或 f.Err
+func (f *Fake) Recognize(ctx context.Context, _ image.Image) ([]Block, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return append([]Block{}, f.Blocks...), nil
+}
+
+// Calls Recognize 被调用的次数
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
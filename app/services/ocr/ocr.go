@@ -0,0 +1,78 @@
+// Package ocr 识别笔记图片中的文字。
+// 识别由 Provider 完成，结果按图片保存并加入本地全文索引，搜索时可以匹配图片中的文字。
+package ocr
+
+import (
+	"context"
+	"image"
+	"strings"
+	"unicode"
+)
+
+// Box 文字在图片中的位置，单位为像素
+type Box struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Union 同时包含 b 和 other 的最小矩形
+func (b Box) Union(other Box) Box {
+	if b.Width == 0 && b.Height == 0 {
+		return other
+	}
+	r := image.Rect(b.X, b.Y, b.X+b.Width, b.Y+b.Height).
+		Union(image.Rect(other.X, other.Y, other.X+other.Width, other.Y+other.Height))
+	return Box{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()}
+}
+
+// Block 识别出的一行文字
+type Block struct {
+	Text       string  `json:"text"`
+	Box        Box     `json:"box"`
+	Confidence float64 `json:"confidence"` // 0-100
+}
+
+// Provider 文字识别引擎
+type Provider interface {
+	// Name 引擎名称，与识别结果一起保存
+	Name() string
+	// Recognize 识别图片中的文字，按阅读顺序返回每一行，没有文字时返回空切片
+	Recognize(ctx context.Context, img image.Image) ([]Block, error)
+}
+
+// Text 把识别结果拼成纯文本，每行一个 Block
+func Text(blocks []Block) string {
+	lines := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if text := strings.TrimSpace(block.Text); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// joinWords 拼接一行中的词，中文等不使用空格分词的文字之间不加空格
+func joinWords(words []string) string {
+	var b strings.Builder
+	var last rune
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) == 0 {
+			continue
+		}
+		if b.Len() > 0 && !(unspaced(last) && unspaced(runes[0])) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+		last = runes[len(runes)-1]
+	}
+	return b.String()
+}
+
+// unspaced 中日韩文字和全角标点，这些字符之间不加空格
+func unspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r > unicode.MaxLatin1 && unicode.IsPunct(r))
+}
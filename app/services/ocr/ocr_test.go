@@ -0,0 +1,107 @@
+package ocr
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/services/search"
+	"xiaohongshu/app/services/xiaohongshu/note"
+)
+
+const tsv = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t400\t300\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t10\t20\t100\t30\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t10\t20\t30\t30\t90\t秋冬\n" +
+	"5\t1\t1\t1\t1\t2\t40\t22\t30\t28\t80\t穿搭\n" +
+	"5\t1\t1\t1\t1\t3\t80\t20\t30\t30\t70\tOOTD\n" +
+	"5\t1\t1\t1\t2\t1\t10\t60\t50\t20\t60\tHello\n" +
+	"5\t1\t1\t1\t2\t2\t70\t60\t50\t20\t-1\t \n" +
+	"5\t1\t1\t1\t2\t3\t130\t60\t50\t20\t40\tworld\n"
+
+func TestParseTSV(t *testing.T) {
+	blocks, err := ParseTSV(strings.NewReader(tsv))
+	if err != nil {
+		t.Fatalf("ParseTSV: %v", err)
+	}
+	want := []Block{
+		{Text: "秋冬穿搭 OOTD", Box: Box{X: 10, Y: 20, Width: 100, Height: 30}, Confidence: 80},
+		{Text: "Hello world", Box: Box{X: 10, Y: 60, Width: 170, Height: 20}, Confidence: 50},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %+v, want %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+	if _, err := ParseTSV(strings.NewReader("level\ttext\n")); err == nil {
+		t.Error("expected error for missing columns")
+	}
+}
+
+func TestPipelineIndexesImageText(t *testing.T) {
+	db := dbtest.Open(t)
+	index, err := search.NewIndex(db)
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	store, err := NewStore(db, index)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	provider := &Fake{Blocks: []Block{{Text: "限时折扣", Box: Box{Width: 10, Height: 10}, Confidence: 90}}}
+	pipeline := NewPipeline(store, provider, func(url string) (image.Image, error) {
+		if url == "broken.jpg" {
+			return nil, errors.New("404")
+		}
+		return image.NewGray(image.Rect(0, 0, 8, 8)), nil
+	})
+	bus := eventbus.New()
+	progress := make(chan Progress, 8)
+	bus.Subscribe(eventbus.TopicOCRProgress, func(p Progress) { progress <- p })
+	if err := pipeline.Start(bus); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer pipeline.Stop()
+
+	wait := func() Progress {
+		t.Helper()
+		select {
+		case p := <-progress:
+			return p
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for progress")
+			return Progress{}
+		}
+	}
+	bus.Publish(eventbus.TopicNoteOpened, note.NoteDetail{ID: "n1", Images: []string{"a.jpg", "broken.jpg"}})
+	if p := wait(); p.URL != "a.jpg" || p.Done != 1 || p.Total != 2 || p.Text != "限时折扣" {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if p := wait(); p.URL != "broken.jpg" || p.Done != 2 || p.Failed != 1 || p.Error == "" {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+
+	// 再次打开时跳过已识别的图片
+	pipeline.Enqueue("n1", "a.jpg")
+	if p := wait(); !p.Skipped || p.Total != 1 {
+		t.Fatalf("unexpected progress %+v", p)
+	}
+	if provider.Calls() != 1 {
+		t.Fatalf("Recognize called %d times, want 1", provider.Calls())
+	}
+
+	rows, err := store.ForNote("n1")
+	if err != nil || len(rows) != 1 || len(rows[0].Blocks) != 1 || rows[0].Provider != "fake" {
+		t.Fatalf("unexpected rows %+v, %v", rows, err)
+	}
+	hits, err := index.Search("折扣", 0)
+	if err != nil || len(hits) != 1 || hits[0].Source != search.SourceImage || hits[0].Ref != "a.jpg" {
+		t.Fatalf("unexpected hits %+v, %v", hits, err)
+	}
+}
@@ -0,0 +1,178 @@
+package ocr
+
+import (
+	"context"
+	"image"
+	"sync"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/note"
+
+	"github.com/asaskevich/EventBus"
+)
+
+var logger = logging.Component("ocr")
+
+// queueSize 等待识别的图片数量上限，超出时丢弃并记录日志
+const queueSize = 256
+
+// Loader 下载并解码一张图片
+type Loader func(url string) (image.Image, error)
+
+// Progress 识别进度，每处理完一张图片发布一次
+// Total 为队列从空闲开始累计加入的图片数，全部处理完后重新计数
+type Progress struct {
+	NoteID  string `json:"noteId"`
+	URL     string `json:"url"`
+	Done    int    `json:"done"`
+	Failed  int    `json:"failed"`
+	Total   int    `json:"total"`
+	Skipped bool   `json:"skipped"` // 已经识别过
+	Text    string `json:"text"`
+	Error   string `json:"error"`
+}
+
+type job struct {
+	noteID string
+	url    string
+}
+
+// Pipeline 笔记打开后在后台下载每张图片并识别文字，已识别过的图片跳过
+type Pipeline struct {
+	store    *Store
+	provider Provider
+	load     Loader
+	bus      EventBus.Bus
+	jobs     chan job
+	ctx      context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+
+	mu     sync.Mutex
+	done   int
+	failed int
+	total  int
+}
+
+// NewPipeline 创建文字识别流水线，调用 Start 后开始处理
+func NewPipeline(store *Store, provider Provider, load Loader) *Pipeline {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pipeline{
+		store:    store,
+		provider: provider,
+		load:     load,
+		jobs:     make(chan job, queueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 订阅 note:opened 并启动后台处理，进度发布到 ocr:progress
+func (p *Pipeline) Start(bus EventBus.Bus) error {
+	if err := bus.Subscribe(eventbus.TopicNoteOpened, p.onNoteOpened); err != nil {
+		return err
+	}
+	p.bus = bus
+	go p.run()
+	return nil
+}
+
+// Stop 取消订阅并停止后台处理，正在识别的图片被中断，排队中的图片不再识别
+func (p *Pipeline) Stop() {
+	p.once.Do(func() {
+		if p.bus != nil {
+			_ = p.bus.Unsubscribe(eventbus.TopicNoteOpened, p.onNoteOpened)
+		}
+		p.cancel()
+	})
+}
+
+// Enqueue 把笔记的图片加入队列
+func (p *Pipeline) Enqueue(noteID string, urls ...string) {
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		p.mu.Lock()
+		select {
+		case p.jobs <- job{noteID: noteID, url: url}:
+			p.total++
+		default:
+			logger.Warn("ocr queue is full", "note", noteID, "url", url)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Progress 当前的识别进度
+func (p *Pipeline) Progress() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Progress{Done: p.done, Failed: p.failed, Total: p.total}
+}
+
+func (p *Pipeline) onNoteOpened(detail note.NoteDetail) {
+	p.Enqueue(detail.ID, detail.Images...)
+}
+
+func (p *Pipeline) run() {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case j := <-p.jobs:
+			progress := p.process(j)
+			if progress.Error != "" {
+				logger.Warn("failed to recognize image", "note", j.noteID, "url", j.url, "error", progress.Error)
+			}
+			if p.bus != nil {
+				p.bus.Publish(eventbus.TopicOCRProgress, progress)
+			}
+		}
+	}
+}
+
+// process 识别一张图片并更新进度
+func (p *Pipeline) process(j job) Progress {
+	progress := Progress{NoteID: j.noteID, URL: j.url}
+	text, skipped, err := p.recognize(j)
+	progress.Text = text
+	progress.Skipped = skipped
+	if err != nil {
+		progress.Error = err.Error()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if err != nil {
+		p.failed++
+	}
+	progress.Done, progress.Failed, progress.Total = p.done, p.failed, p.total
+	// 队列处理完后重新计数
+	if p.done >= p.total {
+		p.done, p.failed, p.total = 0, 0, 0
+	}
+	return progress
+}
+
+func (p *Pipeline) recognize(j job) (string, bool, error) {
+	if exists, err := p.store.Has(j.noteID, j.url); err != nil || exists {
+		return "", exists, err
+	}
+	img, err := p.load(j.url)
+	if err != nil {
+		// 下载失败不保存，下次打开笔记时重试
+		return "", false, err
+	}
+	blocks, err := p.provider.Recognize(p.ctx, img)
+	if p.ctx.Err() != nil {
+		return "", false, p.ctx.Err()
+	}
+	row, saveErr := p.store.Save(j.noteID, j.url, p.provider.Name(), blocks, err)
+	if err != nil {
+		return "", false, err
+	}
+	logger.Debug("image recognized", "note", j.noteID, "url", j.url, "lines", len(blocks))
+	return row.Text, false, saveErr
+}
@@ -0,0 +1,88 @@
+package ocr
+
+import (
+	"fmt"
+	"time"
+	"xiaohongshu/app/services/search"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImageText 一张笔记图片的识别结果，Blocks 以 JSON 保存
+type ImageText struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	NoteID    string    `gorm:"size:64;not null;uniqueIndex:idx_image_text_note_url" json:"noteId"`
+	URL       string    `gorm:"size:512;not null;uniqueIndex:idx_image_text_note_url" json:"url"`
+	Provider  string    `gorm:"size:64" json:"provider"`
+	Text      string    `gorm:"type:text" json:"text"`
+	Blocks    []Block   `gorm:"type:text;serializer:json" json:"blocks"`
+	Error     string    `gorm:"type:text" json:"error"` // 识别失败的原因，失败的图片不再重试
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName 指定识别结果表名
+func (ImageText) TableName() string {
+	return "image_texts"
+}
+
+// Store 识别结果存储，保存时同步更新全文索引
+type Store struct {
+	db    *gorm.DB
+	index *search.Index
+}
+
+// NewStore 创建识别结果存储并迁移表结构，index 为 nil 时不更新全文索引
+func NewStore(db *gorm.DB, index *search.Index) (*Store, error) {
+	if err := db.AutoMigrate(&ImageText{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate image texts: %w", err)
+	}
+	return &Store{db: db, index: index}, nil
+}
+
+// Has 笔记的这张图片是否已经识别过，包括识别失败的
+func (s *Store) Has(noteID, url string) (bool, error) {
+	var count int64
+	err := s.db.Model(&ImageText{}).Where("note_id = ? AND url = ?", noteID, url).Count(&count).Error
+	return count > 0, err
+}
+
+// Save 保存识别结果并加入全文索引，同一张图片再次识别时覆盖之前的结果
+// recognizeErr 不为 nil 时记录失败原因
+func (s *Store) Save(noteID, url, provider string, blocks []Block, recognizeErr error) (ImageText, error) {
+	row := ImageText{
+		NoteID:   noteID,
+		URL:      url,
+		Provider: provider,
+		Text:     Text(blocks),
+		Blocks:   blocks,
+	}
+	if row.Blocks == nil {
+		row.Blocks = []Block{}
+	}
+	if recognizeErr != nil {
+		row.Error = recognizeErr.Error()
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "note_id"}, {Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "text", "blocks", "error", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return row, fmt.Errorf("failed to save image text: %w", err)
+	}
+	if s.index != nil {
+		doc := search.Document{NoteID: noteID, Source: search.SourceImage, Ref: url, Text: row.Text}
+		if err := s.index.Put(doc); err != nil {
+			return row, err
+		}
+	}
+	return row, nil
+}
+
+// ForNote 笔记所有图片的识别结果
+func (s *Store) ForNote(noteID string) ([]ImageText, error) {
+	var rows []ImageText
+	err := s.db.Where("note_id = ?", noteID).Order("id").Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,151 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// 默认的 tesseract 命令和识别语言
+const (
+	DefaultTesseract = "tesseract"
+	DefaultLanguages = "chi_sim+eng"
+)
+
+// Tesseract 调用本地 tesseract 命令识别文字，图片通过标准输入传入，结果为 TSV
+type Tesseract struct {
+	Path      string // tesseract 可执行文件，为空时从 PATH 查找
+	Languages string // 如 chi_sim+eng，需要安装对应的语言包
+}
+
+// NewTesseract 创建 tesseract 识别引擎，参数为空时使用默认值
+func NewTesseract(path string, languages string) *Tesseract {
+	if path == "" {
+		path = DefaultTesseract
+	}
+	if languages == "" {
+		languages = DefaultLanguages
+	}
+	return &Tesseract{Path: path, Languages: languages}
+}
+
+// Name 引擎名称
+func (t *Tesseract) Name() string {
+	return "tesseract:" + t.Languages
+}
+
+// Available 检查 tesseract 命令是否存在
+func (t *Tesseract) Available() error {
+	if _, err := exec.LookPath(t.Path); err != nil {
+		return fmt.Errorf("tesseract is not installed: %w", err)
+	}
+	return nil
+}
+
+// Recognize 识别图片中的文字
+func (t *Tesseract) Recognize(ctx context.Context, img image.Image) ([]Block, error) {
+	var input bytes.Buffer
+	if err := png.Encode(&input, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	// --psm 3 自动分析版面，适合海报和截图中的多段文字
+	cmd := exec.CommandContext(ctx, t.Path, "stdin", "stdout", "-l", t.Languages, "--psm", "3", "tsv")
+	cmd.Stdin = &input
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseTSV(&stdout)
+}
+
+// line 一行文字在 TSV 中的位置
+type line struct {
+	page, block, par, line int
+}
+
+// ParseTSV 解析 tesseract 的 TSV 输出，把同一行的词合并为一个 Block
+// 行的位置为所有词的并集，置信度为词的平均值
+func ParseTSV(r io.Reader) ([]Block, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []Block{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tsv header: %w", err)
+	}
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[name] = i
+	}
+	for _, name := range []string{"level", "page_num", "block_num", "par_num", "line_num", "left", "top", "width", "height", "conf", "text"} {
+		if _, ok := column[name]; !ok {
+			return nil, fmt.Errorf("tsv column %q is missing", name)
+		}
+	}
+
+	type pending struct {
+		words []string
+		box   Box
+		conf  float64
+	}
+	var (
+		order []line
+		lines = make(map[line]*pending)
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tsv: %w", err)
+		}
+		if len(record) < len(header) {
+			continue
+		}
+		number := func(name string) int {
+			n, _ := strconv.Atoi(record[column[name]])
+			return n
+		}
+		text := strings.TrimSpace(record[column["text"]])
+		conf, _ := strconv.ParseFloat(record[column["conf"]], 64)
+		// level 5 为单词，conf 为 -1 的是版面元素
+		if number("level") != 5 || text == "" || conf < 0 {
+			continue
+		}
+		key := line{page: number("page_num"), block: number("block_num"), par: number("par_num"), line: number("line_num")}
+		p, ok := lines[key]
+		if !ok {
+			p = &pending{}
+			lines[key] = p
+			order = append(order, key)
+		}
+		p.words = append(p.words, text)
+		p.box = p.box.Union(Box{X: number("left"), Y: number("top"), Width: number("width"), Height: number("height")})
+		p.conf += conf
+	}
+
+	blocks := make([]Block, 0, len(order))
+	for _, key := range order {
+		p := lines[key]
+		blocks = append(blocks, Block{
+			Text:       joinWords(p.words),
+			Box:        p.box,
+			Confidence: p.conf / float64(len(p.words)),
+		})
+	}
+	return blocks, nil
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultAccount 未指定账号时任务所属的账号
+const DefaultAccount = "default"
+
+// 任务类型
+const (
+	// KindCrawlChannel 抓取推荐页某个频道若干页，参数 CrawlChannelParams
+	KindCrawlChannel = "crawl-channel"
+	// KindSearchKeyword 搜索关键词，参数 SearchKeywordParams
+	KindSearchKeyword = "search-keyword"
+	// KindRefreshProfile 刷新用户主页，参数 RefreshProfileParams
+	KindRefreshProfile = "refresh-profile"
+	// KindRecrawlComments 重新抓取笔记评论，参数 RecrawlCommentsParams
+	KindRecrawlComments = "recrawl-comments"
+	// KindCheckSelectors 打开代表性页面检查选择器是否失效，参数 CheckSelectorsParams
+	KindCheckSelectors = "check-selectors"
+)
+
+// 任务执行状态
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// CrawlChannelParams 频道抓取参数
+type CrawlChannelParams struct {
+	Channel string `json:"channel"`
+	Pages   int    `json:"pages"`
+}
+
+// SearchKeywordParams 关键词搜索参数
+type SearchKeywordParams struct {
+	Keyword string `json:"keyword"`
+	Pages   int    `json:"pages"`
+}
+
+// RefreshProfileParams 用户主页刷新参数
+type RefreshProfileParams struct {
+	UserId string `json:"userId"`
+}
+
+// RecrawlCommentsParams 评论重抓参数
+type RecrawlCommentsParams struct {
+	NoteId string `json:"noteId"`
+}
+
+// CheckSelectorsParams 选择器健康检查参数，Keyword 为检查搜索页使用的关键词
+type CheckSelectorsParams struct {
+	Keyword string `json:"keyword"`
+}
+
+// Job 定时任务定义
+type Job struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:128" json:"name"`
+	Kind       string     `gorm:"size:64;not null" json:"kind"`
+	Account    string     `gorm:"size:64;index" json:"account"`
+	Spec       string     `gorm:"size:128;not null" json:"spec"` // cron 表达式，如 "0 8 * * *" 或 "@daily"
+	Params     string     `gorm:"type:text" json:"params"`
+	Paused     bool       `json:"paused"`
+	MaxRetries int        `json:"maxRetries"`
+	LastRunAt  *time.Time `json:"lastRunAt"`
+	NextRunAt  *time.Time `json:"nextRunAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// TableName 指定任务表名
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// DecodeParams 将任务参数解析到 v 中
+func (j Job) DecodeParams(v interface{}) error {
+	if j.Params == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(j.Params), v)
+}
+
+// JobRun 任务的一次执行记录，每次重试单独记录
+type JobRun struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JobID      uint       `gorm:"index" json:"jobId"`
+	Account    string     `gorm:"size:64" json:"account"`
+	Attempt    int        `json:"attempt"`
+	Status     string     `gorm:"size:16" json:"status"`
+	Error      string     `gorm:"type:text" json:"error"`
+	Result     string     `gorm:"type:text" json:"result"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+}
+
+// TableName 指定任务执行记录表名
+func (JobRun) TableName() string {
+	return "job_runs"
+}
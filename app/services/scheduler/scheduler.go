@@ -0,0 +1,460 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// Handler 执行一种类型的任务，返回的结果会以 JSON 形式保存在执行记录中
+type Handler func(ctx context.Context, job Job) (interface{}, error)
+
+// RunFinished 任务执行结束后在事件总线上发布的载荷
+type RunFinished struct {
+	JobID   uint   `json:"jobId"`
+	Kind    string `json:"kind"`
+	Account string `json:"account"`
+	Status  string `json:"status"`
+	Attempt int    `json:"attempt"`
+	Error   string `json:"error"`
+}
+
+// Scheduler 基于 cron 表达式的任务调度器，任务与执行记录保存在数据库中
+// 同一账号同一时间只会执行一个任务
+type Scheduler struct {
+	db         *gorm.DB
+	bus        EventBus.Bus
+	cron       *cron.Cron
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mu         sync.Mutex
+	handlers   map[string]Handler
+	entries    map[uint]cron.EntryID
+	running    map[uint]context.CancelFunc // 按任务 id，同一任务同一时间只执行一次
+	accounts   map[string]chan struct{}
+	paused     map[string]bool // 被暂停的账号，例如触发风控时
+	started    bool
+	RetryDelay time.Duration // 第 n 次重试前等待 n*RetryDelay
+}
+
+var logger = logging.Component("scheduler")
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// NewScheduler 创建调度器并迁移任务相关的表，任务执行结束后在 bus 上发布 TopicJobFinished
+func NewScheduler(db *gorm.DB, bus EventBus.Bus) (*Scheduler, error) {
+	if err := db.AutoMigrate(&Job{}, &JobRun{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate jobs: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:         db,
+		bus:        bus,
+		cron:       cron.New(cron.WithParser(parser)),
+		ctx:        ctx,
+		cancel:     cancel,
+		handlers:   make(map[string]Handler),
+		entries:    make(map[uint]cron.EntryID),
+		running:    make(map[uint]context.CancelFunc),
+		accounts:   make(map[string]chan struct{}),
+		paused:     make(map[string]bool),
+		RetryDelay: 30 * time.Second,
+	}, nil
+}
+
+// Register 注册某种任务类型的处理函数，需在 Start 之前调用
+func (s *Scheduler) Register(kind string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+// Start 加载所有未暂停的任务并开始调度，重复调用无效
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	var jobs []Job
+	if err := s.db.Where("paused = ?", false).Find(&jobs).Error; err != nil {
+		return fmt.Errorf("failed to load jobs: %w", err)
+	}
+	for _, job := range jobs {
+		if err := s.schedule(job); err != nil {
+			logger.Error("failed to schedule job", "job", job.ID, "error", err)
+		}
+	}
+	s.cron.Start()
+	return nil
+}
+
+// Stop 停止调度并取消正在执行的任务，等待调度器退出
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.cron.Stop().Done()
+}
+
+// CreateJob 校验并保存任务，调度器已启动时立即开始调度
+func (s *Scheduler) CreateJob(job Job) (Job, error) {
+	if job.Account == "" {
+		job.Account = DefaultAccount
+	}
+	if _, err := parser.Parse(job.Spec); err != nil {
+		return job, fmt.Errorf("invalid schedule %q: %w", job.Spec, err)
+	}
+	s.mu.Lock()
+	_, ok := s.handlers[job.Kind]
+	s.mu.Unlock()
+	if !ok {
+		return job, fmt.Errorf("unknown job kind: %s", job.Kind)
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return job, fmt.Errorf("failed to save job: %w", err)
+	}
+	if !job.Paused && s.isStarted() {
+		if err := s.schedule(job); err != nil {
+			return job, err
+		}
+	}
+	return s.GetJob(job.ID)
+}
+
+// DeleteJob 删除任务及其执行记录
+func (s *Scheduler) DeleteJob(id uint) error {
+	s.unschedule(id)
+	s.cancelRunning(id)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id = ?", id).Delete(&JobRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Job{}, id).Error
+	})
+}
+
+// GetJob 获取单个任务
+func (s *Scheduler) GetJob(id uint) (Job, error) {
+	var job Job
+	if err := s.db.First(&job, id).Error; err != nil {
+		return job, fmt.Errorf("job %d not found: %w", id, err)
+	}
+	return job, nil
+}
+
+// ListJobs 列出所有任务
+func (s *Scheduler) ListJobs() ([]Job, error) {
+	var jobs []Job
+	err := s.db.Order("id ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+// History 返回任务最近的执行记录，按时间倒序
+func (s *Scheduler) History(jobID uint, limit int) ([]JobRun, error) {
+	var runs []JobRun
+	tx := s.db.Where("job_id = ?", jobID).Order("started_at DESC, id DESC")
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	err := tx.Find(&runs).Error
+	return runs, err
+}
+
+// Pause 暂停任务，正在执行的任务会被取消
+func (s *Scheduler) Pause(id uint) error {
+	if err := s.db.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{"paused": true, "next_run_at": nil}).Error; err != nil {
+		return err
+	}
+	s.unschedule(id)
+	s.cancelRunning(id)
+	return nil
+}
+
+// Resume 恢复被暂停的任务
+func (s *Scheduler) Resume(id uint) error {
+	if err := s.db.Model(&Job{}).Where("id = ?", id).Update("paused", false).Error; err != nil {
+		return err
+	}
+	job, err := s.GetJob(id)
+	if err != nil {
+		return err
+	}
+	if s.isStarted() {
+		return s.schedule(job)
+	}
+	return nil
+}
+
+// PauseAccount 暂停账号的所有任务：取消正在执行的任务，之后的调度直接跳过
+// 与 Pause 不同，不修改任务自身的暂停状态，ResumeAccount 后恢复原有调度
+func (s *Scheduler) PauseAccount(account string) {
+	s.mu.Lock()
+	s.paused[account] = true
+	s.mu.Unlock()
+
+	var jobs []Job
+	if err := s.db.Where("account = ?", account).Find(&jobs).Error; err != nil {
+		return
+	}
+	for _, job := range jobs {
+		s.cancelRunning(job.ID)
+	}
+}
+
+// ResumeAccount 恢复被暂停账号的任务
+func (s *Scheduler) ResumeAccount(account string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.paused, account)
+}
+
+// AccountPaused 账号是否被暂停
+func (s *Scheduler) AccountPaused(account string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused[account]
+}
+
+// RunNow 立即在后台执行一次任务，不影响原有调度；任务正在执行或等待执行时返回错误
+func (s *Scheduler) RunNow(id uint) error {
+	if _, err := s.GetJob(id); err != nil {
+		return err
+	}
+	if s.isRunning(id) {
+		return fmt.Errorf("job %d is already running", id)
+	}
+	go s.run(id)
+	return nil
+}
+
+func (s *Scheduler) isStarted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+func (s *Scheduler) isRunning(id uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.running[id]
+	return ok
+}
+
+// schedule 将任务加入 cron，已存在时先移除
+func (s *Scheduler) schedule(job Job) error {
+	s.unschedule(job.ID)
+	id := job.ID
+	wrapped := cron.NewChain(cron.SkipIfStillRunning(cron.DiscardLogger)).Then(cron.FuncJob(func() {
+		s.run(id)
+	}))
+	entryID, err := s.cron.AddJob(job.Spec, wrapped)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", job.Spec, err)
+	}
+	s.mu.Lock()
+	s.entries[job.ID] = entryID
+	s.mu.Unlock()
+	// 任务已加入调度，只是下次执行时间没有保存，不影响执行
+	if err := s.updateNextRun(job.ID); err != nil {
+		logger.Error("failed to save next run time", "job", job.ID, "error", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) unschedule(id uint) {
+	s.mu.Lock()
+	entryID, ok := s.entries[id]
+	delete(s.entries, id)
+	s.mu.Unlock()
+	if ok {
+		s.cron.Remove(entryID)
+	}
+}
+
+func (s *Scheduler) cancelRunning(id uint) {
+	s.mu.Lock()
+	cancel, ok := s.running[id]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// updateNextRun 保存任务的下次执行时间，任务不在调度中时什么也不做
+func (s *Scheduler) updateNextRun(id uint) error {
+	s.mu.Lock()
+	entryID, ok := s.entries[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	next := s.cron.Entry(entryID).Next
+	if next.IsZero() {
+		// cron 尚未启动时 Next 未计算，直接由表达式推算
+		job, err := s.GetJob(id)
+		if err != nil {
+			return err
+		}
+		schedule, err := parser.Parse(job.Spec)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q: %w", job.Spec, err)
+		}
+		next = schedule.Next(time.Now())
+	}
+	if err := s.db.Model(&Job{}).Where("id = ?", id).Update("next_run_at", next).Error; err != nil {
+		return fmt.Errorf("failed to update next run: %w", err)
+	}
+	return nil
+}
+
+// accountSlot 返回账号的执行令牌，容量为 1 保证同一账号串行执行
+func (s *Scheduler) accountSlot(account string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slot, ok := s.accounts[account]
+	if !ok {
+		slot = make(chan struct{}, 1)
+		s.accounts[account] = slot
+	}
+	return slot
+}
+
+// run 执行一次任务，失败时按 MaxRetries 重试
+func (s *Scheduler) run(id uint) {
+	job, err := s.GetJob(id)
+	if err != nil || job.Paused || s.AccountPaused(job.Account) {
+		return
+	}
+	s.mu.Lock()
+	handler, ok := s.handlers[job.Kind]
+	s.mu.Unlock()
+	if !ok {
+		logger.Error("no handler registered for job kind", "job", job.ID, "kind", job.Kind)
+		return
+	}
+
+	// 同一次执行（含重试）的日志共享关联 id
+	ctx, cancel := context.WithCancel(logging.WithCorrelation(s.ctx))
+	defer cancel()
+	// 等待账号令牌之前登记，Pause 和 PauseAccount 可以取消等待中的执行；
+	// 上一次执行还没有结束（如 RunNow 与定时触发重叠）时跳过本次
+	s.mu.Lock()
+	if _, running := s.running[id]; running {
+		s.mu.Unlock()
+		logger.Info("job is already running, skipped", "job", id)
+		return
+	}
+	s.running[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+	}()
+
+	slot := s.accountSlot(job.Account)
+	select {
+	case slot <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-slot }()
+
+	// 等待期间任务或账号可能已被暂停
+	if job, err = s.GetJob(id); err != nil || job.Paused || s.AccountPaused(job.Account) {
+		return
+	}
+
+	var run JobRun
+	for attempt := 1; attempt <= job.MaxRetries+1; attempt++ {
+		run = s.attempt(ctx, job, handler, attempt)
+		if run.Status == RunStatusSucceeded || ctx.Err() != nil || s.AccountPaused(job.Account) {
+			break
+		}
+		if attempt <= job.MaxRetries {
+			select {
+			case <-time.After(time.Duration(attempt) * s.RetryDelay):
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	logger.InfoContext(ctx, "job finished", "job", job.ID, "kind", job.Kind, "account", job.Account,
+		"status", run.Status, "attempt", run.Attempt, "error", run.Error)
+	now := time.Now()
+	if err := s.db.Model(&Job{}).Where("id = ?", id).Update("last_run_at", now).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to save last run time", "job", id, "error", err)
+	}
+	if err := s.updateNextRun(id); err != nil {
+		logger.ErrorContext(ctx, "failed to save next run time", "job", id, "error", err)
+	}
+	s.bus.Publish(eventbus.TopicJobFinished, RunFinished{
+		JobID:   job.ID,
+		Kind:    job.Kind,
+		Account: job.Account,
+		Status:  run.Status,
+		Attempt: run.Attempt,
+		Error:   run.Error,
+	})
+}
+
+// attempt 执行一次处理函数并保存执行记录
+func (s *Scheduler) attempt(ctx context.Context, job Job, handler Handler, attempt int) JobRun {
+	run := JobRun{
+		JobID:     job.ID,
+		Account:   job.Account,
+		Attempt:   attempt,
+		Status:    RunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to save job run", "job", job.ID, "attempt", attempt, "error", err)
+	}
+	logger.InfoContext(ctx, "job started", "job", job.ID, "kind", job.Kind, "account", job.Account, "attempt", attempt)
+
+	result, err := invoke(ctx, job, handler)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if err != nil {
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = RunStatusSucceeded
+		if result != nil {
+			data, marshalErr := json.Marshal(result)
+			if marshalErr != nil {
+				run.Error = fmt.Sprintf("failed to encode result: %v", marshalErr)
+			} else {
+				run.Result = string(data)
+			}
+		}
+	}
+	if err := s.db.Save(&run).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to save job run", "job", job.ID, "attempt", attempt, "error", err)
+	}
+	return run
+}
+
+// invoke 调用处理函数，并把 panic 转换为错误
+func invoke(ctx context.Context, job Job, handler Handler) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New(fmt.Sprint("job panicked: ", r))
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/db/dbtest"
+	"xiaohongshu/app/infra/eventbus"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	db := dbtest.Open(t)
+	s, err := NewScheduler(db, eventbus.New())
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	s.RetryDelay = time.Millisecond
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunRetriesAndRecordsHistory(t *testing.T) {
+	s := newTestScheduler(t)
+	var calls int32
+	s.Register(KindSearchKeyword, func(ctx context.Context, job Job) (interface{}, error) {
+		var params SearchKeywordParams
+		if err := job.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("boom")
+		}
+		return map[string]string{"keyword": params.Keyword}, nil
+	})
+	job, err := s.CreateJob(Job{Kind: KindSearchKeyword, Spec: "@daily", Params: `{"keyword":"咖啡"}`, MaxRetries: 2})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if job.Account != DefaultAccount {
+		t.Fatalf("expected default account, got %q", job.Account)
+	}
+	if err := s.RunNow(job.ID); err != nil {
+		t.Fatalf("RunNow: %v", err)
+	}
+	var runs []JobRun
+	waitFor(t, func() bool {
+		runs, _ = s.History(job.ID, 0)
+		return len(runs) == 2 && runs[0].Status != RunStatusRunning
+	})
+	if runs[0].Status != RunStatusSucceeded || runs[0].Result != `{"keyword":"咖啡"}` || runs[1].Status != RunStatusFailed {
+		t.Fatalf("unexpected history: %+v", runs)
+	}
+}
+
+func TestOneJobPerAccount(t *testing.T) {
+	s := newTestScheduler(t)
+	var active, maxActive int32
+	s.Register(KindRefreshProfile, func(ctx context.Context, job Job) (interface{}, error) {
+		n := atomic.AddInt32(&active, 1)
+		if n > atomic.LoadInt32(&maxActive) {
+			atomic.StoreInt32(&maxActive, n)
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		return nil, nil
+	})
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		job, err := s.CreateJob(Job{Kind: KindRefreshProfile, Spec: "@hourly", Account: "a1"})
+		if err != nil {
+			t.Fatalf("CreateJob: %v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+	for _, id := range ids {
+		_ = s.RunNow(id)
+	}
+	waitFor(t, func() bool {
+		for _, id := range ids {
+			runs, _ := s.History(id, 1)
+			if len(runs) == 0 || runs[0].Status == RunStatusRunning {
+				return false
+			}
+		}
+		return true
+	})
+	if maxActive != 1 {
+		t.Fatalf("expected jobs of one account to run serially, max concurrent %d", maxActive)
+	}
+}
+
+func TestCreateJobValidation(t *testing.T) {
+	s := newTestScheduler(t)
+	if _, err := s.CreateJob(Job{Kind: "unknown", Spec: "@daily"}); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+	s.Register(KindCrawlChannel, func(ctx context.Context, job Job) (interface{}, error) { return nil, nil })
+	if _, err := s.CreateJob(Job{Kind: KindCrawlChannel, Spec: "not a cron"}); err == nil {
+		t.Fatal("expected error for invalid spec")
+	}
+}
+
+func TestPauseAccountCancelsAndSkips(t *testing.T) {
+	s := newTestScheduler(t)
+	started := make(chan struct{}, 1)
+	s.Register(KindRecrawlComments, func(ctx context.Context, job Job) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	job, err := s.CreateJob(Job{Kind: KindRecrawlComments, Spec: "@daily", Account: "a1", MaxRetries: 3})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	_ = s.RunNow(job.ID)
+	<-started
+	s.PauseAccount("a1")
+	var runs []JobRun
+	waitFor(t, func() bool {
+		runs, _ = s.History(job.ID, 0)
+		return len(runs) == 1 && runs[0].Status == RunStatusFailed
+	})
+
+	s.run(job.ID)
+	if runs, _ = s.History(job.ID, 0); len(runs) != 1 {
+		t.Fatalf("expected paused account to skip runs, got %d runs", len(runs))
+	}
+	s.ResumeAccount("a1")
+	waitFor(t, func() bool { return !s.isRunning(job.ID) })
+	go s.run(job.ID)
+	<-started
+}
+
+func TestRunSkipsOverlapAndCancelsWaiting(t *testing.T) {
+	s := newTestScheduler(t)
+	started := make(chan uint, 2)
+	release := make(chan struct{})
+	s.Register(KindRefreshProfile, func(ctx context.Context, job Job) (interface{}, error) {
+		started <- job.ID
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil, ctx.Err()
+	})
+	first, err := s.CreateJob(Job{Kind: KindRefreshProfile, Spec: "@hourly", Account: "a1"})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	second, err := s.CreateJob(Job{Kind: KindRefreshProfile, Spec: "@hourly", Account: "a1"})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	_ = s.RunNow(first.ID)
+	<-started
+	if err := s.RunNow(first.ID); err == nil {
+		t.Fatal("expected overlapping run to be refused")
+	}
+	s.run(first.ID)
+
+	// 第二个任务在等待账号令牌时被暂停，拿到令牌后也不应执行
+	go s.run(second.ID)
+	waitFor(t, func() bool { return s.isRunning(second.ID) })
+	if err := s.Pause(second.ID); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	waitFor(t, func() bool { return !s.isRunning(second.ID) })
+	close(release)
+	waitFor(t, func() bool { return !s.isRunning(first.ID) })
+	if runs, _ := s.History(first.ID, 0); len(runs) != 1 {
+		t.Fatalf("expected one run of the first job, got %d", len(runs))
+	}
+	if runs, _ := s.History(second.ID, 0); len(runs) != 0 {
+		t.Fatalf("expected paused job not to run, got %d runs", len(runs))
+	}
+}
@@ -0,0 +1,168 @@
+// Package search 本地全文索引，收录笔记的标题、正文和图片中识别出的文字。
+// 使用 SQLite FTS5，中文按单字切分后建立索引，查询时每个词按短语匹配。
+package search
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/note"
+
+	"github.com/asaskevich/EventBus"
+	"gorm.io/gorm"
+)
+
+var logger = logging.Component("search")
+
+// 文档来源
+const (
+	SourceNote  = "note"  // 笔记的标题和正文
+	SourceImage = "image" // 图片中识别出的文字，Ref 为图片地址
+)
+
+// DefaultLimit 搜索结果的默认数量
+const DefaultLimit = 50
+
+// tableName FTS5 虚拟表名
+const tableName = "search_index"
+
+// Document 索引中的一篇文档，同一篇笔记的同一来源和 Ref 只保留一篇
+type Document struct {
+	NoteID string `json:"noteId"`
+	Source string `json:"source"`
+	Ref    string `json:"ref"`
+	Text   string `json:"text"`
+}
+
+// Hit 一条搜索结果，Rank 越小越相关
+type Hit struct {
+	Document
+	Rank float64 `json:"rank"`
+}
+
+// Index 全文索引
+type Index struct {
+	db  *gorm.DB
+	mu  sync.Mutex // Put 先删除后插入，需要串行执行
+	bus EventBus.Bus
+}
+
+// NewIndex 创建全文索引，虚拟表不存在时创建
+func NewIndex(db *gorm.DB) (*Index, error) {
+	// content 是切分后的文本，text 保存原文用于展示
+	err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + tableName + ` USING fts5(
+		note_id UNINDEXED, source UNINDEXED, ref UNINDEXED, text UNINDEXED, content,
+		tokenize = 'unicode61 remove_diacritics 2'
+	)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+	return &Index{db: db}, nil
+}
+
+// Start 订阅 note:opened，把打开的笔记的标题和正文加入索引
+func (i *Index) Start(bus EventBus.Bus) error {
+	if err := bus.Subscribe(eventbus.TopicNoteOpened, i.onNoteOpened); err != nil {
+		return err
+	}
+	i.bus = bus
+	return nil
+}
+
+// Stop 取消订阅
+func (i *Index) Stop() {
+	if i.bus != nil {
+		_ = i.bus.Unsubscribe(eventbus.TopicNoteOpened, i.onNoteOpened)
+		i.bus = nil
+	}
+}
+
+func (i *Index) onNoteOpened(detail note.NoteDetail) {
+	text := strings.TrimSpace(detail.Title + "\n" + detail.Desc)
+	if detail.ID == "" || text == "" {
+		return
+	}
+	if err := i.Put(Document{NoteID: detail.ID, Source: SourceNote, Text: text}); err != nil {
+		logger.Warn("failed to index note", "note", detail.ID, "error", err)
+	}
+}
+
+// Put 加入或替换一篇文档，文本为空时只删除旧文档
+func (i *Index) Put(doc Document) error {
+	if doc.NoteID == "" || doc.Source == "" {
+		return fmt.Errorf("note id and source are required")
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`DELETE FROM `+tableName+` WHERE note_id = ? AND source = ? AND ref = ?`,
+			doc.NoteID, doc.Source, doc.Ref).Error
+		if err != nil || strings.TrimSpace(doc.Text) == "" {
+			return err
+		}
+		return tx.Exec(`INSERT INTO `+tableName+` (note_id, source, ref, text, content) VALUES (?, ?, ?, ?, ?)`,
+			doc.NoteID, doc.Source, doc.Ref, doc.Text, Segment(doc.Text)).Error
+	})
+}
+
+// DeleteNote 删除笔记的所有文档
+func (i *Index) DeleteNote(noteID string) error {
+	return i.db.Exec(`DELETE FROM `+tableName+` WHERE note_id = ?`, noteID).Error
+}
+
+// Search 搜索包含 query 中所有词的文档，按相关度排序，limit 不大于 0 时使用默认数量
+func (i *Index) Search(query string, limit int) ([]Hit, error) {
+	match := Query(query)
+	if match == "" {
+		return nil, fmt.Errorf("搜索内容不能为空")
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	var hits []Hit
+	err := i.db.Raw(`SELECT note_id, source, ref, text, rank FROM `+tableName+
+		` WHERE `+tableName+` MATCH ? ORDER BY rank LIMIT ?`, match, limit).Scan(&hits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %q: %w", query, err)
+	}
+	return hits, nil
+}
+
+// Segment 在中日韩文字之间插入空格，使 unicode61 分词器按单字建立索引
+func Segment(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+	for _, r := range text {
+		if isCJK(r) {
+			b.WriteByte(' ')
+			b.WriteRune(r)
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Query 把用户输入转换为 FTS5 查询，每个词作为一个短语，词之间为 AND
+// 词中的中文按单字切分，短语匹配保证这些字连续出现
+func Query(input string) string {
+	var phrases []string
+	for _, word := range strings.Fields(input) {
+		tokens := strings.FieldsFunc(Segment(word), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		if len(tokens) == 0 {
+			continue
+		}
+		phrases = append(phrases, `"`+strings.Join(tokens, " ")+`"`)
+	}
+	return strings.Join(phrases, " ")
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"testing"
+	"xiaohongshu/app/infra/db/dbtest"
+)
+
+func TestIndexSearch(t *testing.T) {
+	db := dbtest.Open(t)
+	index, err := NewIndex(db)
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	docs := []Document{
+		{NoteID: "n1", Source: SourceNote, Text: "秋冬穿搭分享 OOTD"},
+		{NoteID: "n2", Source: SourceImage, Ref: "a.jpg", Text: "早餐食谱：燕麦牛奶"},
+		{NoteID: "n2", Source: SourceImage, Ref: "b.jpg", Text: "今日穿搭"},
+	}
+	for _, doc := range docs {
+		if err := index.Put(doc); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	hits, err := index.Search("穿搭", 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2: %+v", len(hits), hits)
+	}
+	// 字不连续时不匹配
+	if hits, _ := index.Search("穿分", 0); len(hits) != 0 {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+	hits, err = index.Search("ootd 秋冬", 0)
+	if err != nil || len(hits) != 1 || hits[0].NoteID != "n1" || hits[0].Text != docs[0].Text {
+		t.Fatalf("unexpected hits %+v, %v", hits, err)
+	}
+
+	// 替换同一张图片的文字
+	if err := index.Put(Document{NoteID: "n2", Source: SourceImage, Ref: "b.jpg", Text: "晚餐"}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if hits, _ := index.Search("穿搭", 0); len(hits) != 1 {
+		t.Fatalf("replaced document still matches: %+v", hits)
+	}
+	if hits, _ := index.Search("燕麦", 0); len(hits) != 1 || hits[0].Ref != "a.jpg" {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+	if _, err := index.Search(" \"* ", 0); err == nil {
+		t.Fatal("expected error for empty query")
+	}
+}
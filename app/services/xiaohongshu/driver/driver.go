@@ -0,0 +1,82 @@
+// Package driver 抓取逻辑依赖的最小页面驱动接口。
+// 线上使用 Playwright 实现，测试中可以使用基于 HTML 夹具的内存实现，无需启动浏览器。
+package driver
+
+import (
+	"errors"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ErrUnsupported 驱动不支持该操作，例如内存实现无法执行页面脚本
+var ErrUnsupported = errors.New("driver: operation not supported")
+
+// Locator 元素定位器，语义与 playwright.Locator 一致
+type Locator interface {
+	// Locator 在当前元素内查找 CSS 选择器匹配的元素
+	Locator(selector string) Locator
+	// First 第一个匹配的元素
+	First() Locator
+	Count() (int, error)
+	TextContent() (string, error)
+	GetAttribute(name string) (string, error)
+	All() ([]Locator, error)
+	Click() error
+	// Evaluate 以元素为第一个参数执行页面脚本
+	Evaluate(expression string, arg interface{}) (interface{}, error)
+}
+
+// Page 页面，抓取逻辑只需要从页面创建定位器
+type Page interface {
+	Locator(selector string) Locator
+}
+
+// Playwright 返回底层的 playwright.Locator，内存实现返回 false
+// 用于鼠标轨迹、等待等只有真实浏览器才支持的操作
+func Playwright(locator Locator) (playwright.Locator, bool) {
+	l, ok := locator.(*pwLocator)
+	if !ok {
+		return nil, false
+	}
+	return l.locator, true
+}
+
+// PlaywrightPage 返回底层的 playwright.Page，内存实现返回 false
+func PlaywrightPage(page Page) (playwright.Page, bool) {
+	p, ok := page.(*pwPage)
+	if !ok {
+		return nil, false
+	}
+	return p.page, true
+}
+
+// WaitFor 等待元素出现；内存实现的文档是静态的，只检查是否存在
+func WaitFor(locator Locator, timeout time.Duration) error {
+	if l, ok := Playwright(locator); ok {
+		return l.WaitFor(playwright.LocatorWaitForOptions{
+			Timeout: playwright.Float(float64(timeout.Milliseconds())),
+		})
+	}
+	count, err := locator.Count()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("driver: element not found")
+	}
+	return nil
+}
+
+// OuterHTML 元素的 HTML，用于排查选择器失效时保存 DOM 快照
+func OuterHTML(locator Locator) (string, error) {
+	if l, ok := locator.(*memLocator); ok {
+		return l.outerHTML()
+	}
+	result, err := locator.Evaluate("el => el.outerHTML", nil)
+	if err != nil {
+		return "", err
+	}
+	content, _ := result.(string)
+	return content, nil
+}
@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Document 基于 HTML 夹具的内存页面，文档是静态的，不执行脚本
+type Document struct {
+	root *html.Node
+	// OnClick 点击元素时调用，可用于模拟页面变化；为空时点击无效果
+	OnClick func(node *html.Node) error
+	// Evaluator 执行页面脚本的替身，为空时 Evaluate 返回 ErrUnsupported
+	Evaluator func(expression string, node *html.Node, arg interface{}) (interface{}, error)
+}
+
+// ParseDocument 解析 HTML 文档
+func ParseDocument(r io.Reader) (*Document, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("driver: failed to parse document: %w", err)
+	}
+	return &Document{root: root}, nil
+}
+
+// ParseHTML 解析 HTML 字符串
+func ParseHTML(content string) (*Document, error) {
+	return ParseDocument(strings.NewReader(content))
+}
+
+func (d *Document) Locator(selector string) Locator {
+	return (&memLocator{doc: d, nodes: []*html.Node{d.root}}).Locator(selector)
+}
+
+// memLocator 内存定位器，创建时即完成匹配
+type memLocator struct {
+	doc      *Document
+	nodes    []*html.Node
+	selector string
+	err      error
+}
+
+func (l *memLocator) Locator(selector string) Locator {
+	next := &memLocator{doc: l.doc, selector: strings.TrimSpace(l.selector + " " + selector)}
+	if l.err != nil {
+		next.err = l.err
+		return next
+	}
+	group, err := parseSelector(selector)
+	if err != nil {
+		next.err = err
+		return next
+	}
+	seen := make(map[*html.Node]bool)
+	for _, scope := range l.nodes {
+		for _, n := range descendants(scope) {
+			if !seen[n] && group.match(n) {
+				seen[n] = true
+				next.nodes = append(next.nodes, n)
+			}
+		}
+	}
+	return next
+}
+
+func (l *memLocator) First() Locator {
+	next := &memLocator{doc: l.doc, selector: l.selector + " >> nth=0", err: l.err}
+	if len(l.nodes) > 0 {
+		next.nodes = l.nodes[:1]
+	}
+	return next
+}
+
+func (l *memLocator) Count() (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	return len(l.nodes), nil
+}
+
+// single 与 Playwright 的严格模式一致：单元素操作要求恰好匹配一个元素
+func (l *memLocator) single() (*html.Node, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	switch len(l.nodes) {
+	case 0:
+		return nil, fmt.Errorf("driver: no element matches %q", l.selector)
+	case 1:
+		return l.nodes[0], nil
+	default:
+		return nil, fmt.Errorf("driver: strict mode violation: %q resolved to %d elements", l.selector, len(l.nodes))
+	}
+}
+
+func (l *memLocator) TextContent() (string, error) {
+	n, err := l.single()
+	if err != nil {
+		return "", err
+	}
+	return textContent(n), nil
+}
+
+// GetAttribute 属性不存在时返回空字符串，与 Playwright 一致
+func (l *memLocator) GetAttribute(name string) (string, error) {
+	n, err := l.single()
+	if err != nil {
+		return "", err
+	}
+	return attr(n, name), nil
+}
+
+func (l *memLocator) All() ([]Locator, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	result := make([]Locator, len(l.nodes))
+	for i, n := range l.nodes {
+		result[i] = &memLocator{doc: l.doc, nodes: []*html.Node{n}, selector: fmt.Sprintf("%s >> nth=%d", l.selector, i)}
+	}
+	return result, nil
+}
+
+func (l *memLocator) Click() error {
+	n, err := l.single()
+	if err != nil {
+		return err
+	}
+	if l.doc.OnClick == nil {
+		return nil
+	}
+	return l.doc.OnClick(n)
+}
+
+func (l *memLocator) Evaluate(expression string, arg interface{}) (interface{}, error) {
+	n, err := l.single()
+	if err != nil {
+		return nil, err
+	}
+	if l.doc.Evaluator == nil {
+		return nil, ErrUnsupported
+	}
+	return l.doc.Evaluator(expression, n, arg)
+}
+
+func (l *memLocator) outerHTML() (string, error) {
+	n, err := l.single()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := html.Render(&sb, n); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// descendants 按文档顺序返回 n 的所有后代元素，不含 n 本身
+func descendants(n *html.Node) []*html.Node {
+	var result []*html.Node
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode {
+				result = append(result, child)
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	return result
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
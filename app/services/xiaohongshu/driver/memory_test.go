@@ -0,0 +1,77 @@
+package driver
+
+import "testing"
+
+const testDocument = `<html><body>
+<div id="list" class="feeds">
+  <section data-index="0" class="item active"><a class="title">first</a></section>
+  <section data-index="1" class="item"><a class="title">second</a><span class="count">2</span></section>
+  <div class="item"><a class="title">third</a></div>
+</div>
+<ul class="stats"><li><b class="count">1</b></li><li><b class="count">2</b></li></ul>
+</body></html>`
+
+func TestMemorySelectors(t *testing.T) {
+	doc, err := ParseHTML(testDocument)
+	if err != nil {
+		t.Fatalf("ParseHTML: %v", err)
+	}
+	cases := map[string]int{
+		"section":                          2,
+		"#list .title":                     3,
+		"#list > section":                  2,
+		".feeds > .title":                  0,
+		"section.item.active":              1,
+		"section:not(.active)":             1,
+		"[data-index]":                     2,
+		"section[data-index=\"1\"] .count": 1,
+		".stats > li:nth-child(2) .count":  1,
+		".stats li:last-child b":           1,
+		"div.item .title, section .title":  3,
+		"span":                             1,
+	}
+	for selector, want := range cases {
+		count, err := doc.Locator(selector).Count()
+		if err != nil {
+			t.Fatalf("%s: %v", selector, err)
+		}
+		if count != want {
+			t.Errorf("%s: count = %d, want %d", selector, count, want)
+		}
+	}
+}
+
+func TestMemoryLocator(t *testing.T) {
+	doc, err := ParseHTML(testDocument)
+	if err != nil {
+		t.Fatalf("ParseHTML: %v", err)
+	}
+	items, err := doc.Locator("#list").Locator("section").All()
+	if err != nil || len(items) != 2 {
+		t.Fatalf("All: %v (%d items)", err, len(items))
+	}
+	index, err := items[1].GetAttribute("data-index")
+	if err != nil || index != "1" {
+		t.Fatalf("GetAttribute = %q, %v", index, err)
+	}
+	text, err := items[1].Locator(".title").TextContent()
+	if err != nil || text != "second" {
+		t.Fatalf("TextContent = %q, %v", text, err)
+	}
+	// 与 Playwright 严格模式一致，匹配多个元素时单元素操作报错
+	if _, err := doc.Locator(".title").TextContent(); err == nil {
+		t.Fatalf("expected strict mode violation")
+	}
+	if text, err := doc.Locator(".title").First().TextContent(); err != nil || text != "first" {
+		t.Fatalf("First().TextContent = %q, %v", text, err)
+	}
+	if _, err := doc.Locator(".missing").GetAttribute("class"); err == nil {
+		t.Fatalf("expected error for missing element")
+	}
+	if _, err := items[0].Evaluate("(e) => e.id", nil); err != ErrUnsupported {
+		t.Fatalf("Evaluate error = %v, want ErrUnsupported", err)
+	}
+	if _, err := doc.Locator("a:hover").Count(); err == nil {
+		t.Fatalf("expected unsupported pseudo-class error")
+	}
+}
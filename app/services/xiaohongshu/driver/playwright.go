@@ -0,0 +1,65 @@
+package driver
+
+import "github.com/playwright-community/playwright-go"
+
+type pwPage struct {
+	page playwright.Page
+}
+
+// NewPage 包装 Playwright 页面
+func NewPage(page playwright.Page) Page {
+	return &pwPage{page: page}
+}
+
+func (p *pwPage) Locator(selector string) Locator {
+	return NewLocator(p.page.Locator(selector))
+}
+
+type pwLocator struct {
+	locator playwright.Locator
+}
+
+// NewLocator 包装 Playwright 定位器
+func NewLocator(locator playwright.Locator) Locator {
+	return &pwLocator{locator: locator}
+}
+
+func (l *pwLocator) Locator(selector string) Locator {
+	return NewLocator(l.locator.Locator(selector))
+}
+
+func (l *pwLocator) First() Locator {
+	return NewLocator(l.locator.First())
+}
+
+func (l *pwLocator) Count() (int, error) {
+	return l.locator.Count()
+}
+
+func (l *pwLocator) TextContent() (string, error) {
+	return l.locator.TextContent()
+}
+
+func (l *pwLocator) GetAttribute(name string) (string, error) {
+	return l.locator.GetAttribute(name)
+}
+
+func (l *pwLocator) All() ([]Locator, error) {
+	locators, err := l.locator.All()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Locator, len(locators))
+	for i, locator := range locators {
+		result[i] = NewLocator(locator)
+	}
+	return result, nil
+}
+
+func (l *pwLocator) Click() error {
+	return l.locator.Click()
+}
+
+func (l *pwLocator) Evaluate(expression string, arg interface{}) (interface{}, error) {
+	return l.locator.Evaluate(expression, arg)
+}
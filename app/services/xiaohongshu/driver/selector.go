@@ -0,0 +1,375 @@
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// 内存实现使用的 CSS 选择器子集：
+// 标签、#id、.class、[attr]、[attr=v]、[attr~=v]、[attr^=v]、[attr$=v]、[attr*=v]、
+// :not(...)、:nth-child(n)、:first-child、:last-child，后代（空格）和子元素（>）组合，以及逗号分组
+
+type attrMatcher struct {
+	name  string
+	op    string // 为空表示只要求存在
+	value string
+}
+
+type compound struct {
+	tag      string
+	id       string
+	classes  []string
+	attrs    []attrMatcher
+	not      []selector
+	nthChild int // 0 表示不限
+	last     bool
+}
+
+// part 复合选择器及其与左侧选择器的组合方式
+type part struct {
+	combinator byte // ' ' 后代，'>' 子元素，0 表示最左侧
+	compound   compound
+}
+
+type selector []part
+
+// selectorGroup 逗号分隔的选择器组
+type selectorGroup []selector
+
+func parseSelector(input string) (selectorGroup, error) {
+	var group selectorGroup
+	for _, item := range splitTopLevel(input, ',') {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("driver: empty selector in %q", input)
+		}
+		sel, err := parseComplex(item)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, sel)
+	}
+	if len(group) == 0 {
+		return nil, fmt.Errorf("driver: empty selector")
+	}
+	return group, nil
+}
+
+// splitTopLevel 按分隔符切分，忽略括号和方括号内的分隔符
+func splitTopLevel(input string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(input); i++ {
+		switch input[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, input[start:])
+}
+
+func parseComplex(input string) (selector, error) {
+	var sel selector
+	i := 0
+	for {
+		// 空白表示后代组合，> 表示子元素组合
+		combinator := byte(' ')
+		for i < len(input) && input[i] == ' ' {
+			i++
+		}
+		if i < len(input) && input[i] == '>' {
+			combinator = '>'
+			i++
+			for i < len(input) && input[i] == ' ' {
+				i++
+			}
+		}
+		if i >= len(input) {
+			if combinator == '>' {
+				return nil, fmt.Errorf("driver: dangling combinator in %q", input)
+			}
+			break
+		}
+		end := compoundEnd(input, i)
+		c, err := parseCompound(input[i:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(sel) == 0 {
+			combinator = 0
+		}
+		sel = append(sel, part{combinator: combinator, compound: c})
+		i = end
+	}
+	if len(sel) == 0 {
+		return nil, fmt.Errorf("driver: invalid selector %q", input)
+	}
+	return sel, nil
+}
+
+// compoundEnd 找到复合选择器的结束位置（空格或 > 且不在括号内）
+func compoundEnd(input string, start int) int {
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch input[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ' ', '>':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(input)
+}
+
+func parseCompound(input string) (compound, error) {
+	var c compound
+	i := 0
+	readName := func() string {
+		start := i
+		for i < len(input) && isNameChar(input[i]) {
+			i++
+		}
+		return input[start:i]
+	}
+	if i < len(input) && (isNameChar(input[i]) || input[i] == '*') {
+		if input[i] == '*' {
+			i++
+		} else {
+			c.tag = strings.ToLower(readName())
+		}
+	}
+	for i < len(input) {
+		switch input[i] {
+		case '#':
+			i++
+			c.id = readName()
+		case '.':
+			i++
+			c.classes = append(c.classes, readName())
+		case '[':
+			end := strings.IndexByte(input[i:], ']')
+			if end < 0 {
+				return c, fmt.Errorf("driver: unclosed attribute selector in %q", input)
+			}
+			c.attrs = append(c.attrs, parseAttr(input[i+1:i+end]))
+			i += end + 1
+		case ':':
+			i++
+			name := readName()
+			arg := ""
+			if i < len(input) && input[i] == '(' {
+				end := matchingParen(input, i)
+				if end < 0 {
+					return c, fmt.Errorf("driver: unclosed pseudo-class in %q", input)
+				}
+				arg = input[i+1 : end]
+				i = end + 1
+			}
+			switch name {
+			case "not":
+				group, err := parseSelector(arg)
+				if err != nil {
+					return c, err
+				}
+				c.not = append(c.not, group...)
+			case "nth-child":
+				n, err := strconv.Atoi(strings.TrimSpace(arg))
+				if err != nil {
+					return c, fmt.Errorf("driver: unsupported nth-child(%s)", arg)
+				}
+				c.nthChild = n
+			case "first-child":
+				c.nthChild = 1
+			case "last-child":
+				c.last = true
+			default:
+				return c, fmt.Errorf("driver: unsupported pseudo-class :%s", name)
+			}
+		default:
+			return c, fmt.Errorf("driver: unexpected %q in selector %q", input[i], input)
+		}
+	}
+	return c, nil
+}
+
+func parseAttr(input string) attrMatcher {
+	for _, op := range []string{"~=", "^=", "$=", "*=", "="} {
+		if idx := strings.Index(input, op); idx >= 0 {
+			value := strings.TrimSpace(input[idx+len(op):])
+			value = strings.Trim(value, `"'`)
+			return attrMatcher{name: strings.TrimSpace(input[:idx]), op: op, value: value}
+		}
+	}
+	return attrMatcher{name: strings.TrimSpace(input)}
+}
+
+func matchingParen(input string, open int) int {
+	depth := 0
+	for i := open; i < len(input); i++ {
+		switch input[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameChar(b byte) bool {
+	return b == '-' || b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= 0x80
+}
+
+func (g selectorGroup) match(n *html.Node) bool {
+	for _, sel := range g {
+		if sel.match(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// match 从右向左匹配
+func (s selector) match(n *html.Node) bool {
+	return s.matchAt(n, len(s)-1)
+}
+
+func (s selector) matchAt(n *html.Node, index int) bool {
+	if !s[index].compound.match(n) {
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+	switch s[index].combinator {
+	case '>':
+		parent := n.Parent
+		return parent != nil && parent.Type == html.ElementNode && s.matchAt(parent, index-1)
+	default:
+		for parent := n.Parent; parent != nil && parent.Type == html.ElementNode; parent = parent.Parent {
+			if s.matchAt(parent, index-1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (c compound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(attr(n, "class"))
+		for _, want := range c.classes {
+			if !containsString(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		if !a.match(n) {
+			return false
+		}
+	}
+	for _, sel := range c.not {
+		if sel.match(n) {
+			return false
+		}
+	}
+	if c.nthChild > 0 && elementIndex(n) != c.nthChild {
+		return false
+	}
+	if c.last && nextElement(n) != nil {
+		return false
+	}
+	return true
+}
+
+func (a attrMatcher) match(n *html.Node) bool {
+	value, ok := lookupAttr(n, a.name)
+	if !ok {
+		return false
+	}
+	switch a.op {
+	case "":
+		return true
+	case "=":
+		return value == a.value
+	case "~=":
+		return containsString(strings.Fields(value), a.value)
+	case "^=":
+		return strings.HasPrefix(value, a.value)
+	case "$=":
+		return strings.HasSuffix(value, a.value)
+	case "*=":
+		return strings.Contains(value, a.value)
+	}
+	return false
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+// elementIndex 元素在兄弟元素中的位置，从 1 开始
+func elementIndex(n *html.Node) int {
+	index := 1
+	for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode {
+			index++
+		}
+	}
+	return index
+}
+
+func nextElement(n *html.Node) *html.Node {
+	for sibling := n.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		}
+	}
+	return nil
+}
+
+func containsString(items []string, want string) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,22 @@
 package entity
 
-import "github.com/playwright-community/playwright-go"
+import (
+	"context"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+)
 
 // Element ElementInfo 描述DOM元素信息
 type Element struct {
-	Text     string             // 元素的文本内容
-	Selector playwright.Locator // 元素的选择器
+	Text     string         // 元素的文本内容
+	Selector driver.Locator // 元素的选择器
+	Pacer    *pacing.Pacer  // 点击使用的节奏控制器，为 nil 时直接点击
 }
 
-func (e *Element) Click() error {
-	err := e.Selector.Click()
-	return err
+// Click 经过节奏控制的点击，会随机停顿并模拟鼠标移动，ctx 取消时停止等待；非浏览器驱动直接点击
+func (e *Element) Click(ctx context.Context) error {
+	if locator, ok := driver.Playwright(e.Selector); ok && e.Pacer != nil {
+		return e.Pacer.Click(ctx, locator)
+	}
+	return e.Selector.Click()
 }
@@ -2,9 +2,10 @@ package explore
 
 import (
 	"strings"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/entity"
-
-	"github.com/playwright-community/playwright-go"
+	"xiaohongshu/app/services/xiaohongshu/site"
 )
 
 type ChannelInfo struct {
@@ -12,20 +13,24 @@ type ChannelInfo struct {
 	active bool // 元素是否具有"active"类名
 }
 type Channel struct {
-	locator playwright.Locator
+	site    *site.Site
+	locator driver.Locator
 	info    []ChannelInfo
 }
 
-func NewChannel(page playwright.Page) *Channel {
+func NewChannel(s *site.Site, page driver.Page) *Channel {
 	return &Channel{
-		locator: page.Locator("#channel-container"),
+		site:    s,
+		locator: page.Locator(s.Get("channel.container")),
 		info:    make([]ChannelInfo, 0),
 	}
 }
 func (c *Channel) Show() ([]ChannelInfo, error) {
-	locator := c.locator.Locator(".content-container .channel")
-	err := locator.WaitFor(playwright.LocatorWaitForOptions{Timeout: playwright.Float(3000)})
+	locator := c.locator.Locator(c.site.Get("channel.item"))
+	err := driver.WaitFor(locator, 3*time.Second)
+	c.site.Record("channel.item", err == nil)
 	if err != nil {
+		c.site.Observe("explore", c.locator)
 		return nil, err
 	}
 	elements, err := locator.All()
@@ -51,14 +56,26 @@ func (c *Channel) Show() ([]ChannelInfo, error) {
 
 		// 创建ChannelInfo实例并添加到结果中
 		channelInfo := ChannelInfo{
-			Element: entity.Element{
-				Text:     text,
-				Selector: element, // 直接使用当前element作为选择器
-			},
-			active: active,
+			Element: c.site.Element(text, element),
+			active:  active,
 		}
 		result = append(result, channelInfo)
 	}
 	c.info = result
-	return nil, err
+	return result, nil
+}
+
+// Active 频道是否处于选中状态
+func (c ChannelInfo) Active() bool {
+	return c.active
+}
+
+// Find 按名称查找频道，需先调用 Show
+func (c *Channel) Find(name string) (ChannelInfo, bool) {
+	for _, info := range c.info {
+		if strings.TrimSpace(info.Text) == name {
+			return info, true
+		}
+	}
+	return ChannelInfo{}, false
 }
@@ -1,15 +1,19 @@
 package explore
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/entity"
 	"xiaohongshu/app/services/xiaohongshu/scripts"
-
-	"github.com/playwright-community/playwright-go"
+	"xiaohongshu/app/services/xiaohongshu/site"
 )
 
+var logger = logging.Component("explore")
+
 type FeedsInfo struct {
 	Element entity.Element
 	Index   int
@@ -21,7 +25,8 @@ type FeedsInfo struct {
 }
 
 type Explore struct {
-	locator     playwright.Locator
+	site        *site.Site
+	locator     driver.Locator
 	allFeeds    []FeedsInfo
 	pageFeeds   []FeedsInfo
 	elementInfo scripts.ElementInfo
@@ -37,13 +42,30 @@ func (s *Explore) hasFeedIndex(index int) bool {
 	return false
 }
 
-func NewExplore(page playwright.Page) *Explore {
-	locator := page.Locator("#exploreFeeds")
+// Map 转换为 map 以便前端和任务结果使用
+func (f FeedsInfo) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"index":         f.Index,
+		"title":         f.Title.Text,
+		"coverImageUrl": f.Cover.Text,
+		"username":      f.User.Text,
+		"avatarUrl":     f.Avatar.Text,
+		"likes":         f.Likes.Text,
+	}
+}
+
+func NewExplore(s *site.Site, page driver.Page) *Explore {
+	return NewExploreWithLocator(s, page.Locator(s.Get("explore.container")))
+}
+
+// NewExploreWithLocator 基于任意瀑布流容器创建，搜索结果页、用户主页的笔记列表结构与推荐页相同
+func NewExploreWithLocator(s *site.Site, locator driver.Locator) *Explore {
 	elementInfo, err := scripts.GetElementInfo(locator)
 	if err != nil {
-		fmt.Println(fmt.Sprintf("GetElementInfo err: %v", err))
+		logger.Warn("failed to get feeds container info", "error", err)
 	}
 	return &Explore{
+		site:        s,
 		locator:     locator,
 		elementInfo: elementInfo,
 		allFeeds:    make([]FeedsInfo, 0),
@@ -54,25 +76,29 @@ func (s *Explore) Show() ([]FeedsInfo, error) {
 	var err error
 	s.pageFeeds, err = s.getExploreFeeds()
 	s.allFeeds = append(s.allFeeds, s.pageFeeds...)
+	s.site.Observe("explore", s.locator)
 	return s.pageFeeds, err
 }
 
-// NextPage 下一页
-func (s *Explore) NextPage() {
-	feedLen := len(s.allFeeds)
-	if feedLen != 0 {
-		_ = scripts.SmoothScrollTo(s.locator, s.elementInfo.Element.VisibleHeight)
+// NextPage 下一页，ctx 取消时停止等待节奏控制
+func (s *Explore) NextPage(ctx context.Context) error {
+	if len(s.allFeeds) == 0 {
+		return nil
 	}
+	return s.site.Pacer.Scroll(ctx, s.elementInfo.Element.VisibleHeight, func(distance float64) error {
+		return scripts.SmoothScrollTo(s.locator, distance)
+	})
 }
 
 func (s *Explore) getExploreFeeds() ([]FeedsInfo, error) {
 	var elements []FeedsInfo
-	locator := s.locator.Locator("section")
+	locator := s.locator.Locator(s.site.Get("explore.feed"))
 
 	sectionElements, err := locator.All()
 	if err != nil {
 		return nil, fmt.Errorf("failed to find section elements: %v", err)
 	}
+	s.site.Record("explore.feed", len(sectionElements) > 0)
 	if len(sectionElements) == 0 {
 		return elements, nil // 返回空数组而不是错误
 	}
@@ -100,66 +126,53 @@ func (s *Explore) getExploreFeeds() ([]FeedsInfo, error) {
 		}
 
 		var e FeedsInfo
-		e.Element = entity.Element{
-			Text:     "",
-			Selector: element,
-		}
+		e.Element = s.site.Element("", element)
 		e.Index = int(dataIndexInt)
 		// 获取封面图片链接
-		coverImgElement := element.Locator("a.cover img")
+		coverImgElement := element.Locator(s.site.Get("explore.cover"))
 
 		coverImg, err := coverImgElement.GetAttribute("src")
+		s.site.Record("explore.cover", err == nil && coverImg != "")
 		if err != nil || coverImg == "" {
 			continue
 		}
-		e.Cover = entity.Element{
-			Text:     coverImg,
-			Selector: coverImgElement,
-		}
+		e.Cover = s.site.Element(coverImg, coverImgElement)
 
 		// 获取标题
-		titleElement := element.Locator(".footer .title")
+		titleElement := element.Locator(s.site.Get("explore.title"))
 		titleText, err := titleElement.TextContent()
+		s.site.Record("explore.title", err == nil && titleText != "")
 		if err != nil || titleText == "" {
 			continue
 		}
-		e.Title = entity.Element{
-			Text:     titleText,
-			Selector: titleElement,
-		}
+		e.Title = s.site.Element(titleText, titleElement)
 
 		// 获取用户名称和头像
-		authorElement := element.Locator(".author-wrapper .author")
+		authorElement := element.Locator(s.site.Get("explore.author"))
 
 		authorName, err := authorElement.TextContent()
+		s.site.Record("explore.author", err == nil && authorName != "")
 		if err != nil || authorName == "" {
 			continue
 		}
-		e.User = entity.Element{
-			Text:     authorName,
-			Selector: authorElement,
-		}
+		e.User = s.site.Element(authorName, authorElement)
 
-		authorAvatar := authorElement.Locator("img")
+		authorAvatar := authorElement.Locator(s.site.Get("explore.avatar"))
 		avatarSrc, err := authorAvatar.GetAttribute("src")
+		s.site.Record("explore.avatar", err == nil && avatarSrc != "")
 		if err != nil || avatarSrc == "" {
 			continue
 		}
-		e.Avatar = entity.Element{
-			Text:     avatarSrc,
-			Selector: authorAvatar,
-		}
+		e.Avatar = s.site.Element(avatarSrc, authorAvatar)
 		// 获取点赞数
-		likeElement := element.Locator(".like-wrapper .count")
+		likeElement := element.Locator(s.site.Get("explore.likes"))
 
 		likeCount, err := likeElement.TextContent()
+		s.site.Record("explore.likes", err == nil)
 		if err != nil {
 			continue
 		}
-		e.Likes = entity.Element{
-			Text:     likeCount,
-			Selector: likeElement,
-		}
+		e.Likes = s.site.Element(likeCount, likeElement)
 		elements = append(elements, e)
 	}
 
@@ -175,11 +188,10 @@ func (s *Explore) GetFeed(index int) (FeedsInfo, error) {
 	return FeedsInfo{}, errors.New("not found")
 }
 
-// 刷新页面
-func (s *Explore) RefreshPage() error {
-	selector := s.locator.Locator(".floating-btn-sets .reload")
+// RefreshPage 点击刷新按钮重新加载推荐
+func (s *Explore) RefreshPage(ctx context.Context) error {
+	reload := s.site.Element("reload", s.locator.Locator(s.site.Get("explore.reload")))
 	s.pageFeeds = make([]FeedsInfo, 0)
 	s.allFeeds = make([]FeedsInfo, 0)
-	return selector.Click()
-
+	return reload.Click(ctx)
 }
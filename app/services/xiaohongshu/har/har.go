@@ -0,0 +1,84 @@
+// Package har 会话的 HAR 录制与回放配置。
+// 录制使用 Playwright 的 RecordHarPath，HAR 文件在浏览器上下文关闭时写入；
+// 回放通过 RouteFromHAR 完全由 HAR 提供页面，未录制的请求直接中止。
+package har
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 会话模式
+const (
+	ModeOff    = "off"
+	ModeRecord = "record" // 录制每个会话的 HAR
+	ModeReplay = "replay" // 从 HAR 回放，不访问线上站点
+)
+
+// Options HAR 配置
+type Options struct {
+	Mode string `json:"mode"`
+	// File 回放时读取的 HAR 文件；录制时为空则写入缓存目录下的 har 目录
+	File string `json:"file"`
+}
+
+// Validate 检查配置，回放模式必须指定存在的 HAR 文件
+func (o Options) Validate() error {
+	switch o.Mode {
+	case "", ModeOff, ModeRecord:
+		return nil
+	case ModeReplay:
+		if o.File == "" {
+			return fmt.Errorf("har replay requires a HAR file")
+		}
+		if _, err := os.Stat(o.File); err != nil {
+			return fmt.Errorf("har file %s: %w", o.File, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown har mode %q, expected off, record or replay", o.Mode)
+	}
+}
+
+// Recording 是否为录制模式
+func (o Options) Recording() bool {
+	return o.Mode == ModeRecord
+}
+
+// Replaying 是否为回放模式
+func (o Options) Replaying() bool {
+	return o.Mode == ModeReplay
+}
+
+// SessionPath 返回新会话的录制路径，指定了 File 时使用 File
+func (o Options) SessionPath(cacheDir string, now time.Time) (string, error) {
+	if o.File != "" {
+		return o.File, nil
+	}
+	dir := filepath.Join(cacheDir, "har")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create har directory: %w", err)
+	}
+	return filepath.Join(dir, "session-"+now.Format("20060102-150405")+".har"), nil
+}
+
+// Copy 复制 HAR 文件
+func Copy(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
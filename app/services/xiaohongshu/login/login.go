@@ -0,0 +1,248 @@
+package login
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/site"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// 登录状态
+const (
+	StateWaiting   = "waiting"   // 等待扫码
+	StateScanned   = "scanned"   // 已扫码，等待手机确认
+	StateConfirmed = "confirmed" // 已确认，登录成功
+	StateExpired   = "expired"   // 二维码已过期
+	StateRefreshed = "refreshed" // 二维码已刷新
+	StateFailed    = "failed"    // 登录失败或超时
+)
+
+const statusAPI = "/api/sns/web/v1/login/qrcode/status"
+
+// sessionCookie 登录成功后站点下发新的会话 cookie
+const sessionCookie = "web_session"
+
+// Status 推送给前端的登录状态
+type Status struct {
+	State   string `json:"state"`
+	QRCode  string `json:"qrCode,omitempty"` // 二维码图片，data URL 格式
+	Message string `json:"message,omitempty"`
+}
+
+// codeStatus 二维码状态接口返回的 code_status
+var codeStatus = map[int]string{
+	0: StateWaiting,
+	1: StateScanned,
+	2: StateConfirmed,
+	3: StateExpired,
+}
+
+// Login 扫码登录弹窗
+type Login struct {
+	site       *site.Site
+	page       playwright.Page
+	Interval   time.Duration // 登录状态轮询间隔
+	Timeout    time.Duration // 整个登录流程的超时时间
+	MaxRefresh int           // 二维码过期后最多自动刷新次数
+	CloseGrace time.Duration // 弹窗消失后等待会话 cookie 更新的时间，超过后视为用户关闭了弹窗
+}
+
+func NewLogin(s *site.Site, page playwright.Page) *Login {
+	return &Login{
+		site:       s,
+		page:       page,
+		Interval:   time.Second,
+		Timeout:    5 * time.Minute,
+		MaxRefresh: 3,
+		CloseGrace: 3 * time.Second,
+	}
+}
+
+// Open 打开登录弹窗，未登录时页面通常会自动弹出
+func (l *Login) Open(ctx context.Context) error {
+	modal := l.page.Locator(l.site.Get("login.modal"))
+	if visible, _ := modal.IsVisible(); !visible {
+		button := l.page.Locator(l.site.Get("login.button")).First()
+		if err := l.site.Pacer.Click(ctx, button); err != nil {
+			return fmt.Errorf("failed to open login modal: %w", err)
+		}
+	}
+	return l.page.Locator(l.site.Get("login.qrcode")).WaitFor(playwright.LocatorWaitForOptions{
+		State: playwright.WaitForSelectorStateVisible,
+	})
+}
+
+// QRCode 获取二维码图片，优先使用 img 的 data URL，否则截图
+func (l *Login) QRCode() (string, error) {
+	img := l.page.Locator(l.site.Get("login.qrcode")).First()
+	src, err := img.GetAttribute("src")
+	if err == nil && strings.HasPrefix(src, "data:image") {
+		return src, nil
+	}
+	data, err := img.Screenshot()
+	if err != nil {
+		return "", fmt.Errorf("failed to capture qrcode: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Run 打开弹窗并轮询登录状态，直到登录成功、失败或 ctx 被取消
+// 每次状态变化都会调用 notify
+func (l *Login) Run(ctx context.Context, notify func(Status)) error {
+	ctx, cancel := context.WithTimeout(ctx, l.Timeout)
+	defer cancel()
+
+	if err := l.Open(ctx); err != nil {
+		return err
+	}
+	qrcode, err := l.QRCode()
+	if err != nil {
+		return err
+	}
+	notify(Status{State: StateWaiting, QRCode: qrcode})
+	// 未登录时也可能有游客会话，登录成功以会话变化为准
+	session := l.session()
+
+	// 页面自身会轮询二维码状态接口，监听其响应
+	states := make(chan string, 8)
+	onResponse := func(response playwright.Response) {
+		if !strings.Contains(response.URL(), statusAPI) {
+			return
+		}
+		go func() {
+			if state, ok := parseStatus(response); ok {
+				select {
+				case states <- state:
+				default:
+				}
+			}
+		}()
+	}
+	l.page.On("response", onResponse)
+	defer l.page.RemoveListener("response", onResponse)
+
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+	current := StateWaiting
+	refreshed := 0
+	var closedAt time.Time
+	for {
+		var state string
+		select {
+		case <-ctx.Done():
+			message := "login canceled"
+			if ctx.Err() == context.DeadlineExceeded {
+				message = "login timeout"
+			}
+			notify(Status{State: StateFailed, Message: message})
+			return ctx.Err()
+		case state = <-states:
+		case <-ticker.C:
+			state = current
+			if visible, _ := l.page.Locator(l.site.Get("login.modal")).IsVisible(); visible {
+				closedAt = time.Time{}
+				break
+			}
+			// 弹窗消失后会话已更新，说明登录成功但接口响应被错过；否则是弹窗被关闭
+			if value := l.session(); value != "" && value != session {
+				state = StateConfirmed
+				break
+			}
+			if closedAt.IsZero() {
+				closedAt = time.Now()
+			} else if time.Since(closedAt) >= l.CloseGrace {
+				notify(Status{State: StateFailed, Message: "login modal closed"})
+				return fmt.Errorf("login modal closed before confirmation")
+			}
+		}
+		if state == current {
+			continue
+		}
+		current = state
+		switch state {
+		case StateConfirmed:
+			notify(Status{State: StateConfirmed})
+			return nil
+		case StateExpired:
+			notify(Status{State: StateExpired})
+			if refreshed >= l.MaxRefresh {
+				notify(Status{State: StateFailed, Message: "qrcode expired"})
+				return fmt.Errorf("qrcode expired")
+			}
+			refreshed++
+			qrcode, err := l.refresh(ctx)
+			if err != nil {
+				notify(Status{State: StateFailed, Message: err.Error()})
+				return err
+			}
+			current = StateWaiting
+			notify(Status{State: StateRefreshed, QRCode: qrcode})
+		default:
+			notify(Status{State: state})
+		}
+	}
+}
+
+// refresh 点击刷新二维码并返回新的二维码
+func (l *Login) refresh(ctx context.Context) (string, error) {
+	old, _ := l.page.Locator(l.site.Get("login.qrcode")).First().GetAttribute("src")
+	if err := l.site.Pacer.Click(ctx, l.page.Locator(l.site.Get("login.refresh")).First()); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		// 没有刷新按钮时重新打开弹窗
+		if _, err := l.page.Reload(); err != nil {
+			return "", fmt.Errorf("failed to refresh qrcode: %w", err)
+		}
+		if err := l.Open(ctx); err != nil {
+			return "", err
+		}
+	}
+	// 等待二维码图片更新
+	for i := 0; i < 10; i++ {
+		src, _ := l.page.Locator(l.site.Get("login.qrcode")).First().GetAttribute("src")
+		if src != "" && src != old {
+			break
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+	return l.QRCode()
+}
+
+// session 当前页面的会话 cookie，没有时返回空字符串
+func (l *Login) session() string {
+	cookies, err := l.page.Context().Cookies(l.page.URL())
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == sessionCookie {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// parseStatus 解析二维码状态接口的响应
+func parseStatus(response playwright.Response) (string, bool) {
+	body, err := response.Body()
+	if err != nil {
+		return "", false
+	}
+	var result struct {
+		Success bool `json:"success"`
+		Data    struct {
+			CodeStatus int `json:"code_status"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &result) != nil || !result.Success {
+		return "", false
+	}
+	state, ok := codeStatus[result.Data.CodeStatus]
+	return state, ok
+}
@@ -1,10 +1,10 @@
 package note
 
 import (
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/entity"
-	"xiaohongshu/app/services/xiaohongshu/scripts"
-
-	"github.com/playwright-community/playwright-go"
+	"xiaohongshu/app/services/xiaohongshu/site"
 )
 
 type CommentInfo struct {
@@ -18,51 +18,111 @@ type CommentInfo struct {
 	showMore    entity.Element
 }
 
+// Map 转换为 map 以便前端和任务结果使用，子评论递归转换
+func (c CommentInfo) Map() map[string]interface{} {
+	imgs := make([]string, 0, len(c.imgs))
+	for _, img := range c.imgs {
+		imgs = append(imgs, img.Text)
+	}
+	subComments := make([]map[string]interface{}, 0, len(c.subComment))
+	for _, sub := range c.subComment {
+		subComments = append(subComments, sub.Map())
+	}
+	return map[string]interface{}{
+		"author":      c.author.Text,
+		"content":     c.content.Text,
+		"imgs":        imgs,
+		"dateAddress": c.dateAddress.Text,
+		"like":        c.like.Text,
+		"reply":       c.reply.Text,
+		"subComments": subComments,
+	}
+}
+
+// CommentDetail 评论的提取结果，子评论保存在 Replies 中
+type CommentDetail struct {
+	Author      string          `json:"author"`
+	Content     string          `json:"content"`
+	Images      []string        `json:"images"`
+	DateAddress string          `json:"dateAddress"`
+	Likes       string          `json:"likes"`
+	ReplyCount  string          `json:"replyCount"`
+	Replies     []CommentDetail `json:"replies"`
+}
+
+// Detail 转换为可以发布和序列化的评论，子评论递归转换
+func (c CommentInfo) Detail() CommentDetail {
+	images := make([]string, 0, len(c.imgs))
+	for _, img := range c.imgs {
+		images = append(images, img.Text)
+	}
+	replies := make([]CommentDetail, 0, len(c.subComment))
+	for _, sub := range c.subComment {
+		replies = append(replies, sub.Detail())
+	}
+	return CommentDetail{
+		Author:      c.author.Text,
+		Content:     c.content.Text,
+		Images:      images,
+		DateAddress: c.dateAddress.Text,
+		Likes:       c.like.Text,
+		ReplyCount:  c.reply.Text,
+		Replies:     replies,
+	}
+}
+
+// CommentsCrawled 一次评论抓取的结果，随 note:comments-crawled 发布
+type CommentsCrawled struct {
+	Note      NoteDetail      `json:"note"`
+	Comments  []CommentDetail `json:"comments"`
+	CrawledAt time.Time       `json:"crawledAt"`
+}
+
 type Comment struct {
-	locator playwright.Locator // 元素的选择器
+	site    *site.Site
+	locator driver.Locator // 元素的选择器
 }
 
-func NewComment(locator playwright.Locator) *Comment {
+func NewComment(s *site.Site, locator driver.Locator) *Comment {
 	return &Comment{
+		site:    s,
 		locator: locator,
 	}
 }
 func (c Comment) Show() ([]CommentInfo, error) {
 	// 获取所有父评论
-	parentCommentsLocator := c.locator.Locator(".comments-el .comments-container .list-container .parent-comment")
+	parentCommentsLocator := c.locator.Locator(c.site.Get("comment.parent"))
 	parentComments, err := parentCommentsLocator.All()
 	if err != nil {
 		return nil, err
 	}
+	c.site.Record("comment.parent", len(parentComments) > 0)
 
 	comments := make([]CommentInfo, len(parentComments))
 	for i, commentLocator := range parentComments {
 		comment := CommentInfo{}
 		// 用户信息名称
-		comment.author = scripts.BuildElement(commentLocator, ".right .author-wrapper .author")
+		comment.author = c.site.BuildElement(commentLocator, "comment.author")
 		// 内容
-		comment.content = scripts.BuildElement(commentLocator, ".right .content")
+		comment.content = c.site.BuildElement(commentLocator, "comment.content")
 		// 评论图片
-		imgLocators, err := commentLocator.Locator(".right .comment-picture img").All()
+		imgLocators, err := commentLocator.Locator(c.site.Get("comment.picture")).All()
 		if err == nil {
 			imgElements := make([]entity.Element, len(imgLocators))
 			for j, imgLocator := range imgLocators {
 				text, _ := imgLocator.GetAttribute("src")
-				imgElements[j] = entity.Element{
-					Text:     text,
-					Selector: imgLocator,
-				}
+				imgElements[j] = c.site.Element(text, imgLocator)
 			}
 			comment.imgs = imgElements
 		}
 		// 评论日期和地址
-		comment.dateAddress = scripts.BuildElement(commentLocator, ".right .info .date")
+		comment.dateAddress = c.site.BuildElement(commentLocator, "comment.date")
 		// 点赞(数量)
-		comment.like = scripts.BuildElement(commentLocator, ".right .info .interactions .like-wrapper")
+		comment.like = c.site.BuildElement(commentLocator, "comment.like")
 		// 回复(数量)
-		comment.reply = scripts.BuildElement(commentLocator, ".right .info .interactions .reply")
+		comment.reply = c.site.BuildElement(commentLocator, "comment.reply")
 		// 子评论处理
-		subCommentsLocator := commentLocator.Locator(".reply-container .list-container .comment-item-sub")
+		subCommentsLocator := commentLocator.Locator(c.site.Get("comment.sub"))
 		subComments, err := subCommentsLocator.All()
 		if err == nil {
 			subCommentElements := make([]CommentInfo, len(subComments))
@@ -70,79 +130,37 @@ func (c Comment) Show() ([]CommentInfo, error) {
 				subComment := CommentInfo{}
 
 				// 用户信息名称
-				subComment.author = scripts.BuildElement(subCommentLocator, ".right .author-wrapper .author")
+				subComment.author = c.site.BuildElement(subCommentLocator, "comment.author")
 
 				// 内容
-				subComment.content = scripts.BuildElement(subCommentLocator, ".right .content")
+				subComment.content = c.site.BuildElement(subCommentLocator, "comment.content")
 				// 评论图片
-				subImgLocators, err := subCommentLocator.Locator(".right .comment-picture img").All()
+				subImgLocators, err := subCommentLocator.Locator(c.site.Get("comment.picture")).All()
 				if err == nil {
 					subImgElements := make([]entity.Element, len(subImgLocators))
 					for k, subImgLocator := range subImgLocators {
 						text, _ := subImgLocator.GetAttribute("src")
-						subImgElements[k] = entity.Element{
-							Text:     text,
-							Selector: subImgLocator,
-						}
+						subImgElements[k] = c.site.Element(text, subImgLocator)
 					}
 					subComment.imgs = subImgElements
 				}
 				// 评论日期和地址
-				subComment.dateAddress = scripts.BuildElement(subCommentLocator, ".right .info .date")
+				subComment.dateAddress = c.site.BuildElement(subCommentLocator, "comment.date")
 
 				// 点赞(数量)
-				subComment.like = scripts.BuildElement(subCommentLocator, ".right .info .interactions .like-wrapper")
+				subComment.like = c.site.BuildElement(subCommentLocator, "comment.like")
 
 				// 回复(数量)
-				subComment.reply = scripts.BuildElement(subCommentLocator, ".right .info .interactions .reply")
+				subComment.reply = c.site.BuildElement(subCommentLocator, "comment.reply")
 
 				subCommentElements[j] = subComment
 			}
 			comment.subComment = subCommentElements
 		}
 		// 显示更多 展开
-		comment.showMore = scripts.BuildElement(commentLocator, ".reply-container .show-more")
+		comment.showMore = c.site.BuildElement(commentLocator, "comment.showMore")
 		comments[i] = comment
 	}
-
+	c.site.Observe("note", c.locator)
 	return comments, nil
 }
-
-//#noteContainer
-// attr data-type : default  video
-
-// 左侧banner .media-container
-// 获取banner  document.querySelectorAll(".swiper-slide:not(.swiper-slide-duplicate)")
-// 获取bannerImage  .swiper-slide:not(.swiper-slide-duplicate) .note-slider-img  img
-
-//视频类型 .video-player-media
-
-// 右侧内容区域 .interaction-container
-// 关注：.interaction-container .author-container .author-wrapper  .note-detail-follow-btn
-// 发布用户信息：.interaction-container .author-container .author-wrapper  .author-container
-// 标题：.interaction-container .note-scroller .note-content .title
-// 介绍：.interaction-container .note-scroller .note-content .desc
-// 日期和地址：.interaction-container .note-scroller .note-content .bottom-container .date
-// 举报按钮：.interaction-container .note-scroller .note-content .bottom-container .notedetail-menu
-
-//评论区域 .interaction-container .note-scroller .comments-el .comments-container
-//评论数量 .interaction-container .note-scroller .comments-el .comments-container .total
-
-//评论内容 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment
-//用户信息名称 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right .author-wrapper .author
-//内容 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .content
-//评论图片 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .comment-picture img
-//评论图片 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .comment-picture img
-//评论日期和地址 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .info .date
-//点赞(数量) .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .info .interactions .like-wrapper
-//回复(数量) .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .right  .info .interactions .reply
-
-//子评论列表 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container  .list-container .comment-item-sub
-//显示更多 展开 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container  .show-more
-
-//用户信息名称 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment   .reply-container .list-container .comment-item-sub .right .author-wrapper .author
-//内容 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container .list-container .comment-item-sub  .right  .content
-//评论图片 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container .list-container .comment-item-sub  .right  .comment-picture img
-//评论日期和地址 .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container .list-container .comment-item-sub  .right  .info .date
-//点赞(数量) .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment   .reply-container .list-container .comment-item-sub  .right  .info .interactions .like-wrapper
-//回复(数量) .interaction-container .note-scroller .comments-el .comments-container .list-container .parent-comment  .reply-container .list-container .comment-item-sub   .right  .info .interactions .reply
@@ -0,0 +1,253 @@
+package note
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
+)
+
+// KeyframeMode 关键帧的选取方式
+type KeyframeMode string
+
+const (
+	KeyframeInterval KeyframeMode = "interval" // 每隔固定时间取一帧
+	KeyframeScene    KeyframeMode = "scene"    // 与上一张关键帧的颜色直方图差异超过阈值时取一帧
+)
+
+// 关键帧提取的默认参数
+const (
+	DefaultKeyframeInterval = 5 * time.Second
+	DefaultSceneSample      = time.Second // 场景模式的采样间隔
+	DefaultSceneThreshold   = 0.3
+	DefaultMaxKeyframes     = 60
+	DefaultKeyframeWidth    = 320
+)
+
+// histogramBins 每个颜色通道的直方图分箱数
+const histogramBins = 16
+
+// KeyframeOptions 关键帧提取参数，零值使用默认值
+type KeyframeOptions struct {
+	Mode      KeyframeMode  `json:"mode"`
+	Interval  time.Duration `json:"interval"`  // 固定间隔模式的间隔，场景模式的采样间隔
+	Threshold float64       `json:"threshold"` // 场景模式的直方图差异阈值，范围 0-1
+	MaxFrames int           `json:"maxFrames"`
+	Width     int           `json:"width"` // 缩略图的最大宽度
+}
+
+func (o KeyframeOptions) withDefaults() KeyframeOptions {
+	if o.Mode == "" {
+		o.Mode = KeyframeInterval
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultKeyframeInterval
+		if o.Mode == KeyframeScene {
+			o.Interval = DefaultSceneSample
+		}
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultSceneThreshold
+	}
+	if o.MaxFrames <= 0 {
+		o.MaxFrames = DefaultMaxKeyframes
+	}
+	if o.Width <= 0 {
+		o.Width = DefaultKeyframeWidth
+	}
+	return o
+}
+
+// Keyframe 提取到的一张关键帧
+type Keyframe struct {
+	Index int           `json:"index"`
+	Time  time.Duration `json:"time"`
+	Score float64       `json:"score"` // 与上一张关键帧的直方图差异，第一张为 1
+	Image image.Image   `json:"-"`
+}
+
+// Histogram 归一化的 RGB 颜色直方图
+type Histogram [3 * histogramBins]float64
+
+// NewHistogram 统计图片的颜色直方图，每个通道分别归一化
+func NewHistogram(img image.Image) Histogram {
+	var h Histogram
+	bounds := img.Bounds()
+	total := float64(bounds.Dx() * bounds.Dy())
+	if total == 0 {
+		return h
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			h[r>>12]++
+			h[histogramBins+int(g>>12)]++
+			h[2*histogramBins+int(b>>12)]++
+		}
+	}
+	for i := range h {
+		h[i] /= total
+	}
+	return h
+}
+
+// Diff 两个直方图的差异，0 为完全相同，1 为没有任何重叠
+func (h Histogram) Diff(other Histogram) float64 {
+	var sum float64
+	for i := range h {
+		sum += math.Abs(h[i] - other[i])
+	}
+	// 每个通道的 L1 距离最大为 2
+	return sum / 6
+}
+
+// ExtractKeyframes 暂停视频并逐个跳转到采样位置截取画面，按 opts.Mode 选取关键帧
+// 画面来自页面中的视频元素，不下载视频文件；结束后恢复原来的播放位置和播放状态
+func (v *Video) ExtractKeyframes(opts KeyframeOptions) ([]Keyframe, error) {
+	if v.mediaCapture == nil {
+		return nil, driver.ErrUnsupported
+	}
+	element, ok := driver.Playwright(v.videoElement)
+	if !ok {
+		return nil, driver.ErrUnsupported
+	}
+	opts = opts.withDefaults()
+	if opts.Mode != KeyframeInterval && opts.Mode != KeyframeScene {
+		return nil, fmt.Errorf("未知的关键帧模式: %s", opts.Mode)
+	}
+	if err := v.loadMetadata(); err != nil {
+		return nil, err
+	}
+	total, err := v.Duration()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := v.State()
+	if err != nil {
+		return nil, err
+	}
+	position, err := v.CurrentTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Pause(); err != nil {
+		return nil, err
+	}
+	defer v.restore(position, state == StatePlaying || state == StateWaiting)
+
+	var (
+		frames []Keyframe
+		last   Histogram
+	)
+	for at := time.Duration(0); at < total && len(frames) < opts.MaxFrames; at += opts.Interval {
+		if err := v.Seek(at); err != nil {
+			return frames, err
+		}
+		frame, err := v.mediaCapture.CaptureFrame(element, opts.Width)
+		if err != nil {
+			return frames, err
+		}
+		img, err := scripts.DecodeFrame(frame)
+		if err != nil {
+			return frames, err
+		}
+		histogram := NewHistogram(img)
+		score := 1.0
+		if len(frames) > 0 {
+			score = histogram.Diff(last)
+		}
+		if opts.Mode == KeyframeScene && len(frames) > 0 && score < opts.Threshold {
+			continue
+		}
+		frames = append(frames, Keyframe{Index: len(frames), Time: duration(frame.Ts), Score: score, Image: img})
+		last = histogram
+	}
+	return frames, nil
+}
+
+// loadMetadata 视频还没有开始加载时（preload="none"）主动加载元数据，否则无法跳转和读取时长
+func (v *Video) loadMetadata() error {
+	_, err := v.videoElement.Evaluate(`(video, timeout) => new Promise((resolve, reject) => {
+		if (video.readyState >= 1) {
+			resolve();
+			return;
+		}
+		const done = () => { cleanup(); resolve(); };
+		const fail = () => { cleanup(); reject(new Error(video.error?.message || 'failed to load metadata')); };
+		const timer = setTimeout(() => { cleanup(); reject(new Error('metadata timeout')); }, timeout);
+		const cleanup = () => {
+			clearTimeout(timer);
+			video.removeEventListener('loadedmetadata', done);
+			video.removeEventListener('error', fail);
+		};
+		video.addEventListener('loadedmetadata', done);
+		video.addEventListener('error', fail);
+		video.preload = 'auto';
+		video.load();
+	})`, seekTimeout.Milliseconds())
+	return err
+}
+
+// restore 回到提取前的播放位置，提取前在播放时继续播放
+func (v *Video) restore(position time.Duration, playing bool) {
+	if err := v.Seek(position); err != nil {
+		logger.Warn("failed to restore video position", "error", err)
+	}
+	if playing {
+		if err := v.Play(); err != nil {
+			logger.Warn("failed to resume video", "error", err)
+		}
+	}
+}
+
+// contactSheetGap 联系表中缩略图之间的间距
+const contactSheetGap = 4
+
+// ContactSheet 把关键帧按 columns 列排成一张联系表，每张缩放到 cellWidth 宽、保持比例
+func ContactSheet(frames []Keyframe, columns int, cellWidth int) (image.Image, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("没有关键帧")
+	}
+	if columns <= 0 || cellWidth <= 0 {
+		return nil, fmt.Errorf("列数和宽度必须大于 0")
+	}
+	if columns > len(frames) {
+		columns = len(frames)
+	}
+	first := frames[0].Image.Bounds()
+	cellHeight := int(math.Round(float64(cellWidth) * float64(first.Dy()) / float64(first.Dx())))
+	rows := (len(frames) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		columns*cellWidth+(columns+1)*contactSheetGap,
+		rows*cellHeight+(rows+1)*contactSheetGap))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	for i, frame := range frames {
+		x := contactSheetGap + (i%columns)*(cellWidth+contactSheetGap)
+		y := contactSheetGap + (i/columns)*(cellHeight+contactSheetGap)
+		scaleInto(sheet, image.Rect(x, y, x+cellWidth, y+cellHeight), frame.Image)
+	}
+	return sheet, nil
+}
+
+// scaleInto 最近邻缩放 src 并居中绘制到 dst 的 rect 区域，保持比例
+func scaleInto(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return
+	}
+	scale := math.Min(float64(rect.Dx())/float64(bounds.Dx()), float64(rect.Dy())/float64(bounds.Dy()))
+	w, h := int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale)
+	offset := rect.Min.Add(image.Pt((rect.Dx()-w)/2, (rect.Dy()-h)/2))
+	for y := 0; y < h; y++ {
+		sy := bounds.Min.Y + int(float64(y)/scale)
+		for x := 0; x < w; x++ {
+			sx := bounds.Min.X + int(float64(x)/scale)
+			dst.Set(offset.X+x, offset.Y+y, src.At(sx, sy))
+		}
+	}
+}
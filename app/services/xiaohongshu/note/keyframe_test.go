@@ -0,0 +1,52 @@
+package note
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func solid(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestHistogramDiff(t *testing.T) {
+	red := NewHistogram(solid(8, 8, color.RGBA{R: 255, A: 255}))
+	if d := red.Diff(NewHistogram(solid(4, 4, color.RGBA{R: 255, A: 255}))); d != 0 {
+		t.Fatalf("same color at different sizes should not differ, got %v", d)
+	}
+	if d := red.Diff(NewHistogram(solid(8, 8, color.RGBA{G: 255, A: 255}))); d < DefaultSceneThreshold {
+		t.Fatalf("red and green should be a scene change, got %v", d)
+	}
+	if d := NewHistogram(solid(8, 8, color.Black)).Diff(NewHistogram(solid(8, 8, color.White))); d != 1 {
+		t.Fatalf("black and white should not overlap, got %v", d)
+	}
+}
+
+func TestContactSheet(t *testing.T) {
+	frames := []Keyframe{
+		{Index: 0, Image: solid(40, 20, color.White)},
+		{Index: 1, Image: solid(40, 20, color.White)},
+		{Index: 2, Image: solid(40, 20, color.White)},
+	}
+	sheet, err := ContactSheet(frames, 2, 20)
+	if err != nil {
+		t.Fatalf("ContactSheet: %v", err)
+	}
+	// 2 列 2 行，每格 20x10，四周和中间留 4 像素
+	if got := sheet.Bounds().Size(); got != image.Pt(2*20+3*contactSheetGap, 2*10+3*contactSheetGap) {
+		t.Fatalf("unexpected sheet size %v", got)
+	}
+	if r, _, _, _ := sheet.At(contactSheetGap, contactSheetGap).RGBA(); r != 0xffff {
+		t.Fatal("expected the first frame in the top-left cell")
+	}
+	if r, _, _, _ := sheet.At(contactSheetGap+20+contactSheetGap, contactSheetGap+10+contactSheetGap).RGBA(); r != 0 {
+		t.Fatal("expected the last cell to stay empty")
+	}
+	if _, err := ContactSheet(nil, 2, 20); err == nil {
+		t.Fatal("expected error without frames")
+	}
+}
@@ -0,0 +1,395 @@
+package note
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
+	"xiaohongshu/app/services/xiaohongshu/site"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/playwright-community/playwright-go"
+)
+
+var logger = logging.Component("note")
+
+// noteURL 笔记详情的地址，第一个分组为笔记 id
+var noteURL = regexp.MustCompile(`/explore/([0-9A-Za-z_-]+)`)
+
+// DefaultReadyTimeout 等待笔记详情加载完成的时间
+const DefaultReadyTimeout = 15 * time.Second
+
+// NoteDetail 笔记详情的提取结果，随 note:opened 和 note:closed 发布
+type NoteDetail struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	Type         string    `json:"type"`
+	Author       string    `json:"author"`
+	Title        string    `json:"title"`
+	Desc         string    `json:"desc"`
+	DateAddress  string    `json:"dateAddress"`
+	CommentCount string    `json:"commentCount"`
+	Images       []string  `json:"images"`
+	OpenedAt     time.Time `json:"openedAt"`
+}
+
+// Detail 转换为可以发布和序列化的笔记详情
+func (n NoteInfo) Detail(id string, url string) NoteDetail {
+	return NoteDetail{
+		ID:           id,
+		URL:          url,
+		Type:         n.noteType,
+		Author:       n.authorElement.Text,
+		Title:        n.title.Text,
+		Desc:         n.desc.Text,
+		DateAddress:  n.dateAddress.Text,
+		CommentCount: n.commentCount.Text,
+		Images:       n.images(),
+		OpenedAt:     time.Now(),
+	}
+}
+
+// NoteID 从笔记详情地址中取出笔记 id，不是笔记地址时返回空字符串
+func NoteID(url string) string {
+	if m := noteURL.FindStringSubmatch(url); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+type opened struct {
+	info NoteInfo
+	err  error
+}
+
+// Lifecycle 跟踪主页面上笔记详情的打开和关闭
+// 详情弹窗出现或地址变为 /explore/<id> 时等待详情加载完成并提取，发布 note:opened；
+// 弹窗移除或离开笔记地址时停止媒体采集，发布 note:closed。在浏览器中手动打开的笔记同样会被提取
+type Lifecycle struct {
+	site         *site.Site
+	page         playwright.Page
+	watcher      *scripts.DOMWatcher
+	mediaCapture *scripts.MediaCapture
+	bus          EventBus.Bus
+	noteClass    string
+	readyTimeout time.Duration
+
+	mu         sync.Mutex
+	watchID    string
+	onNavigate func(playwright.Frame) // 主框架跳转的监听，Stop 时移除
+	stopped    bool
+	opening    string      // 正在提取的笔记 id
+	current    *NoteDetail // 当前打开的笔记，提取完成前为 nil
+	info       NoteInfo    // 当前打开的笔记的页面元素，用于控制视频等
+	sequence   int         // 每次打开和关闭递增，提取完成时笔记已关闭则丢弃结果
+	waiters    []chan opened
+}
+
+// NewLifecycle 创建主页面的笔记生命周期管理，noteClass 为详情弹窗的类名
+func NewLifecycle(s *site.Site, page playwright.Page, watcher *scripts.DOMWatcher, mediaCapture *scripts.MediaCapture, bus EventBus.Bus, noteClass string) *Lifecycle {
+	return &Lifecycle{
+		site:         s,
+		page:         page,
+		watcher:      watcher,
+		mediaCapture: mediaCapture,
+		bus:          bus,
+		noteClass:    noteClass,
+		readyTimeout: DefaultReadyTimeout,
+	}
+}
+
+// Start 开始监听详情弹窗和地址变化，当前页面已经是笔记详情时立即提取
+func (l *Lifecycle) Start() error {
+	id, err := l.watcher.Watch(scripts.WatchSpec{Selector: "." + l.noteClass}, l.onDOM)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.watchID = id
+	l.mu.Unlock()
+	onNavigate := func(frame playwright.Frame) {
+		if frame != l.page.MainFrame() || l.isStopped() {
+			return
+		}
+		// 页面事件的回调中不能调用 Evaluate
+		go l.onNavigated(frame.URL())
+	}
+	l.mu.Lock()
+	l.onNavigate = onNavigate
+	l.mu.Unlock()
+	l.page.On("framenavigated", onNavigate)
+	l.onNavigated(l.page.URL())
+	return nil
+}
+
+// Stop 停止监听，不再发布事件
+func (l *Lifecycle) Stop() error {
+	l.mu.Lock()
+	l.stopped = true
+	id := l.watchID
+	l.watchID = ""
+	onNavigate := l.onNavigate
+	l.onNavigate = nil
+	l.reset()
+	waiters := l.takeWaiters()
+	l.mu.Unlock()
+	if onNavigate != nil {
+		l.page.RemoveListener("framenavigated", onNavigate)
+	}
+	notify(waiters, opened{err: fmt.Errorf("笔记生命周期已停止")})
+	if id == "" {
+		return nil
+	}
+	return l.watcher.Unwatch(id)
+}
+
+func (l *Lifecycle) isStopped() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopped
+}
+
+// Current 当前打开的笔记
+func (l *Lifecycle) Current() (NoteDetail, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return NoteDetail{}, false
+	}
+	return *l.current, true
+}
+
+// CurrentNote 当前打开的笔记及其页面元素
+func (l *Lifecycle) CurrentNote() (NoteInfo, NoteDetail, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return NoteInfo{}, NoteDetail{}, false
+	}
+	return l.info, *l.current, true
+}
+
+// WaitOpened 执行 action（例如点击笔记封面），等待随后打开的笔记加载并提取完成
+func (l *Lifecycle) WaitOpened(ctx context.Context, action func(ctx context.Context) error) (NoteInfo, error) {
+	ch := make(chan opened, 1)
+	l.mu.Lock()
+	l.waiters = append(l.waiters, ch)
+	l.mu.Unlock()
+	defer l.removeWaiter(ch)
+
+	if err := action(ctx); err != nil {
+		return NoteInfo{}, err
+	}
+	timer := time.NewTimer(l.readyTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-ch:
+		return result.info, result.err
+	case <-timer.C:
+		return NoteInfo{}, fmt.Errorf("笔记详情在 %s 内没有打开", l.readyTimeout)
+	case <-ctx.Done():
+		return NoteInfo{}, ctx.Err()
+	}
+}
+
+func (l *Lifecycle) removeWaiter(ch chan opened) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, waiter := range l.waiters {
+		if waiter == ch {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *Lifecycle) onDOM(event scripts.DOMEvent) {
+	switch event.Type {
+	case scripts.DOMAdded:
+		url := l.page.URL()
+		if id := l.noteID(url, event.Handle); id != "" {
+			l.open(id, url)
+			return
+		}
+		// 地址和弹窗上都还没有笔记 id，详情加载后再读取，不用临时 id 发布事件
+		go l.awaitID(event.Handle)
+	case scripts.DOMRemoved:
+		l.close()
+	}
+}
+
+// noteID 优先从地址中取笔记 id，地址没有变化时使用弹窗上的 note-id
+func (l *Lifecycle) noteID(url string, handle string) string {
+	if id := NoteID(url); id != "" {
+		return id
+	}
+	id, _ := l.watcher.Locator(handle).GetAttribute("note-id")
+	return id
+}
+
+// awaitID 等待详情加载完成后再读取笔记 id；期间地址变化已打开笔记或弹窗已关闭时放弃
+func (l *Lifecycle) awaitID(handle string) {
+	l.mu.Lock()
+	sequence := l.sequence
+	l.mu.Unlock()
+	_ = l.page.Locator(l.site.Get("note.container")).WaitFor(playwright.LocatorWaitForOptions{
+		State:   playwright.WaitForSelectorStateVisible,
+		Timeout: playwright.Float(float64(l.readyTimeout.Milliseconds())),
+	})
+	l.mu.Lock()
+	changed := l.stopped || l.sequence != sequence
+	var dropped []chan opened
+	if changed {
+		dropped = l.dropWaiters()
+	}
+	l.mu.Unlock()
+	if changed {
+		notify(dropped, opened{err: fmt.Errorf("笔记在加载完成前已关闭")})
+		return
+	}
+	url := l.page.URL()
+	if id := l.noteID(url, handle); id != "" {
+		l.open(id, url)
+		return
+	}
+	logger.Warn("note id not found, note is not published", "url", url)
+	l.mu.Lock()
+	waiters := l.takeWaiters()
+	l.mu.Unlock()
+	notify(waiters, opened{err: fmt.Errorf("笔记 id 未知: %s", url)})
+}
+
+func (l *Lifecycle) onNavigated(url string) {
+	if id := NoteID(url); id != "" {
+		l.open(id, url)
+		return
+	}
+	l.close()
+}
+
+// open 开始提取笔记，同一篇笔记正在提取时忽略，已打开时直接返回给等待者；打开另一篇时先关闭当前的笔记
+func (l *Lifecycle) open(id string, url string) {
+	l.mu.Lock()
+	if l.stopped || l.opening == id {
+		l.mu.Unlock()
+		return
+	}
+	if l.current != nil && l.current.ID == id {
+		waiters, info := l.takeWaiters(), l.info
+		l.mu.Unlock()
+		notify(waiters, opened{info: info})
+		return
+	}
+	previous, active := l.reset()
+	l.opening = id
+	sequence := l.sequence
+	l.mu.Unlock()
+
+	if active {
+		l.closed(previous)
+	}
+	go l.extract(sequence, id, url)
+}
+
+func (l *Lifecycle) extract(sequence int, id string, url string) {
+	info, err := l.load()
+	l.mu.Lock()
+	if l.sequence != sequence {
+		// 提取期间笔记已关闭
+		dropped := l.dropWaiters()
+		l.mu.Unlock()
+		notify(dropped, opened{err: fmt.Errorf("笔记 %s 在加载完成前已关闭", id)})
+		return
+	}
+	l.opening = ""
+	waiters := l.takeWaiters()
+	var detail NoteDetail
+	if err == nil {
+		detail = info.Detail(id, url)
+		l.current = &detail
+		l.info = info
+	}
+	l.mu.Unlock()
+
+	notify(waiters, opened{info: info, err: err})
+	if err != nil {
+		logger.Warn("failed to extract note", "note", id, "error", err)
+		return
+	}
+	logger.Info("note opened", "note", id, "type", detail.Type, "title", detail.Title)
+	l.bus.Publish(eventbus.TopicNoteOpened, detail)
+}
+
+// load 等待 #noteContainer 出现后提取笔记详情
+func (l *Lifecycle) load() (NoteInfo, error) {
+	container := l.page.Locator(l.site.Get("note.container"))
+	err := container.WaitFor(playwright.LocatorWaitForOptions{
+		State:   playwright.WaitForSelectorStateVisible,
+		Timeout: playwright.Float(float64(l.readyTimeout.Milliseconds())),
+	})
+	if err != nil {
+		return NoteInfo{}, fmt.Errorf("笔记详情没有加载完成: %w", err)
+	}
+	return NewNote(l.site, driver.NewPage(l.page), l.mediaCapture).Show()
+}
+
+// takeWaiters 取出所有等待者，调用方需持有锁
+func (l *Lifecycle) takeWaiters() []chan opened {
+	waiters := l.waiters
+	l.waiters = nil
+	return waiters
+}
+
+// dropWaiters 结果被丢弃时取出等待者；已有新的笔记正在提取时留给它返回，调用方需持有锁
+func (l *Lifecycle) dropWaiters() []chan opened {
+	if l.opening != "" && !l.stopped {
+		return nil
+	}
+	return l.takeWaiters()
+}
+
+// notify 把结果发给等待者，通道有缓冲且每个等待者只会收到一次
+func notify(waiters []chan opened, result opened) {
+	for _, waiter := range waiters {
+		waiter <- result
+	}
+}
+
+// close 关闭当前的笔记
+func (l *Lifecycle) close() {
+	l.mu.Lock()
+	previous, active := l.reset()
+	l.mu.Unlock()
+	if active {
+		l.closed(previous)
+	}
+}
+
+// reset 清除当前的笔记，返回关闭的笔记以及是否有打开或正在提取的笔记，调用方需持有锁
+func (l *Lifecycle) reset() (*NoteDetail, bool) {
+	previous, active := l.current, l.current != nil || l.opening != ""
+	l.current = nil
+	l.info = NoteInfo{}
+	l.opening = ""
+	l.sequence++
+	return previous, active
+}
+
+// closed 停止媒体采集，笔记已提取完成时发布 note:closed
+func (l *Lifecycle) closed(detail *NoteDetail) {
+	if l.mediaCapture != nil && !l.page.IsClosed() {
+		if err := l.mediaCapture.Shutdown(); err != nil {
+			logger.Warn("failed to stop media capture", "error", err)
+		}
+	}
+	if detail == nil {
+		return
+	}
+	logger.Info("note closed", "note", detail.ID)
+	l.bus.Publish(eventbus.TopicNoteClosed, *detail)
+}
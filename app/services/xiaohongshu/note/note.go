@@ -1,28 +1,17 @@
 package note
 
 import (
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/entity"
 	"xiaohongshu/app/services/xiaohongshu/scripts"
-
-	"github.com/playwright-community/playwright-go"
-	"github.com/spf13/cast"
+	"xiaohongshu/app/services/xiaohongshu/site"
 )
 
-// Note 浏览详情
-type SwiperItem struct {
-	imag   string
-	active bool
-	index  int
-}
-type Swiper struct {
-	item []SwiperItem
-	entity.Element
-}
-
+// NoteInfo 笔记详情
 type NoteInfo struct {
 	noteType string
 	//图片
-	swiper     Swiper
+	swiper     *Swiper
 	swiperPrev entity.Element
 	swiperNext entity.Element
 	//视频
@@ -46,18 +35,54 @@ type NoteInfo struct {
 	comment *Comment
 }
 
+// Swiper 图片轮播，视频笔记为 nil
+func (n NoteInfo) Swiper() *Swiper {
+	return n.swiper
+}
+
 func (n NoteInfo) Video() *Video {
 	return n.video
 }
 
+// Comment 评论区
+func (n NoteInfo) Comment() *Comment {
+	return n.comment
+}
+
+// Map 转换为 map 以便前端和任务结果使用
+func (n NoteInfo) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"type":         n.noteType,
+		"author":       n.authorElement.Text,
+		"title":        n.title.Text,
+		"desc":         n.desc.Text,
+		"dateAddress":  n.dateAddress.Text,
+		"commentCount": n.commentCount.Text,
+		"images":       n.images(),
+	}
+}
+
+// images 打开笔记时已经加载的图片地址
+func (n NoteInfo) images() []string {
+	if n.swiper == nil {
+		return []string{}
+	}
+	images := make([]string, 0, len(n.swiper.items))
+	for _, item := range n.swiper.items {
+		images = append(images, item.Image)
+	}
+	return images
+}
+
 type Note struct {
-	locator      playwright.Locator
+	site         *site.Site
+	locator      driver.Locator
 	mediaCapture *scripts.MediaCapture
 }
 
-func NewNote(page playwright.Page, mediaCapture *scripts.MediaCapture) *Note {
-	locator := page.Locator("#noteContainer")
-	return &Note{locator: locator, mediaCapture: mediaCapture}
+func NewNote(s *site.Site, page driver.Page, mediaCapture *scripts.MediaCapture) *Note {
+	locator := page.Locator(s.Get("note.container"))
+	return &Note{site: s, locator: locator, mediaCapture: mediaCapture}
 }
 
 func (n *Note) Show() (NoteInfo, error) {
@@ -70,57 +95,32 @@ func (n *Note) Show() (NoteInfo, error) {
 	info.noteType = attribute
 	if info.noteType == "video" {
 		info.video = NewVideo(
-			n.locator.Locator(".player-container"),
+			n.site,
+			n.locator.Locator(n.site.Get("note.video")),
 			n.mediaCapture,
 		)
 	} else {
-		info.swiper = n.swiperHandler(n.locator.Locator(".swiper-slide:not(.swiper-slide-duplicate-active)"))
+		info.swiper = newSwiper(n.site, n.locator.Locator(n.site.Get("note.swiper")))
+		info.swiper.items = info.swiper.read()
+		info.swiperPrev = n.site.BuildElement(n.locator, "note.swiperPrev")
+		info.swiperNext = n.site.BuildElement(n.locator, "note.swiperNext")
 	}
 	//用户头像区域
-	info.authorElement = scripts.BuildElement(n.locator, ".interaction-container .author-container .author-wrapper .info .name")
+	info.authorElement = n.site.BuildElement(n.locator, "note.author")
 	//关注区域
-	info.followElement = scripts.BuildElement(n.locator, ".interaction-container .author-container .author-wrapper  .note-detail-follow-btn", "关注")
-	info.likeElement = scripts.BuildElement(n.locator, ".engage-bar .like-lottie")
-	info.collectElement = scripts.BuildElement(n.locator, ".engage-bar .collect-wrapper")
+	info.followElement = n.site.BuildElement(n.locator, "note.follow", "关注")
+	info.likeElement = n.site.BuildElement(n.locator, "note.like")
+	info.collectElement = n.site.BuildElement(n.locator, "note.collect")
 
 	//标题
-	info.title = scripts.BuildElement(n.locator, ".interaction-container .note-scroller .note-content .title")
+	info.title = n.site.BuildElement(n.locator, "note.title")
 
-	info.desc = scripts.BuildElement(n.locator, ".interaction-container .note-scroller .note-content .desc")
+	info.desc = n.site.BuildElement(n.locator, "note.desc")
 
-	info.dateAddress = scripts.BuildElement(n.locator, ".interaction-container .note-scroller .note-content .bottom-container .date")
+	info.dateAddress = n.site.BuildElement(n.locator, "note.date")
 
-	info.commentCount = scripts.BuildElement(n.locator, ".interaction-container .note-scroller .comments-el .comments-container .total")
-	info.comment = NewComment(n.locator)
+	info.commentCount = n.site.BuildElement(n.locator, "note.commentCount")
+	info.comment = NewComment(n.site, n.locator)
+	n.site.Observe("note", n.locator)
 	return info, nil
 }
-
-func (n *Note) swiperHandler(slideLocator playwright.Locator) Swiper {
-	slides, err := slideLocator.All()
-	if err != nil {
-		slides = make([]playwright.Locator, 0)
-	}
-	items := make([]SwiperItem, len(slides))
-	for _, slide := range slides {
-		index, err := slide.GetAttribute("data-index")
-		if err != nil {
-			continue
-		}
-		imaSrc, err := slide.Locator("img").GetAttribute("src")
-		if err != nil {
-			continue
-		}
-		items = append(items, SwiperItem{
-			imag:   imaSrc,
-			active: false,
-			index:  cast.ToInt(index),
-		})
-	}
-	return Swiper{
-		item: items,
-		Element: entity.Element{
-			Text:     "show swiper",
-			Selector: slideLocator,
-		},
-	}
-}
@@ -0,0 +1,239 @@
+package note
+
+import (
+	"fmt"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
+
+	"github.com/spf13/cast"
+)
+
+// PlaybackState 视频播放状态
+type PlaybackState string
+
+const (
+	StatePlaying PlaybackState = "playing"
+	StatePaused  PlaybackState = "paused"
+	StateWaiting PlaybackState = "waiting" // 缓冲中
+	StateEnded   PlaybackState = "ended"
+	StateError   PlaybackState = "error"
+)
+
+// StateChange 一次播放状态变化
+type StateChange struct {
+	State PlaybackState `json:"state"`
+	Time  time.Duration `json:"time"` // 发生时的播放位置
+	Error string        `json:"error"`
+}
+
+// TimeRange 已缓冲的时间段
+type TimeRange struct {
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// seekTimeout 页面中等待 seeked 事件的时间
+const seekTimeout = 10 * time.Second
+
+// stateScript 根据视频元素的属性判断当前的播放状态
+const stateScript = `(video) => video.error ? 'error'
+	: video.ended ? 'ended'
+	: video.paused ? 'paused'
+	: video.readyState < 3 ? 'waiting'
+	: 'playing'`
+
+func seconds(d time.Duration) float64 {
+	return d.Seconds()
+}
+
+func duration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Play 开始播放，页面拒绝播放（例如资源无法加载）时返回错误
+func (v *Video) Play() error {
+	_, err := v.videoElement.Evaluate(`(video) => video.play()`, nil)
+	return err
+}
+
+// Pause 暂停播放
+func (v *Video) Pause() error {
+	_, err := v.videoElement.Evaluate(`(video) => video.pause()`, nil)
+	return err
+}
+
+// Seek 跳转到 position，等待页面的 seeked 事件后返回，此时画面已经是新位置的帧
+func (v *Video) Seek(position time.Duration) error {
+	if position < 0 {
+		return fmt.Errorf("播放位置不能为负数: %s", position)
+	}
+	_, err := v.videoElement.Evaluate(`(video, [position, timeout]) => new Promise((resolve, reject) => {
+		if (!video.seeking && Math.abs(video.currentTime - position) < 0.001) {
+			resolve(video.currentTime);
+			return;
+		}
+		const done = () => { cleanup(); resolve(video.currentTime); };
+		const timer = setTimeout(() => { cleanup(); reject(new Error('seek timeout')); }, timeout);
+		const cleanup = () => { clearTimeout(timer); video.removeEventListener('seeked', done); };
+		video.addEventListener('seeked', done);
+		video.currentTime = position;
+	})`, []interface{}{seconds(position), seekTimeout.Milliseconds()})
+	return err
+}
+
+// SetPlaybackRate 设置播放速度，1 为正常速度
+func (v *Video) SetPlaybackRate(rate float64) error {
+	if rate <= 0 {
+		return fmt.Errorf("播放速度必须大于 0: %v", rate)
+	}
+	_, err := v.videoElement.Evaluate(`(video, rate) => { video.playbackRate = rate; }`, rate)
+	return err
+}
+
+// SetMuted 设置是否静音
+func (v *Video) SetMuted(muted bool) error {
+	_, err := v.videoElement.Evaluate(`(video, muted) => { video.muted = muted; }`, muted)
+	return err
+}
+
+// SetVolume 设置音量，范围 0-1
+func (v *Video) SetVolume(volume float64) error {
+	if volume < 0 || volume > 1 {
+		return fmt.Errorf("音量必须在 0 到 1 之间: %v", volume)
+	}
+	_, err := v.videoElement.Evaluate(`(video, volume) => { video.volume = volume; }`, volume)
+	return err
+}
+
+// Duration 视频时长，元数据尚未加载或是直播流时返回错误
+func (v *Video) Duration() (time.Duration, error) {
+	result, err := v.videoElement.Evaluate(`(video) => Number.isFinite(video.duration) ? video.duration : -1`, nil)
+	if err != nil {
+		return 0, err
+	}
+	d := cast.ToFloat64(result)
+	if d < 0 {
+		return 0, fmt.Errorf("视频时长未知，元数据尚未加载")
+	}
+	return duration(d), nil
+}
+
+// CurrentTime 当前播放位置
+func (v *Video) CurrentTime() (time.Duration, error) {
+	result, err := v.videoElement.Evaluate(`(video) => video.currentTime`, nil)
+	if err != nil {
+		return 0, err
+	}
+	return duration(cast.ToFloat64(result)), nil
+}
+
+// Buffered 已缓冲的时间段
+func (v *Video) Buffered() ([]TimeRange, error) {
+	result, err := v.videoElement.Evaluate(`(video) => Array.from({length: video.buffered.length},
+		(_, i) => [video.buffered.start(i), video.buffered.end(i)])`, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := result.([]interface{})
+	ranges := make([]TimeRange, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		ranges = append(ranges, TimeRange{Start: duration(cast.ToFloat64(pair[0])), End: duration(cast.ToFloat64(pair[1]))})
+	}
+	return ranges, nil
+}
+
+// Resolution 视频的原始分辨率，元数据尚未加载时为 0
+func (v *Video) Resolution() (width int, height int, err error) {
+	result, err := v.videoElement.Evaluate(`(video) => [video.videoWidth, video.videoHeight]`, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, _ := result.([]interface{})
+	if len(size) != 2 {
+		return 0, 0, fmt.Errorf("无法读取视频分辨率")
+	}
+	return cast.ToInt(size[0]), cast.ToInt(size[1]), nil
+}
+
+// State 当前的播放状态
+func (v *Video) State() (PlaybackState, error) {
+	result, err := v.videoElement.Evaluate(stateScript, nil)
+	if err != nil {
+		return "", err
+	}
+	return PlaybackState(cast.ToString(result)), nil
+}
+
+// ListenState 订阅播放状态变化，首次订阅时自动在视频元素上添加监听，返回取消订阅的函数
+func (v *Video) ListenState(handler func(StateChange)) (func(), error) {
+	if v.mediaCapture == nil {
+		return nil, driver.ErrUnsupported
+	}
+	id, err := v.watchPlayback()
+	if err != nil {
+		return nil, err
+	}
+	return v.mediaCapture.Subscribe(scripts.TopicVideoPlayback, func(payload interface{}) {
+		if event, ok := payload.(scripts.PlaybackEvent); ok && event.Video == id {
+			handler(StateChange{State: PlaybackState(event.State), Time: duration(event.Time), Error: event.Error})
+		}
+	}), nil
+}
+
+// WaitUntil 等待视频进入 state，当前已经是该状态时立即返回
+func (v *Video) WaitUntil(state PlaybackState, timeout time.Duration) error {
+	changes := make(chan StateChange, 16)
+	cancel, err := v.ListenState(func(change StateChange) {
+		select {
+		case changes <- change:
+		default:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	// 先订阅再检查当前状态，避免错过两者之间的变化
+	if current, err := v.State(); err != nil {
+		return err
+	} else if current == state {
+		return nil
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case change := <-changes:
+			if change.State == state {
+				return nil
+			}
+		case <-timer.C:
+			current, _ := v.State()
+			return fmt.Errorf("视频在 %s 内没有进入 %s 状态，当前为 %s", timeout, state, current)
+		}
+	}
+}
+
+// watchPlayback 在视频元素上添加播放状态监听，返回视频 id
+func (v *Video) watchPlayback() (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.playbackID != "" {
+		return v.playbackID, nil
+	}
+	element, ok := driver.Playwright(v.videoElement)
+	if !ok {
+		return "", driver.ErrUnsupported
+	}
+	id, err := v.mediaCapture.WatchPlayback(element)
+	if err != nil {
+		return "", err
+	}
+	v.playbackID = id
+	return id, nil
+}
@@ -1,13 +1,13 @@
 package note
 
-import "github.com/playwright-community/playwright-go"
+import "xiaohongshu/app/services/xiaohongshu/driver"
 
 // SendComment 发送评论
 type SendComment struct {
-	locator playwright.Locator
+	locator driver.Locator
 }
 
-func NewSendComment(locator playwright.Locator) *SendComment {
+func NewSendComment(locator driver.Locator) *SendComment {
 	//engage-bar active
 	return &SendComment{
 		locator: locator,
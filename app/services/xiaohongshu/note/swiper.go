@@ -0,0 +1,332 @@
+package note
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/entity"
+	"xiaohongshu/app/services/xiaohongshu/site"
+
+	"github.com/spf13/cast"
+)
+
+// DefaultTransitionTimeout 等待轮播切换完成的时间
+const DefaultTransitionTimeout = 3 * time.Second
+
+// DefaultLoadTimeout 切换到懒加载的图片后等待图片地址出现的时间
+const DefaultLoadTimeout = 5 * time.Second
+
+// transitionPoll 检查轮播是否切换完成的间隔
+const transitionPoll = 50 * time.Millisecond
+
+// Slide 轮播中的一张图片
+type Slide struct {
+	Index     int    `json:"index"`
+	Image     string `json:"image"`               // 原图地址
+	LiveVideo string `json:"liveVideo,omitempty"` // 实况图的视频地址
+}
+
+// Swiper 图文笔记的图片轮播
+// 循环模式下首尾复制出的幻灯片不计入，序号为幻灯片的 data-index
+type Swiper struct {
+	entity.Element
+	site              *site.Site
+	slides            driver.Locator
+	active            driver.Locator
+	prev              driver.Locator
+	next              driver.Locator
+	items             []Slide // 打开笔记时已加载的图片
+	TransitionTimeout time.Duration
+	LoadTimeout       time.Duration
+}
+
+func newSwiper(s *site.Site, container driver.Locator) *Swiper {
+	return &Swiper{
+		Element:           s.Element("show swiper", container),
+		site:              s,
+		slides:            container.Locator(s.Get("note.slide")),
+		active:            container.Locator(s.Get("note.slideActive")),
+		prev:              container.Locator(s.Get("note.swiperPrev")),
+		next:              container.Locator(s.Get("note.swiperNext")),
+		TransitionTimeout: DefaultTransitionTimeout,
+		LoadTimeout:       DefaultLoadTimeout,
+	}
+}
+
+// Slides 打开笔记时已经加载的图片，懒加载的图片可能缺失，完整的图片使用 AllImages
+func (s *Swiper) Slides() []Slide {
+	return s.items
+}
+
+// Count 图片数量
+func (s *Swiper) Count() (int, error) {
+	indexes, err := s.indexes()
+	return len(indexes), err
+}
+
+// Current 当前显示的图片序号
+func (s *Swiper) Current() (int, error) {
+	index, err := s.active.First().GetAttribute("data-index")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(index)
+}
+
+// Next 切换到下一张并等待切换完成，最后一张的下一张是第一张
+func (s *Swiper) Next(ctx context.Context) error {
+	return s.step(ctx, s.next, 1)
+}
+
+// Prev 切换到上一张并等待切换完成，第一张的上一张是最后一张
+func (s *Swiper) Prev(ctx context.Context) error {
+	return s.step(ctx, s.prev, -1)
+}
+
+// GoTo 按较短的方向切换到第 index 张
+func (s *Swiper) GoTo(ctx context.Context, index int) error {
+	count, err := s.Count()
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= count {
+		return fmt.Errorf("图片序号 %d 超出范围 [0, %d)", index, count)
+	}
+	current, err := s.Current()
+	if err != nil {
+		return err
+	}
+	forward := (index - current + count) % count
+	for i := 0; i < forward && forward <= count-forward; i++ {
+		if err := s.Next(ctx); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < count-forward && forward > count-forward; i++ {
+		if err := s.Prev(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllImages 依次切换到每一张以触发懒加载，按序号返回所有图片的原图和实况视频地址，结束后切回原来的图片
+// 某一张在 LoadTimeout 内没有加载出图片地址时返回错误，不会返回少于 Count 张的结果
+func (s *Swiper) AllImages(ctx context.Context) ([]Slide, error) {
+	count, err := s.Count()
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return []Slide{}, nil
+	}
+	start, err := s.Current()
+	if err != nil {
+		return nil, err
+	}
+	if count == 1 {
+		slide, err := s.waitSlide(ctx, start)
+		if err != nil {
+			return nil, err
+		}
+		return []Slide{slide}, nil
+	}
+	slides := make(map[int]Slide, count)
+	for i := 0; i < count; i++ {
+		current, err := s.Current()
+		if err != nil {
+			return nil, err
+		}
+		slide, err := s.waitSlide(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		slides[current] = slide
+		if i < count-1 {
+			if err := s.Next(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := s.GoTo(ctx, start); err != nil {
+		return nil, err
+	}
+	result := make([]Slide, 0, len(slides))
+	for _, slide := range slides {
+		result = append(result, slide)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+func (s *Swiper) step(ctx context.Context, arrow driver.Locator, delta int) error {
+	count, err := s.Count()
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return fmt.Errorf("只有 %d 张图片，无法切换", count)
+	}
+	current, err := s.Current()
+	if err != nil {
+		return err
+	}
+	target := (current + delta + count) % count
+	button := s.site.Element("swiper arrow", arrow)
+	if err := button.Click(ctx); err != nil {
+		return err
+	}
+	return s.wait(ctx, target)
+}
+
+// wait 等待切换动画结束、第 target 张成为当前图片
+func (s *Swiper) wait(ctx context.Context, target int) error {
+	deadline := time.Now().Add(s.TransitionTimeout)
+	for {
+		if current, err := s.Current(); err == nil && current == target {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("轮播没有在 %s 内切换到第 %d 张", s.TransitionTimeout, target)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(transitionPoll):
+		}
+	}
+}
+
+// waitSlide 等待当前显示的第 index 张加载出图片地址
+func (s *Swiper) waitSlide(ctx context.Context, index int) (Slide, error) {
+	deadline := time.Now().Add(s.LoadTimeout)
+	for {
+		if slide, ok := s.slide(index); ok {
+			return slide, nil
+		}
+		if time.Now().After(deadline) {
+			return Slide{}, fmt.Errorf("第 %d 张图片没有在 %s 内加载", index, s.LoadTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return Slide{}, ctx.Err()
+		case <-time.After(transitionPoll):
+		}
+	}
+}
+
+// indexes 不重复的幻灯片序号
+func (s *Swiper) indexes() ([]int, error) {
+	slides, err := s.slides.All()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool, len(slides))
+	indexes := make([]int, 0, len(slides))
+	for _, slide := range slides {
+		index, err := slide.GetAttribute("data-index")
+		if err != nil || index == "" || seen[cast.ToInt(index)] {
+			continue
+		}
+		seen[cast.ToInt(index)] = true
+		indexes = append(indexes, cast.ToInt(index))
+	}
+	return indexes, nil
+}
+
+// read 读取已经加载的图片，不切换轮播
+func (s *Swiper) read() []Slide {
+	indexes, err := s.indexes()
+	s.site.Record("note.slide", err == nil && len(indexes) > 0)
+	items := make([]Slide, 0, len(indexes))
+	for _, index := range indexes {
+		if slide, ok := s.slide(index); ok {
+			items = append(items, slide)
+		}
+	}
+	return items
+}
+
+// slide 读取第 index 张幻灯片的图片和实况视频地址，图片还没有加载时返回 false
+func (s *Swiper) slide(index int) (Slide, bool) {
+	var locator driver.Locator
+	slides, _ := s.slides.All()
+	for _, slide := range slides {
+		if value, _ := slide.GetAttribute("data-index"); value != "" && cast.ToInt(value) == index {
+			locator = slide
+			break
+		}
+	}
+	if locator == nil {
+		return Slide{}, false
+	}
+	image := imageURL(locator.Locator(s.site.Get("note.slideImage")).First())
+	if image == "" {
+		return Slide{}, false
+	}
+	slide := Slide{Index: index, Image: FullImageURL(image)}
+	video := locator.Locator(s.site.Get("note.slideVideo"))
+	if count, err := video.Count(); err == nil && count > 0 {
+		src, _ := video.First().GetAttribute("src")
+		if src == "" {
+			src, _ = video.First().GetAttribute("data-src")
+		}
+		slide.LiveVideo = FullImageURL(src)
+	}
+	return slide, true
+}
+
+// imageURL 图片地址，依次使用 srcset 中最大的一张、src 和懒加载的 data-src
+func imageURL(img driver.Locator) string {
+	if count, err := img.Count(); err != nil || count == 0 {
+		return ""
+	}
+	if srcset, _ := img.GetAttribute("srcset"); srcset != "" {
+		if url := largestCandidate(srcset); url != "" {
+			return url
+		}
+	}
+	if src, _ := img.GetAttribute("src"); src != "" && !strings.HasPrefix(src, "data:") {
+		return src
+	}
+	src, _ := img.GetAttribute("data-src")
+	return src
+}
+
+// largestCandidate srcset 中宽度或像素密度最大的地址
+func largestCandidate(srcset string) string {
+	best, bestSize := "", -1.0
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		size := 1.0
+		if len(fields) > 1 {
+			size = cast.ToFloat64(strings.TrimRight(fields[1], "wx"))
+		}
+		if size > bestSize {
+			best, bestSize = fields[0], size
+		}
+	}
+	return best
+}
+
+// FullImageURL 去掉图片地址中 ! 之后的缩放和格式参数以及 ?imageView2 等查询参数得到原图，补全省略协议的地址
+func FullImageURL(url string) string {
+	if strings.HasPrefix(url, "//") {
+		url = "https:" + url
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	slash := strings.LastIndex(url, "/")
+	if i := strings.Index(url[slash+1:], "!"); i >= 0 {
+		url = url[:slash+1+i]
+	}
+	return url
+}
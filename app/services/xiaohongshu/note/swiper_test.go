@@ -0,0 +1,29 @@
+package note
+
+import "testing"
+
+func TestFullImageURL(t *testing.T) {
+	cases := map[string]string{
+		"//sns-webpic-qc.xhscdn.com/202406/abc/1040g2sg!nd_dft_wlteh_webp_3":    "https://sns-webpic-qc.xhscdn.com/202406/abc/1040g2sg",
+		"https://ci.xiaohongshu.com/1040g2sg?imageView2/2/w/1080":               "https://ci.xiaohongshu.com/1040g2sg",
+		"//sns-img-qc.xhscdn.com/1040g2sg?imageView2/2/w/540/format/webp":       "https://sns-img-qc.xhscdn.com/1040g2sg",
+		"https://sns-webpic-qc.xhscdn.com/abc/1040g2sg!nd_dft_wlteh_webp_3?t=1": "https://sns-webpic-qc.xhscdn.com/abc/1040g2sg",
+		"https://ci.xiaohongshu.com/1040g2sg":                                   "https://ci.xiaohongshu.com/1040g2sg",
+		"/static/slide1.png!nd_prv_wlteh_webp_3":                                "/static/slide1.png",
+		"":                                                                      "",
+	}
+	for in, want := range cases {
+		if got := FullImageURL(in); got != want {
+			t.Errorf("FullImageURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLargestCandidate(t *testing.T) {
+	if got := largestCandidate("/a.jpg 540w, /b.jpg 1080w, /c.jpg 720w"); got != "/b.jpg" {
+		t.Fatalf("unexpected width candidate %q", got)
+	}
+	if got := largestCandidate("/a.jpg, /b.jpg 2x"); got != "/b.jpg" {
+		t.Fatalf("unexpected density candidate %q", got)
+	}
+}
@@ -1,28 +1,21 @@
 package note
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 	"xiaohongshu/app/services/xiaohongshu/scripts"
+	"xiaohongshu/app/services/xiaohongshu/site"
 
-	"github.com/playwright-community/playwright-go"
 	"github.com/spf13/cast"
 )
 
-// VideoFrame 视频帧数据结构
-type VideoFrame struct {
-	Width  int64       `json:"width"`
-	Height int64       `json:"height"`
-	Data   interface{} `json:"data"`
-	Ts     float64     `json:"ts"`
-}
+// VideoFrame 视频帧数据结构，与 MediaCapture 发布的类型相同
+type VideoFrame = scripts.VideoFrame
 
-// VideoAudio 视频音频数据结构
-type VideoAudio struct {
-	SampleRate int64       `json:"sampleRate"`
-	Channels   int64       `json:"channels"`
-	Frames     int64       `json:"frames"`
-	Buffer     interface{} `json:"buffer"`
-	Ts         float64     `json:"ts"`
-}
+// VideoAudio 视频音频数据结构，与 MediaCapture 发布的类型相同
+type VideoAudio = scripts.VideoAudio
 
 // VideoState 视频状态枚举
 type VideoState int
@@ -36,22 +29,64 @@ const (
 )
 
 type Video struct {
-	locator      playwright.Locator
-	videoElement playwright.Locator
+	site         *site.Site
+	locator      driver.Locator
+	videoElement driver.Locator
 	mediaCapture *scripts.MediaCapture
+	mu           sync.Mutex
+	playbackID   string // 播放状态监听添加后视频元素的 id
+	captureID    string // Start 返回的采集 id
 }
 
-func NewVideo(locator playwright.Locator, mediaCapture *scripts.MediaCapture) *Video {
+func NewVideo(s *site.Site, locator driver.Locator, mediaCapture *scripts.MediaCapture) *Video {
 	return &Video{
+		site:         s,
 		mediaCapture: mediaCapture,
 		locator:      locator,
-		videoElement: locator.Locator("video"),
+		videoElement: locator.Locator(s.Get("video.element")),
 	}
 }
 
+// Start 开始采集视频的画面和音频，已在采集时什么也不做
 func (v *Video) Start() error {
-	err := v.mediaCapture.Start(v.videoElement)
-	return err
+	// 媒体采集依赖真实浏览器
+	element, ok := driver.Playwright(v.videoElement)
+	if !ok || v.mediaCapture == nil {
+		return driver.ErrUnsupported
+	}
+	id, err := v.mediaCapture.Start(element)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.captureID = id
+	v.mu.Unlock()
+	return nil
+}
+
+// Stop 停止采集，笔记关闭时所有采集会自动销毁
+func (v *Video) Stop() error {
+	id := v.CaptureID()
+	if id == "" || v.mediaCapture == nil {
+		return nil
+	}
+	return v.mediaCapture.Stop(id)
+}
+
+// CaptureID 采集 id，没有开始采集时为空
+func (v *Video) CaptureID() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.captureID
+}
+
+// CaptureStatus 采集的帧率、丢帧数和音频块数
+func (v *Video) CaptureStatus() (scripts.CaptureStatus, error) {
+	id := v.CaptureID()
+	if id == "" || v.mediaCapture == nil {
+		return scripts.CaptureStatus{}, fmt.Errorf("视频没有开始采集")
+	}
+	return v.mediaCapture.Status(id)
 }
 
 //player-el xgplayer xgplayer-pc xhsplayer-skin-default xgplayer-pause xgplayer-volume-muted
@@ -73,20 +108,24 @@ func (v *Video) IsPlayable() bool {
 
 // IsMute  是否在静音
 func (v *Video) IsMute() bool {
-	count, err := v.locator.Locator(".xgplayer-volume-muted").Count()
+	count, err := v.locator.Locator(v.site.Get("video.muted")).Count()
 	if err != nil {
 		return false
 	}
 	return count != 0
 }
 
-func (v *Video) TogglePlay() bool {
-	_ = v.videoElement.Click()
+// TogglePlay 点击视频切换播放和暂停，返回切换后是否在播放
+func (v *Video) TogglePlay(ctx context.Context) bool {
+	video := v.site.Element("video", v.videoElement)
+	_ = video.Click(ctx)
 	return v.IsPlayable()
 }
 
-func (v *Video) ToggleVolume() bool {
-	_ = v.locator.Locator(".xgplayer-volume .xgplayer-icon").Click()
+// ToggleVolume 点击音量按钮切换静音，返回切换后是否静音
+func (v *Video) ToggleVolume(ctx context.Context) bool {
+	volume := v.site.Element("volume", v.locator.Locator(v.site.Get("video.volume")))
+	_ = volume.Click(ctx)
 	return v.IsMute()
 }
 
@@ -101,32 +140,48 @@ func (v *Video) GetVideoState() (VideoState, error) {
 	return VideoState(readyState), nil
 }
 
-func (v *Video) ListenVideoState(handler func(bool2 bool)) error {
-	err := scripts.GetEventBus().Subscribe("media:video:state", func(state interface{}) {
-		if v, ok := state.(bool); ok {
-			handler(v)
+// ListenVideoState 订阅是否正在播放，自动在视频元素上添加监听，返回取消订阅的函数
+// 需要区分缓冲、结束等状态时使用 ListenState
+func (v *Video) ListenVideoState(handler func(playing bool)) (func(), error) {
+	element, ok := driver.Playwright(v.videoElement)
+	if !ok || v.mediaCapture == nil {
+		return nil, driver.ErrUnsupported
+	}
+	if err := v.mediaCapture.ListenVideoState(element); err != nil {
+		return nil, err
+	}
+	return v.mediaCapture.Subscribe(scripts.TopicVideoState, func(payload interface{}) {
+		if playing, ok := payload.(bool); ok {
+			handler(playing)
 		}
-	})
-	return err
+	}), nil
 }
 
-// ListenVideoFrame 订阅视频帧数据事件
-func (v *Video) ListenVideoFrame(handler func(VideoFrame)) error {
-	err := scripts.GetEventBus().Subscribe("media:video:frame", func(frame interface{}) {
-		if videoFrame, ok := frame.(VideoFrame); ok {
-			handler(videoFrame)
+// ListenVideoFrame 订阅这个视频的采集帧，Start 之前订阅时收到页面上所有视频的帧，返回取消订阅的函数
+func (v *Video) ListenVideoFrame(handler func(VideoFrame)) (func(), error) {
+	if v.mediaCapture == nil {
+		return nil, driver.ErrUnsupported
+	}
+	return v.mediaCapture.Subscribe(scripts.TopicVideoFrame, func(payload interface{}) {
+		if frame, ok := payload.(VideoFrame); ok && v.ownCapture(frame.Capture) {
+			handler(frame)
 		}
-	})
-	return err
+	}), nil
 }
 
-// ListenVideoAudio 订阅视频音频数据事件
-func (v *Video) ListenVideoAudio(handler func(VideoAudio)) error {
-	err := scripts.GetEventBus().Subscribe("media:video:audio", func(audio interface{}) {
-		if videoAudio, ok := audio.(VideoAudio); ok {
-			handler(videoAudio)
+// ListenVideoAudio 订阅这个视频的采集音频，返回取消订阅的函数
+func (v *Video) ListenVideoAudio(handler func(VideoAudio)) (func(), error) {
+	if v.mediaCapture == nil {
+		return nil, driver.ErrUnsupported
+	}
+	return v.mediaCapture.Subscribe(scripts.TopicVideoAudio, func(payload interface{}) {
+		if audio, ok := payload.(VideoAudio); ok && v.ownCapture(audio.Capture) {
+			handler(audio)
 		}
-	})
+	}), nil
+}
 
-	return err
+func (v *Video) ownCapture(id string) bool {
+	own := v.CaptureID()
+	return own == "" || own == id
 }
@@ -0,0 +1,90 @@
+package pacing
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Delay 随机延迟分布
+type Delay interface {
+	Sample(r *rand.Rand) time.Duration
+}
+
+// Uniform 在 [Min, Max] 内均匀分布
+type Uniform struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (u Uniform) Sample(r *rand.Rand) time.Duration {
+	if u.Max <= u.Min {
+		return u.Min
+	}
+	return u.Min + time.Duration(r.Int63n(int64(u.Max-u.Min)+1))
+}
+
+// Normal 正态分布，结果截断到 [Min, Max]
+type Normal struct {
+	Mean   time.Duration
+	StdDev time.Duration
+	Min    time.Duration
+	Max    time.Duration
+}
+
+func (n Normal) Sample(r *rand.Rand) time.Duration {
+	d := n.Mean + time.Duration(r.NormFloat64()*float64(n.StdDev))
+	return clamp(d, n.Min, n.Max)
+}
+
+// LogNormal 对数正态分布，右侧长尾更接近真人的停顿，结果截断到 [Min, Max]
+type LogNormal struct {
+	Median time.Duration
+	Sigma  float64
+	Min    time.Duration
+	Max    time.Duration
+}
+
+func (l LogNormal) Sample(r *rand.Rand) time.Duration {
+	d := time.Duration(float64(l.Median) * math.Exp(r.NormFloat64()*l.Sigma))
+	return clamp(d, l.Min, l.Max)
+}
+
+func clamp(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+// lockedRand 并发安全的随机数源
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newLockedRand(seed int64) *lockedRand {
+	return &lockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+func (l *lockedRand) sample(d Delay) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return d.Sample(l.r)
+}
+
+func (l *lockedRand) float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+func (l *lockedRand) normFloat64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.NormFloat64()
+}
@@ -0,0 +1,125 @@
+package pacing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded 当日写操作配额已用完
+var ErrQuotaExceeded = errors.New("daily quota exceeded")
+
+// TokenBucket 令牌桶限流器
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket 创建令牌桶，perMinute 为每分钟允许的次数，burst 为允许的突发次数
+func NewTokenBucket(perMinute float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   perMinute / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// reserve 取走一个令牌，返回需要等待的时间
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Allow 不等待，有令牌时取走并返回 true
+func (b *TokenBucket) Allow() bool {
+	if b.reserve() > 0 {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// Wait 等待直到拿到令牌，ctx 取消时返回错误
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	wait := b.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// DailyQuota 按自然日计数的配额，跨天自动清零
+type DailyQuota struct {
+	mu     sync.Mutex
+	limit  int
+	day    string
+	used   int
+	now    func() time.Time
+	action Action
+}
+
+func NewDailyQuota(action Action, limit int) *DailyQuota {
+	return &DailyQuota{action: action, limit: limit, now: time.Now}
+}
+
+// Use 消耗一次配额，超出时返回 ErrQuotaExceeded
+func (q *DailyQuota) Use() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	today := q.now().Format("2006-01-02")
+	if today != q.day {
+		q.day = today
+		q.used = 0
+	}
+	if q.limit > 0 && q.used >= q.limit {
+		return fmt.Errorf("%s: %w (%d/day)", q.action, ErrQuotaExceeded, q.limit)
+	}
+	q.used++
+	return nil
+}
+
+// Remaining 当日剩余次数，limit 为 0 时返回 -1 表示不限
+func (q *DailyQuota) Remaining() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.limit <= 0 {
+		return -1
+	}
+	if q.now().Format("2006-01-02") != q.day {
+		return q.limit
+	}
+	return q.limit - q.used
+}
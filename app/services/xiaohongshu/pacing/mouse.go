@@ -0,0 +1,121 @@
+package pacing
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+type point struct {
+	X float64
+	Y float64
+}
+
+// Click 模拟真人点击：按节奏停顿，沿曲线把鼠标移到元素内的随机位置，再按下抬起
+func (p *Pacer) Click(ctx context.Context, locator playwright.Locator) error {
+	return p.ClickAs(ctx, ActionClick, locator)
+}
+
+// ClickAs 以指定动作类型点击，点赞、关注等写操作会消耗每日配额
+func (p *Pacer) ClickAs(ctx context.Context, action Action, locator playwright.Locator) error {
+	if err := p.Before(ctx, action); err != nil {
+		return err
+	}
+	page, err := locator.Page()
+	if err != nil {
+		return locator.Click()
+	}
+	_ = locator.ScrollIntoViewIfNeeded()
+	box, err := locator.BoundingBox()
+	if err != nil || box == nil {
+		// 拿不到位置时退回普通点击
+		return locator.Click()
+	}
+	// 点击位置围绕中心正态分布，并保持在元素内
+	offsetX := clampFloat(box.Width/2+p.rand.normFloat64()*box.Width/6, 1, math.Max(box.Width-1, 1))
+	offsetY := clampFloat(box.Height/2+p.rand.normFloat64()*box.Height/6, 1, math.Max(box.Height-1, 1))
+	target := point{X: box.X + offsetX, Y: box.Y + offsetY}
+	if err := p.moveMouse(page, target); err != nil {
+		return fmt.Errorf("failed to move mouse: %w", err)
+	}
+	pressDelay := 40 + p.rand.float64()*80
+	return locator.Click(playwright.LocatorClickOptions{
+		Position: &playwright.Position{X: offsetX, Y: offsetY},
+		Delay:    playwright.Float(pressDelay),
+	})
+}
+
+// Scroll 带随机浮动的滚动，scroll 负责执行实际的滚动距离
+func (p *Pacer) Scroll(ctx context.Context, distance float64, scroll func(distance float64) error) error {
+	if err := p.Before(ctx, ActionScroll); err != nil {
+		return err
+	}
+	return scroll(p.JitterDistance(distance))
+}
+
+// moveMouse 沿二次贝塞尔曲线移动鼠标，控制点随机偏离直线
+func (p *Pacer) moveMouse(page playwright.Page, to point) error {
+	key := mouseKey(page)
+	p.mu.Lock()
+	from, ok := p.mouse[key]
+	steps := p.config.MouseSteps
+	p.mu.Unlock()
+	if !ok {
+		viewport := page.ViewportSize()
+		if viewport != nil {
+			from = point{X: float64(viewport.Width) * p.rand.float64(), Y: float64(viewport.Height) * p.rand.float64()}
+		}
+	}
+
+	n := steps[0]
+	if steps[1] > steps[0] {
+		n += int(p.rand.float64() * float64(steps[1]-steps[0]))
+	}
+	if n < 1 {
+		n = 1
+	}
+	dist := math.Hypot(to.X-from.X, to.Y-from.Y)
+	control := point{
+		X: (from.X+to.X)/2 + p.rand.normFloat64()*dist/5,
+		Y: (from.Y+to.Y)/2 + p.rand.normFloat64()*dist/5,
+	}
+	for i := 1; i <= n; i++ {
+		t := easeInOut(float64(i) / float64(n))
+		x := (1-t)*(1-t)*from.X + 2*(1-t)*t*control.X + t*t*to.X
+		y := (1-t)*(1-t)*from.Y + 2*(1-t)*t*control.Y + t*t*to.Y
+		if err := page.Mouse().Move(x, y); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	_, tracked := p.mouse[key]
+	p.mouse[key] = to
+	p.mu.Unlock()
+	if !tracked {
+		// 页面关闭后不再保留鼠标位置，避免后台任务的页面越积越多，地址复用时也不会沿用旧的位置
+		page.OnClose(p.Forget)
+	}
+	return nil
+}
+
+// Forget 清除页面最后的鼠标位置，页面关闭时自动调用
+func (p *Pacer) Forget(page playwright.Page) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.mouse, mouseKey(page))
+}
+
+func mouseKey(page playwright.Page) string {
+	return fmt.Sprintf("%p", page)
+}
+
+func easeInOut(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func clampFloat(v, min, max float64) float64 {
+	return math.Min(math.Max(v, min), max)
+}
@@ -0,0 +1,234 @@
+package pacing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Action 浏览器交互动作类型
+type Action string
+
+const (
+	ActionClick    Action = "click"    // 普通点击
+	ActionScroll   Action = "scroll"   // 滚动翻页
+	ActionView     Action = "view"     // 打开笔记后的阅读停顿
+	ActionNavigate Action = "navigate" // 页面跳转
+	// 以下为写操作，受每日配额限制
+	ActionLike    Action = "like"
+	ActionCollect Action = "collect"
+	ActionFollow  Action = "follow"
+	ActionComment Action = "comment"
+)
+
+// ActionConfig 单个动作的节奏配置
+type ActionConfig struct {
+	Delay      Delay   // 每次动作前的随机延迟
+	PerMinute  float64 // 每账号每分钟最多次数，0 表示不限
+	Burst      int     // 令牌桶突发容量
+	DailyQuota int     // 每账号每日最多次数，0 表示不限
+}
+
+// Config 节奏控制配置
+type Config struct {
+	Actions map[Action]ActionConfig
+	// GlobalPerMinute 所有动作合计的每账号每分钟次数
+	GlobalPerMinute float64
+	// RiskCooldown 遇到风控响应后的冷却时间
+	RiskCooldown time.Duration
+	// ScrollJitter 滚动距离的随机浮动比例，如 0.15 表示 ±15%
+	ScrollJitter float64
+	// MouseSteps 点击前鼠标移动路径的步数范围
+	MouseSteps [2]int
+}
+
+// DefaultConfig 默认节奏配置
+func DefaultConfig() Config {
+	return Config{
+		Actions: map[Action]ActionConfig{
+			ActionClick:    {Delay: LogNormal{Median: 600 * time.Millisecond, Sigma: 0.4, Min: 250 * time.Millisecond, Max: 3 * time.Second}, PerMinute: 30, Burst: 5},
+			ActionScroll:   {Delay: LogNormal{Median: 1800 * time.Millisecond, Sigma: 0.5, Min: 800 * time.Millisecond, Max: 8 * time.Second}, PerMinute: 20, Burst: 3},
+			ActionView:     {Delay: LogNormal{Median: 2500 * time.Millisecond, Sigma: 0.6, Min: time.Second, Max: 15 * time.Second}},
+			ActionNavigate: {Delay: Uniform{Min: 1500 * time.Millisecond, Max: 4 * time.Second}, PerMinute: 10, Burst: 2},
+			ActionLike:     {Delay: Normal{Mean: 2 * time.Second, StdDev: 600 * time.Millisecond, Min: time.Second, Max: 5 * time.Second}, PerMinute: 4, Burst: 1, DailyQuota: 100},
+			ActionCollect:  {Delay: Normal{Mean: 2 * time.Second, StdDev: 600 * time.Millisecond, Min: time.Second, Max: 5 * time.Second}, PerMinute: 4, Burst: 1, DailyQuota: 50},
+			ActionFollow:   {Delay: Normal{Mean: 3 * time.Second, StdDev: time.Second, Min: 1500 * time.Millisecond, Max: 8 * time.Second}, PerMinute: 2, Burst: 1, DailyQuota: 30},
+			ActionComment:  {Delay: Normal{Mean: 6 * time.Second, StdDev: 2 * time.Second, Min: 3 * time.Second, Max: 15 * time.Second}, PerMinute: 1, Burst: 1, DailyQuota: 20},
+		},
+		GlobalPerMinute: 60,
+		RiskCooldown:    30 * time.Minute,
+		ScrollJitter:    0.15,
+		MouseSteps:      [2]int{12, 30},
+	}
+}
+
+// Pacer 所有浏览器交互的节奏控制：随机延迟、按账号限流、每日配额和风控冷却
+type Pacer struct {
+	mu            sync.Mutex
+	config        Config
+	rand          *lockedRand
+	account       string
+	buckets       map[string]*TokenBucket
+	quotas        map[string]*DailyQuota
+	cooldownUntil time.Time
+	mouse         map[string]point // 每个页面最后的鼠标位置
+}
+
+// NewPacer 创建节奏控制器
+func NewPacer(config Config) *Pacer {
+	return &Pacer{
+		config:  config,
+		rand:    newLockedRand(time.Now().UnixNano()),
+		buckets: make(map[string]*TokenBucket),
+		quotas:  make(map[string]*DailyQuota),
+		mouse:   make(map[string]point),
+	}
+}
+
+// SetAccount 设置当前账号，限流和配额按账号分别计算
+func (p *Pacer) SetAccount(account string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.account = account
+}
+
+// Cooldown 进入冷却，冷却期间所有动作都会等待到冷却结束
+func (p *Pacer) Cooldown(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until := time.Now().Add(d)
+	if until.After(p.cooldownUntil) {
+		p.cooldownUntil = until
+	}
+}
+
+// ResetCooldown 把冷却结束时间重置为 d 之后，可用于缩短冷却
+func (p *Pacer) ResetCooldown(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cooldownUntil = time.Now().Add(d)
+}
+
+// CooldownOnRisk 按配置的风控冷却时间进入冷却
+func (p *Pacer) CooldownOnRisk() {
+	p.Cooldown(p.config.RiskCooldown)
+}
+
+// CooldownRemaining 剩余冷却时间
+func (p *Pacer) CooldownRemaining() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Until(p.cooldownUntil)
+}
+
+// Remaining 当前账号某个写操作当日剩余次数，不限时返回 -1
+func (p *Pacer) Remaining(action Action) int {
+	quota := p.quota(action)
+	if quota == nil {
+		return -1
+	}
+	return quota.Remaining()
+}
+
+// Before 在执行 action 之前调用：等待冷却、限流并随机停顿，最后消耗配额
+// 等待期间 ctx 被取消时不消耗配额；配额已用完时不等待直接返回错误
+func (p *Pacer) Before(ctx context.Context, action Action) error {
+	quota := p.quota(action)
+	if quota != nil && quota.Remaining() == 0 {
+		return quota.Use()
+	}
+	if err := p.waitCooldown(ctx); err != nil {
+		return err
+	}
+	if bucket := p.bucket(""); bucket != nil {
+		if err := bucket.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if bucket := p.bucket(action); bucket != nil {
+		if err := bucket.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if err := p.Sleep(ctx, action); err != nil {
+		return err
+	}
+	if quota != nil {
+		return quota.Use()
+	}
+	return nil
+}
+
+// Sleep 按 action 的延迟分布随机停顿
+func (p *Pacer) Sleep(ctx context.Context, action Action) error {
+	p.mu.Lock()
+	cfg, ok := p.config.Actions[action]
+	p.mu.Unlock()
+	if !ok || cfg.Delay == nil {
+		return nil
+	}
+	return sleepContext(ctx, p.rand.sample(cfg.Delay))
+}
+
+// JitterDistance 给滚动距离加上随机浮动
+func (p *Pacer) JitterDistance(distance float64) float64 {
+	p.mu.Lock()
+	jitter := p.config.ScrollJitter
+	p.mu.Unlock()
+	return distance * (1 + (p.rand.float64()*2-1)*jitter)
+}
+
+func (p *Pacer) waitCooldown(ctx context.Context) error {
+	return sleepContext(ctx, p.CooldownRemaining())
+}
+
+func (p *Pacer) bucket(action Action) *TokenBucket {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	perMinute, burst := p.config.GlobalPerMinute, 5
+	if action != "" {
+		cfg := p.config.Actions[action]
+		perMinute, burst = cfg.PerMinute, cfg.Burst
+	}
+	if perMinute <= 0 {
+		return nil
+	}
+	key := p.account + "/" + string(action)
+	bucket, ok := p.buckets[key]
+	if !ok {
+		bucket = NewTokenBucket(perMinute, burst)
+		p.buckets[key] = bucket
+	}
+	return bucket
+}
+
+func (p *Pacer) quota(action Action) *DailyQuota {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	limit := p.config.Actions[action].DailyQuota
+	if limit <= 0 {
+		return nil
+	}
+	key := p.account + "/" + string(action)
+	quota, ok := p.quotas[key]
+	if !ok {
+		quota = NewDailyQuota(action, limit)
+		p.quotas[key] = quota
+	}
+	return quota
+}
+
+// sleepContext 等待 d，ctx 被取消时提前返回错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package pacing
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDelaysStayInBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	delays := []Delay{
+		Uniform{Min: time.Second, Max: 2 * time.Second},
+		Normal{Mean: time.Second, StdDev: time.Second, Min: 500 * time.Millisecond, Max: 2 * time.Second},
+		LogNormal{Median: time.Second, Sigma: 1, Min: 500 * time.Millisecond, Max: 2 * time.Second},
+	}
+	for _, d := range delays {
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 1000; i++ {
+			v := d.Sample(r)
+			if v < 500*time.Millisecond || v > 2*time.Second {
+				t.Fatalf("%T sample %s out of bounds", d, v)
+			}
+			seen[v] = true
+		}
+		if len(seen) < 10 {
+			t.Fatalf("%T produced almost uniform timing: %d distinct values", d, len(seen))
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewTokenBucket(60, 2)
+	b.now = func() time.Time { return now }
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("expected burst of 2 to be allowed")
+	}
+	if b.Allow() {
+		t.Fatal("expected third call to be limited")
+	}
+	now = now.Add(time.Second)
+	if !b.Allow() {
+		t.Fatal("expected a token after one second at 60/min")
+	}
+	if wait := b.reserve(); wait != time.Second {
+		t.Fatalf("expected to wait 1s, got %s", wait)
+	}
+}
+
+func TestDailyQuotaResetsNextDay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.Local)
+	q := NewDailyQuota(ActionComment, 1)
+	q.now = func() time.Time { return now }
+	if err := q.Use(); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if err := q.Use(); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if q.Remaining() != 1 {
+		t.Fatalf("expected quota to reset, remaining %d", q.Remaining())
+	}
+}
+
+func TestPacerQuotaPerAccountAndCooldown(t *testing.T) {
+	p := NewPacer(Config{Actions: map[Action]ActionConfig{ActionLike: {DailyQuota: 1}}})
+	ctx := context.Background()
+	p.SetAccount("a1")
+	if err := p.Before(ctx, ActionLike); err != nil {
+		t.Fatalf("Before: %v", err)
+	}
+	if err := p.Before(ctx, ActionLike); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	p.SetAccount("a2")
+	if err := p.Before(ctx, ActionLike); err != nil {
+		t.Fatalf("quota should be per account: %v", err)
+	}
+
+	p.Cooldown(time.Hour)
+	cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Before(cancelled, ActionClick); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected cooldown to block until ctx deadline, got %v", err)
+	}
+	p.ResetCooldown(0)
+	p.SetAccount("a3")
+	p.Cooldown(time.Hour)
+	cancelled, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Before(cancelled, ActionLike); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected cooldown to block until ctx deadline, got %v", err)
+	}
+	if remaining := p.Remaining(ActionLike); remaining != 1 {
+		t.Fatalf("cancelled action should not use quota, remaining %d", remaining)
+	}
+}
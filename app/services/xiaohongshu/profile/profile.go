@@ -0,0 +1,118 @@
+package profile
+
+import (
+	"fmt"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/entity"
+	"xiaohongshu/app/services/xiaohongshu/explore"
+	"xiaohongshu/app/services/xiaohongshu/site"
+)
+
+// ProfileURL 用户主页地址
+const ProfileURL = "https://www.xiaohongshu.com/user/profile/%s"
+
+// ProfileInfo 用户主页信息
+type ProfileInfo struct {
+	Nickname entity.Element
+	RedId    entity.Element
+	Desc     entity.Element
+	// 关注、粉丝、获赞与收藏
+	Follows entity.Element
+	Fans    entity.Element
+	Likes   entity.Element
+	// 发布的笔记
+	Notes []explore.FeedsInfo
+}
+
+// Map 转换为 map 以便前端和任务结果使用
+func (p ProfileInfo) Map() map[string]interface{} {
+	notes := make([]map[string]interface{}, 0, len(p.Notes))
+	for _, feed := range p.Notes {
+		notes = append(notes, feed.Map())
+	}
+	return map[string]interface{}{
+		"nickname": p.Nickname.Text,
+		"redId":    p.RedId.Text,
+		"desc":     p.Desc.Text,
+		"follows":  p.Follows.Text,
+		"fans":     p.Fans.Text,
+		"likes":    p.Likes.Text,
+		"notes":    notes,
+	}
+}
+
+// ProfileDetail 用户主页的提取结果，随 profile:refreshed 发布
+type ProfileDetail struct {
+	UserID      string    `json:"userId"`
+	Nickname    string    `json:"nickname"`
+	RedID       string    `json:"redId"`
+	Desc        string    `json:"desc"`
+	Follows     string    `json:"follows"`
+	Fans        string    `json:"fans"`
+	Likes       string    `json:"likes"`
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// Detail 转换为可以发布和序列化的主页信息，不包括笔记列表
+func (p ProfileInfo) Detail(userID string) ProfileDetail {
+	return ProfileDetail{
+		UserID:      userID,
+		Nickname:    p.Nickname.Text,
+		RedID:       p.RedId.Text,
+		Desc:        p.Desc.Text,
+		Follows:     p.Follows.Text,
+		Fans:        p.Fans.Text,
+		Likes:       p.Likes.Text,
+		RefreshedAt: time.Now(),
+	}
+}
+
+// Profile 用户主页
+type Profile struct {
+	site    *site.Site
+	page    driver.Page
+	locator driver.Locator
+}
+
+func NewProfile(s *site.Site, page driver.Page) *Profile {
+	return &Profile{
+		site:    s,
+		page:    page,
+		locator: page.Locator(s.Get("profile.container")),
+	}
+}
+
+// Open 打开指定用户的主页，需要浏览器驱动
+func (p *Profile) Open(userId string) error {
+	page, ok := driver.PlaywrightPage(p.page)
+	if !ok {
+		return driver.ErrUnsupported
+	}
+	_, err := page.Goto(fmt.Sprintf(ProfileURL, userId))
+	if err != nil {
+		return err
+	}
+	return driver.WaitFor(p.locator.Locator(p.site.Get("profile.info")), 10*time.Second)
+}
+
+func (p *Profile) Show() (ProfileInfo, error) {
+	var info ProfileInfo
+	info.Nickname = p.site.BuildElement(p.locator, "profile.name")
+	if info.Nickname.Selector == nil {
+		return info, fmt.Errorf("failed to find user name")
+	}
+	info.RedId = p.site.BuildElement(p.locator, "profile.redId")
+	info.Desc = p.site.BuildElement(p.locator, "profile.desc")
+	info.Follows = p.site.BuildElement(p.locator, "profile.follows")
+	info.Fans = p.site.BuildElement(p.locator, "profile.fans")
+	info.Likes = p.site.BuildElement(p.locator, "profile.likes")
+	p.site.Observe("profile", p.locator)
+
+	notes, err := explore.NewExploreWithLocator(p.site, p.page.Locator(p.site.Get("profile.feeds"))).Show()
+	if err != nil {
+		return info, err
+	}
+	info.Notes = notes
+	return info, nil
+}
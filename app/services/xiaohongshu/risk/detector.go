@@ -0,0 +1,289 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+
+	"github.com/asaskevich/EventBus"
+	"github.com/playwright-community/playwright-go"
+)
+
+// 风控类型
+const (
+	KindCaptcha     = "captcha"      // 滑块验证码
+	KindLoginWall   = "login-wall"   // 登录墙/登录过期
+	KindRateLimited = "rate-limited" // 访问频繁
+	KindAPI         = "api"          // 接口返回风控错误码
+)
+
+// Challenge 一次风控事件
+type Challenge struct {
+	Account    string    `json:"account"`
+	Kind       string    `json:"kind"`
+	Reason     string    `json:"reason"`
+	URL        string    `json:"url"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// RiskCodes 接口返回的风控错误码
+var RiskCodes = map[int]string{
+	300011: "账号存在异常",
+	300012: "网络/IP存在风险",
+	300013: "访问频次异常",
+	300015: "浏览器环境异常",
+	-100:   "登录已过期",
+}
+
+// ResolvedCooldown 风控解除后继续保持的冷却时间
+var ResolvedCooldown = 2 * time.Minute
+
+// RiskStatuses 需要验证码时接口返回的HTTP状态码
+var RiskStatuses = map[int]bool{461: true, 471: true}
+
+// challengePaths 风控时页面被重定向到的路径
+var challengePaths = map[string]string{
+	"/website-login/captcha": KindCaptcha,
+	"/website-login/verify":  KindCaptcha,
+	"/website-login/error":   KindRateLimited,
+	"/login":                 KindLoginWall,
+}
+
+// markers 表示风控的页面元素，值为选择器名称，选择器随注册表更新
+var markers = []struct {
+	Kind string
+	Key  string
+}{
+	{KindCaptcha, "risk.captcha"},
+	{KindLoginWall, "risk.loginWall"},
+	{KindRateLimited, "risk.rateLimited"},
+}
+
+// markerScript 按顺序检查页面上的风控元素，返回 {kind, reason} 或 null
+const markerScript = `(markers) => {
+	for (const m of markers) {
+		try {
+			if (m.selector && document.querySelector(m.selector)) return {kind: m.kind, reason: m.selector};
+		} catch (e) {}
+	}
+	return null;
+}`
+
+// Detector 监听浏览器上下文中所有页面的跳转、DOM 标记和接口错误码，发现风控后暂停操作并等待人工处理
+type Detector struct {
+	context    playwright.BrowserContext
+	registry   *selectors.Registry
+	bus        EventBus.Bus
+	pacer      *pacing.Pacer
+	mu         sync.Mutex
+	account    string
+	challenge  *Challenge
+	lastOK     time.Time
+	suppressed int32
+	stop       chan struct{}
+	stopOnce   sync.Once
+	Interval   time.Duration // DOM 检查间隔
+}
+
+// NewDetector 创建风控检测器，风控标记的选择器从 registry 读取，
+// 风控状态变化在 bus 上发布，发现风控时 pacer 进入冷却
+func NewDetector(context playwright.BrowserContext, registry *selectors.Registry, bus EventBus.Bus, pacer *pacing.Pacer) *Detector {
+	return &Detector{
+		context:  context,
+		registry: registry,
+		bus:      bus,
+		pacer:    pacer,
+		stop:     make(chan struct{}),
+		Interval: 3 * time.Second,
+	}
+}
+
+// Start 开始定时检查上下文中打开的页面
+func (d *Detector) Start() {
+	go func() {
+		ticker := time.NewTicker(d.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.check()
+			}
+		}
+	}()
+}
+
+// Watch 监听页面的跳转和加载，页面创建后、开始导航前调用
+func (d *Detector) Watch(page playwright.Page) {
+	page.OnFrameNavigated(func(frame playwright.Frame) {
+		if frame.ParentFrame() != nil {
+			return
+		}
+		if kind, ok := matchChallengePath(frame.URL()); ok {
+			go d.report(page, kind, "redirected to challenge page", frame.URL())
+		}
+	})
+	page.OnLoad(func(page playwright.Page) {
+		go d.check()
+	})
+}
+
+// Stop 停止检查
+func (d *Detector) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
+
+// SetAccount 设置当前账号
+func (d *Detector) SetAccount(account string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.account = account
+}
+
+// Challenge 返回当前未解决的风控，没有时为 nil
+func (d *Detector) Challenge() *Challenge {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.challenge
+}
+
+// Suppress 暂时忽略登录墙等标记（例如主动打开登录弹窗时），返回恢复函数
+func (d *Detector) Suppress() func() {
+	atomic.AddInt32(&d.suppressed, 1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&d.suppressed, -1)
+		})
+	}
+}
+
+// OnResponse 检查接口响应中的风控状态码和错误码
+func (d *Detector) OnResponse(response playwright.Response) {
+	if !strings.Contains(response.URL(), "/api/sns/") {
+		return
+	}
+	page := responsePage(response)
+	if RiskStatuses[response.Status()] {
+		d.report(page, KindCaptcha, fmt.Sprintf("http status %d", response.Status()), response.URL())
+		return
+	}
+	body, err := response.Body()
+	if err != nil {
+		return
+	}
+	var result struct {
+		Code int `json:"code"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return
+	}
+	if reason, ok := RiskCodes[result.Code]; ok {
+		kind := KindAPI
+		if result.Code == -100 {
+			kind = KindLoginWall
+		}
+		d.report(page, kind, reason, response.URL())
+		return
+	}
+	if result.Code == 0 {
+		d.mu.Lock()
+		d.lastOK = time.Now()
+		d.mu.Unlock()
+	}
+}
+
+// check 检查所有页面的 DOM 标记；已处于风控状态时判断是否已被人工解决
+func (d *Detector) check() {
+	args := make([]map[string]string, 0, len(markers))
+	for _, marker := range markers {
+		args = append(args, map[string]string{"kind": marker.Kind, "selector": d.registry.Get(marker.Key)})
+	}
+	for _, page := range d.context.Pages() {
+		result, err := page.Evaluate(markerScript, args)
+		if err != nil {
+			continue
+		}
+		if marker, _ := result.(map[string]interface{}); marker != nil {
+			kind, _ := marker["kind"].(string)
+			reason, _ := marker["reason"].(string)
+			d.report(page, kind, reason, page.URL())
+			return
+		}
+		if _, ok := matchChallengePath(page.URL()); ok {
+			return
+		}
+	}
+
+	d.mu.Lock()
+	challenge := d.challenge
+	// 所有页面恢复正常，且风控之后接口有成功响应，才认为已被人工解决
+	resolved := challenge != nil && d.lastOK.After(challenge.DetectedAt)
+	if resolved {
+		d.challenge = nil
+	}
+	d.mu.Unlock()
+	if resolved {
+		d.pacer.ResetCooldown(ResolvedCooldown)
+		d.bus.Publish(eventbus.TopicRiskResolved, *challenge)
+	}
+}
+
+// report 记录风控，同一时间只上报一次；page 为出现风控的页面，可能为 nil
+func (d *Detector) report(page playwright.Page, kind, reason, url string) {
+	if kind == KindLoginWall && atomic.LoadInt32(&d.suppressed) > 0 {
+		return
+	}
+	d.mu.Lock()
+	if d.challenge != nil {
+		d.mu.Unlock()
+		return
+	}
+	challenge := Challenge{
+		Account:    d.account,
+		Kind:       kind,
+		Reason:     reason,
+		URL:        url,
+		DetectedAt: time.Now(),
+	}
+	d.challenge = &challenge
+	d.mu.Unlock()
+
+	d.pacer.CooldownOnRisk()
+	// 把出现风控的页面切到前台，方便人工处理
+	if page != nil {
+		_ = page.BringToFront()
+	}
+	d.bus.Publish(eventbus.TopicRiskChallenged, challenge)
+}
+
+// responsePage 发出请求的页面，Service Worker 等发出的请求返回 nil
+func responsePage(response playwright.Response) playwright.Page {
+	frame := response.Frame()
+	if frame == nil {
+		return nil
+	}
+	return frame.Page()
+}
+
+// matchChallengePath 判断地址是否为风控页面
+func matchChallengePath(rawURL string) (string, bool) {
+	if !strings.Contains(rawURL, "xiaohongshu.com") {
+		return "", false
+	}
+	for path, kind := range challengePaths {
+		if strings.Contains(rawURL, "xiaohongshu.com"+path) {
+			return kind, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,232 @@
+package scripts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// domWatchBinding 页面中通知 Go 端的函数名，每个页面只暴露一次
+const domWatchBinding = "__domWatchEmit"
+
+// HandleAttribute 元素首次匹配时写入的句柄属性
+const HandleAttribute = "data-dom-watch"
+
+// DOMEventType DOM 事件类型
+type DOMEventType string
+
+const (
+	DOMAdded     DOMEventType = "added"     // 元素出现或开始匹配选择器
+	DOMRemoved   DOMEventType = "removed"   // 元素被移除或不再匹配选择器
+	DOMAttribute DOMEventType = "attribute" // 监听的属性发生变化
+)
+
+// WatchSpec 一个监听的条件
+type WatchSpec struct {
+	Selector   string   `json:"selector"`   // CSS 选择器
+	Attributes []string `json:"attributes"` // 需要通知变化的属性，如 class、aria-pressed
+}
+
+// DOMEvent 页面中匹配元素的变化
+type DOMEvent struct {
+	WatchID   string            `json:"watchId"`
+	Type      DOMEventType      `json:"type"`
+	Selector  string            `json:"selector"`
+	Handle    string            `json:"handle"`    // 元素的句柄，添加和移除事件中相同，可通过 Locator 定位
+	HTML      string            `json:"html"`      // outerHTML 的前 512 个字符
+	Data      map[string]string `json:"data"`      // data-* 属性，键不含 data- 前缀
+	Initial   bool              `json:"initial"`   // 开始监听时已存在的元素
+	Attribute string            `json:"attribute"` // 以下三项仅属性变化时有值
+	OldValue  string            `json:"oldValue"`
+	Value     string            `json:"value"`
+}
+
+type domWatch struct {
+	spec WatchSpec
+	fn   func(DOMEvent)
+}
+
+// DOMWatcher 按 CSS 选择器监听页面元素的添加、移除和属性变化
+// 一个页面只创建一个 DOMWatcher，可以同时有任意多个监听，页面导航后自动重新监听
+type DOMWatcher struct {
+	page    playwright.Page
+	mu      sync.Mutex
+	watches map[string]*domWatch
+	nextID  int
+	started bool
+	closed  bool
+	events  chan DOMEvent // 按页面中的发生顺序分发给各个监听
+}
+
+// NewDOMWatcher 创建 page 的 DOM 监听服务，需调用 Start 后才能监听
+func NewDOMWatcher(page playwright.Page) *DOMWatcher {
+	return &DOMWatcher{
+		page:    page,
+		watches: make(map[string]*domWatch),
+		events:  make(chan DOMEvent, 256),
+	}
+}
+
+// Start 注入 dom_watch.js 并暴露回调函数，之后每次页面导航都会重新监听
+func (w *DOMWatcher) Start(script string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started {
+		return nil
+	}
+	if err := w.page.AddInitScript(playwright.Script{Content: &script}); err != nil {
+		return fmt.Errorf("注入监听脚本失败: %w", err)
+	}
+	// 当前页面已加载时，初始化脚本要到下次导航才执行
+	if _, err := w.page.Evaluate(script); err != nil {
+		return fmt.Errorf("执行监听脚本失败: %w", err)
+	}
+	err := w.page.ExposeFunction(domWatchBinding, func(args ...interface{}) interface{} {
+		if len(args) > 0 {
+			w.receive(args[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("暴露回调函数失败: %w", err)
+	}
+	w.started = true
+	go w.dispatch()
+	w.page.On("domcontentloaded", func() {
+		// 页面事件的回调中不能调用 Evaluate
+		go w.rearm()
+	})
+	return nil
+}
+
+func (w *DOMWatcher) receive(raw interface{}) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return
+	}
+	var event DOMEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		logger.Warn("invalid dom event", "error", err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.events <- event:
+	default:
+		logger.Warn("dom event dropped", "watch", event.WatchID, "type", event.Type)
+	}
+}
+
+func (w *DOMWatcher) dispatch() {
+	for event := range w.events {
+		w.mu.Lock()
+		watch := w.watches[event.WatchID]
+		w.mu.Unlock()
+		// 已取消的监听可能仍有排队的事件
+		if watch != nil {
+			watch.fn(event)
+		}
+	}
+}
+
+// rearm 页面导航后在新文档中重新创建所有监听
+func (w *DOMWatcher) rearm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	for id, watch := range w.watches {
+		if err := w.arm(id, watch.spec); err != nil {
+			logger.Warn("failed to rearm dom watch", "watch", id, "selector", watch.spec.Selector, "error", err)
+		}
+	}
+}
+
+func (w *DOMWatcher) arm(id string, spec WatchSpec) error {
+	if spec.Attributes == nil {
+		spec.Attributes = []string{}
+	}
+	_, err := w.page.Evaluate(`([id, spec]) => window.__DOMWatch.watch(id, spec)`, []interface{}{id, spec})
+	return err
+}
+
+// Watch 监听匹配 spec.Selector 的元素，fn 在独立的 goroutine 中按发生顺序调用，返回监听 id
+// 开始监听时已存在的元素会立即收到 Initial 为 true 的添加事件
+func (w *DOMWatcher) Watch(spec WatchSpec, fn func(DOMEvent)) (string, error) {
+	if spec.Selector == "" {
+		return "", fmt.Errorf("选择器不能为空")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started || w.closed {
+		return "", fmt.Errorf("DOM 监听服务未启动")
+	}
+	w.nextID++
+	id := "watch-" + strconv.Itoa(w.nextID)
+	// 先登记，初始元素的事件才能分发到 fn
+	w.watches[id] = &domWatch{spec: spec, fn: fn}
+	if err := w.arm(id, spec); err != nil {
+		delete(w.watches, id)
+		return "", fmt.Errorf("创建监听失败: %w", err)
+	}
+	return id, nil
+}
+
+// Unwatch 取消一个监听
+func (w *DOMWatcher) Unwatch(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.watches[id]; !ok {
+		return fmt.Errorf("未找到监听: %s", id)
+	}
+	delete(w.watches, id)
+	if _, err := w.page.Evaluate(`(id) => window.__DOMWatch?.unwatch(id)`, id); err != nil {
+		return fmt.Errorf("取消监听失败: %w", err)
+	}
+	return nil
+}
+
+// Watches 当前所有监听的 id
+func (w *DOMWatcher) Watches() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ids := make([]string, 0, len(w.watches))
+	for id := range w.watches {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Locator 按事件中的句柄定位元素，元素已移除时 Locator 匹配不到元素
+func (w *DOMWatcher) Locator(handle string) playwright.Locator {
+	return w.page.Locator(fmt.Sprintf(`[%s=%q]`, HandleAttribute, handle))
+}
+
+// Close 取消所有监听并停止分发事件，之后不能再使用
+func (w *DOMWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.watches = make(map[string]*domWatch)
+	if w.started {
+		close(w.events)
+	}
+	if w.page.IsClosed() {
+		return nil
+	}
+	if _, err := w.page.Evaluate(`() => window.__DOMWatch?.unwatchAll()`); err != nil {
+		return fmt.Errorf("取消所有监听失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/spf13/cast"
+)
+
+// frameTimeout 页面中等待当前帧可以绘制的时间
+const frameTimeout = 5 * time.Second
+
+// CaptureFrame 把视频元素当前显示的帧绘制到 canvas 并以 JPEG 返回，不依赖 Start 启动的连续采集
+// maxWidth 大于 0 时按比例缩小到不超过该宽度，视频跨域且没有 CORS 时页面无法读取像素，返回错误
+func (mc *MediaCapture) CaptureFrame(element playwright.Locator, maxWidth int) (VideoFrame, error) {
+	result, err := element.Evaluate(`(video, [maxWidth, timeout]) => new Promise((resolve, reject) => {
+		const draw = () => {
+			const w = video.videoWidth, h = video.videoHeight;
+			if (!w || !h) {
+				reject(new Error('video has no frame'));
+				return;
+			}
+			const scale = maxWidth > 0 && w > maxWidth ? maxWidth / w : 1;
+			const canvas = document.createElement('canvas');
+			canvas.width = Math.max(1, Math.round(w * scale));
+			canvas.height = Math.max(1, Math.round(h * scale));
+			canvas.getContext('2d').drawImage(video, 0, 0, canvas.width, canvas.height);
+			resolve({
+				width: canvas.width,
+				height: canvas.height,
+				data: canvas.toDataURL('image/jpeg', 0.9).split(',')[1],
+				ts: video.currentTime,
+				format: 'image/jpeg',
+			});
+		};
+		if (video.readyState >= 2 && !video.seeking) {
+			draw();
+			return;
+		}
+		const ready = () => { cleanup(); draw(); };
+		const timer = setTimeout(() => { cleanup(); reject(new Error('frame timeout')); }, timeout);
+		const cleanup = () => {
+			clearTimeout(timer);
+			video.removeEventListener('seeked', ready);
+			video.removeEventListener('loadeddata', ready);
+		};
+		video.addEventListener('seeked', ready);
+		video.addEventListener('loadeddata', ready);
+	})`, []interface{}{maxWidth, frameTimeout.Milliseconds()})
+	if err != nil {
+		return VideoFrame{}, fmt.Errorf("截取视频帧失败: %w", err)
+	}
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return VideoFrame{}, fmt.Errorf("截取视频帧失败: 无效的返回值")
+	}
+	return VideoFrame{
+		Width:  cast.ToInt64(data["width"]),
+		Height: cast.ToInt64(data["height"]),
+		Data:   data["data"],
+		Ts:     cast.ToFloat64(data["ts"]),
+		Format: cast.ToString(data["format"]),
+	}, nil
+}
+
+// DecodeFrame 解码采集到的 base64 图片帧
+func DecodeFrame(frame VideoFrame) (image.Image, error) {
+	encoded, ok := frame.Data.(string)
+	if !ok || encoded == "" {
+		return nil, fmt.Errorf("视频帧没有图片数据")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("视频帧不是有效的 base64: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("解码视频帧失败: %w", err)
+	}
+	return img, nil
+}
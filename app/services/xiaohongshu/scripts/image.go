@@ -0,0 +1,63 @@
+package scripts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/spf13/cast"
+)
+
+// transcodeMaxSize 浏览器转码时缩小到的最大边长，感知哈希只需要很小的图片
+const transcodeMaxSize = 512
+
+// LoadImage 使用页面的浏览器上下文下载图片（带登录状态和 Referer）并解码
+// Go 标准库不支持的格式（如 webp）交给浏览器解码后转成 PNG
+func LoadImage(page playwright.Page, url string) (image.Image, error) {
+	if page == nil || page.IsClosed() {
+		return nil, fmt.Errorf("page is not available")
+	}
+	response, err := page.Request().Get(url, playwright.APIRequestContextGetOptions{
+		Headers: map[string]string{"Referer": page.URL()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("下载图片失败: %w", err)
+	}
+	defer response.Dispose()
+	if !response.Ok() {
+		return nil, fmt.Errorf("下载图片失败: HTTP %d", response.Status())
+	}
+	body, err := response.Body()
+	if err != nil {
+		return nil, fmt.Errorf("读取图片失败: %w", err)
+	}
+	if img, _, err := image.Decode(bytes.NewReader(body)); err == nil {
+		return img, nil
+	}
+	return transcode(page, body)
+}
+
+// transcode 在浏览器中解码图片并转成 PNG
+func transcode(page playwright.Page, data []byte) (image.Image, error) {
+	result, err := page.Evaluate(`async ([encoded, maxSize]) => {
+		const bytes = Uint8Array.from(atob(encoded), (c) => c.charCodeAt(0));
+		const bitmap = await createImageBitmap(new Blob([bytes]));
+		const scale = Math.min(1, maxSize / Math.max(bitmap.width, bitmap.height));
+		const canvas = new OffscreenCanvas(Math.max(1, Math.round(bitmap.width * scale)), Math.max(1, Math.round(bitmap.height * scale)));
+		canvas.getContext('2d').drawImage(bitmap, 0, 0, canvas.width, canvas.height);
+		bitmap.close();
+		const blob = await canvas.convertToBlob({ type: 'image/png' });
+		const buffer = new Uint8Array(await blob.arrayBuffer());
+		let binary = '';
+		for (let i = 0; i < buffer.length; i += 0x8000) {
+			binary += String.fromCharCode(...buffer.subarray(i, i + 0x8000));
+		}
+		return btoa(binary);
+	}`, []interface{}{base64.StdEncoding.EncodeToString(data), transcodeMaxSize})
+	if err != nil {
+		return nil, fmt.Errorf("浏览器解码图片失败: %w", err)
+	}
+	return DecodeFrame(VideoFrame{Data: cast.ToString(result), Format: "image/png"})
+}
@@ -2,21 +2,41 @@ package scripts
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"xiaohongshu/app/infra/logging"
 
 	"github.com/playwright-community/playwright-go"
 	"github.com/spf13/cast"
+
+	"github.com/asaskevich/EventBus"
+)
+
+var logger = logging.Component("media")
+
+// MediaCapture 事件总线上的主题
+const (
+	TopicVideoState    = "media:video:state"    // 是否正在播放，载荷为 bool
+	TopicVideoFrame    = "media:video:frame"    // 采集到的视频帧，载荷为 VideoFrame
+	TopicVideoAudio    = "media:video:audio"    // 采集到的音频，载荷为 VideoAudio
+	TopicVideoPlayback = "media:video:playback" // 视频播放状态变化，载荷为 PlaybackEvent
 )
 
 // VideoFrame 视频帧数据结构
 type VideoFrame struct {
-	Width  int64       `json:"width"`
-	Height int64       `json:"height"`
-	Data   interface{} `json:"data"`
-	Ts     float64     `json:"ts"`
+	Capture string      `json:"capture"` // 采集 id，CaptureFrame 截取的单帧为空
+	Width   int64       `json:"width"`
+	Height  int64       `json:"height"`
+	Data    interface{} `json:"data"`
+	Ts      float64     `json:"ts"`
+	Format  string      `json:"format"` // 图片格式，如 image/jpeg，Data 为 base64 编码
 }
 
 // VideoAudio 视频音频数据结构
 type VideoAudio struct {
+	Capture    string      `json:"capture"` // 采集 id
 	SampleRate int64       `json:"sampleRate"`
 	Channels   int64       `json:"channels"`
 	Frames     int64       `json:"frames"`
@@ -24,134 +44,412 @@ type VideoAudio struct {
 	Ts         float64     `json:"ts"`
 }
 
+// PlaybackEvent 视频元素的播放状态变化
+type PlaybackEvent struct {
+	Video string  `json:"video"` // WatchPlayback 返回的视频 id
+	State string  `json:"state"` // playing、paused、waiting、ended、error
+	Time  float64 `json:"time"`  // 发生时的播放位置，单位秒
+	Error string  `json:"error"`
+}
+
+// CaptureStatus 一个采集的运行状态和计数
+type CaptureStatus struct {
+	ID            string        `json:"id"`
+	Running       bool          `json:"running"`
+	StartedAt     time.Time     `json:"startedAt"`
+	Active        time.Duration `json:"active"`        // 累计采集时长，不含停止的时间
+	Frames        int64         `json:"frames"`        // 收到的视频帧数
+	DroppedFrames int64         `json:"droppedFrames"` // 页面中没能截取或发送的帧数
+	AudioChunks   int64         `json:"audioChunks"`   // 收到的音频块数
+	FPS           float64       `json:"fps"`           // 实际达到的帧率
+	Error         string        `json:"error"`         // 页面中最近一次截帧错误，或采集已失效的原因
+}
+
+// capture Go 端记录的一个采集
+type capture struct {
+	id        string
+	startedAt time.Time
+	running   bool
+	since     time.Time     // 最近一次开始或继续采集的时间
+	active    time.Duration // 之前各段采集的累计时长
+	frames    int64
+	audio     int64
+	dropped   int64 // 页面最近一次报告的丢帧数
+	err       string
+}
+
+func (c *capture) status(now time.Time) CaptureStatus {
+	active := c.active
+	if c.running {
+		active += now.Sub(c.since)
+	}
+	status := CaptureStatus{
+		ID:            c.id,
+		Running:       c.running,
+		StartedAt:     c.startedAt,
+		Active:        active,
+		Frames:        c.frames,
+		DroppedFrames: c.dropped,
+		AudioChunks:   c.audio,
+		Error:         c.err,
+	}
+	if active > 0 {
+		status.FPS = float64(c.frames) / active.Seconds()
+	}
+	return status
+}
+
+func (c *capture) pause(now time.Time) {
+	if c.running {
+		c.active += now.Sub(c.since)
+		c.running = false
+	}
+}
+
 // MediaCapture 媒体捕获类
+// 每个视频元素的采集有独立的 id，Go 端记录所有采集及其收到的帧和音频，按 id 停止、继续和销毁
 type MediaCapture struct {
-	page   playwright.Page
-	frames []VideoFrame
+	page         playwright.Page
+	events       EventBus.Bus // 采集到的视频状态、帧和音频，每个页面独立
+	mu           sync.Mutex
+	captures     map[string]*capture
+	nextID       int
+	listeners    map[int]listener // Subscribe 添加的订阅
+	nextListener int
+	closed       bool // Close 之后不再发布事件
+}
+
+type listener struct {
+	topic string
+	fn    func(interface{})
 }
 
 // NewMediaCapture 创建新的媒体捕获实例
 func NewMediaCapture(page playwright.Page) *MediaCapture {
 	return &MediaCapture{
-		page: page,
+		page:      page,
+		events:    EventBus.New(),
+		captures:  make(map[string]*capture),
+		listeners: make(map[int]listener),
+	}
+}
+
+// Events 发布 media:video:state、media:video:frame、media:video:audio、media:video:playback 的事件总线
+func (mc *MediaCapture) Events() EventBus.Bus {
+	return mc.events
+}
+
+// Subscribe 订阅 Events 上的 topic，返回取消订阅的函数
+// EventBus 按函数的代码地址取消订阅，同一处创建的多个闭包无法区分，需要分别取消时使用 Subscribe
+func (mc *MediaCapture) Subscribe(topic string, fn func(payload interface{})) func() {
+	mc.mu.Lock()
+	mc.nextListener++
+	id := mc.nextListener
+	mc.listeners[id] = listener{topic: topic, fn: fn}
+	mc.mu.Unlock()
+	return func() {
+		mc.mu.Lock()
+		delete(mc.listeners, id)
+		mc.mu.Unlock()
+	}
+}
+
+// publish 发布到 Events 和 Subscribe 添加的订阅，Close 之后到达的回调直接丢弃
+func (mc *MediaCapture) publish(topic string, payload interface{}) {
+	mc.mu.Lock()
+	if mc.closed {
+		mc.mu.Unlock()
+		return
+	}
+	mc.mu.Unlock()
+	mc.events.Publish(topic, payload)
+	mc.mu.Lock()
+	fns := make([]func(interface{}), 0, len(mc.listeners))
+	for _, l := range mc.listeners {
+		if l.topic == topic {
+			fns = append(fns, l.fn)
+		}
+	}
+	mc.mu.Unlock()
+	for _, fn := range fns {
+		fn(payload)
 	}
 }
 
-// Start 启动媒体捕获
-func (mc *MediaCapture) Start(element playwright.Locator) error {
+// Start 开始采集视频元素的画面和音频，返回采集 id
+// 元素已在采集时返回原来的 id，已停止时继续采集
+func (mc *MediaCapture) Start(element playwright.Locator) (string, error) {
 	// 首先检查MediaCaptureController是否存在
-	exists, err := element.Evaluate(`(videoEl) => {
-		return window.__MediaCaptureController !== undefined;
-	}`, nil)
+	exists, err := element.Evaluate(`() => window.__MediaCaptureController !== undefined`, nil)
 	if err != nil {
-		return fmt.Errorf("检查MediaCaptureController失败: %w", err)
+		return "", fmt.Errorf("检查MediaCaptureController失败: %w", err)
+	}
+	if ok, _ := exists.(bool); !ok {
+		return "", fmt.Errorf("MediaCaptureController未定义，请确保脚本已正确注入")
 	}
 
-	if !exists.(bool) {
-		return fmt.Errorf("MediaCaptureController未定义，请确保脚本已正确注入")
+	mc.mu.Lock()
+	mc.nextID++
+	id := "capture-" + strconv.Itoa(mc.nextID)
+	mc.mu.Unlock()
+	result, err := element.Evaluate(`(video, id) => window.__MediaCaptureController.start(video, id)`, id)
+	if err != nil {
+		return "", fmt.Errorf("启动采集失败: %w", err)
 	}
+	id = cast.ToString(result)
 
-	_, err = element.EvaluateHandle(`(videoEl) => {
-		window.__MediaCaptureController.start(videoEl);
-	}`, nil)
+	now := time.Now()
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	c, ok := mc.captures[id]
+	if !ok {
+		c = &capture{id: id, startedAt: now}
+		mc.captures[id] = c
+	}
+	if !c.running {
+		c.running = true
+		c.since = now
+	}
+	logger.Info("capture started", "capture", id)
+	return id, nil
+}
+
+// Resume 继续已停止的采集
+func (mc *MediaCapture) Resume(id string) error {
+	if err := mc.call("resume", id); err != nil {
+		return err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if c, ok := mc.captures[id]; ok && !c.running {
+		c.running = true
+		c.since = time.Now()
+	}
+	return nil
+}
+
+// Stop 停止采集，保留音频上下文和计数，可以通过 Resume 继续
+func (mc *MediaCapture) Stop(id string) error {
+	if err := mc.call("stop", id); err != nil {
+		return err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if c, ok := mc.captures[id]; ok {
+		c.pause(time.Now())
+	}
+	return nil
+}
+
+// Destroy 停止采集并释放页面中的音频上下文和画布，之后 id 不再可用
+func (mc *MediaCapture) Destroy(id string) error {
+	err := mc.call("destroy", id)
+	mc.mu.Lock()
+	delete(mc.captures, id)
+	mc.mu.Unlock()
 	return err
 }
 
-// Stop 停止媒体捕获
-func (mc *MediaCapture) Stop(element playwright.Locator) error {
-	_, err := element.EvaluateHandle(`(element) => {
-		window.__MediaCaptureController.stop(element);
-	}`, nil)
+// call 调用页面中采集控制器的 method，采集不存在时返回错误
+func (mc *MediaCapture) call(method string, id string) error {
+	result, err := mc.page.Evaluate(`([method, id]) => window.__MediaCaptureController?.[method](id) ?? false`, []interface{}{method, id})
+	if err != nil {
+		return fmt.Errorf("%s 采集 %s 失败: %w", method, id, err)
+	}
+	if ok, _ := result.(bool); !ok {
+		return fmt.Errorf("未找到采集: %s", id)
+	}
+	return nil
+}
+
+// StopAll 停止所有采集
+func (mc *MediaCapture) StopAll() error {
+	_, err := mc.page.Evaluate(`() => window.__MediaCaptureController?.stopAll()`)
+	now := time.Now()
+	mc.mu.Lock()
+	for _, c := range mc.captures {
+		c.pause(now)
+	}
+	mc.mu.Unlock()
 	return err
 }
 
-// Destroy 销毁媒体捕获
-func (mc *MediaCapture) Destroy(element playwright.Locator) error {
-	_, err := element.EvaluateHandle(`(element) => {
-window.__MediaCaptureController.destroy(element);
-	}`, nil)
+// DestroyAll 销毁页面上所有的采集，停止 requestAnimationFrame 截帧和音频处理
+func (mc *MediaCapture) DestroyAll() error {
+	mc.reset()
+	_, err := mc.page.Evaluate(`() => window.__MediaCaptureController?.destroyAll()`)
 	return err
 }
 
-// StopAll 停止所有媒体捕获
-func (mc *MediaCapture) StopAll(element playwright.Locator) error {
+// Shutdown 笔记关闭或会话结束时调用，销毁所有采集，页面已关闭时只清除记录
+func (mc *MediaCapture) Shutdown() error {
+	if mc.page.IsClosed() {
+		mc.reset()
+		return nil
+	}
+	return mc.DestroyAll()
+}
 
-	_, err := element.EvaluateHandle(`(element) => {
-window.__MediaCaptureController.stopAll();
-	}`, nil)
+// Close 会话结束时调用：销毁所有采集并移除 Subscribe 添加的订阅，之后页面回调的事件不再发布
+// 重新打开会话时创建新的 MediaCapture，避免旧会话的订阅收到重复的事件
+func (mc *MediaCapture) Close() error {
+	err := mc.Shutdown()
+	mc.mu.Lock()
+	mc.closed = true
+	mc.listeners = make(map[int]listener)
+	mc.mu.Unlock()
 	return err
 }
 
-// DestroyAll 销毁所有媒体捕获
-func (mc *MediaCapture) DestroyAll(element playwright.Locator) error {
+func (mc *MediaCapture) reset() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if len(mc.captures) > 0 {
+		logger.Info("captures cleared", "count", len(mc.captures))
+	}
+	mc.captures = make(map[string]*capture)
+}
 
-	_, err := element.EvaluateHandle(`(element) => {
-window.__MediaCaptureController.destroyAll(element);
-	}`, nil)
-	return err
+// Status 查询采集的状态，丢帧数和截帧错误从页面中读取
+func (mc *MediaCapture) Status(id string) (CaptureStatus, error) {
+	mc.mu.Lock()
+	_, ok := mc.captures[id]
+	mc.mu.Unlock()
+	if !ok {
+		return CaptureStatus{}, fmt.Errorf("未找到采集: %s", id)
+	}
+	mc.refresh(id)
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	c, ok := mc.captures[id]
+	if !ok {
+		return CaptureStatus{}, fmt.Errorf("未找到采集: %s", id)
+	}
+	return c.status(time.Now()), nil
 }
 
-// ListenVideoState  监听视频播放状态变化
-func (mc *MediaCapture) ListenVideoState(element playwright.Locator) error {
+// Captures 所有采集的状态，按开始时间排列
+func (mc *MediaCapture) Captures() []CaptureStatus {
+	mc.mu.Lock()
+	ids := make([]string, 0, len(mc.captures))
+	for id := range mc.captures {
+		ids = append(ids, id)
+	}
+	mc.mu.Unlock()
+	statuses := make([]CaptureStatus, 0, len(ids))
+	for _, id := range ids {
+		if status, err := mc.Status(id); err == nil {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].StartedAt.Before(statuses[j].StartedAt) })
+	return statuses
+}
 
-	_, err := element.EvaluateHandle(`
-		(video) => {
-			// 确保只添加一次事件监听器
-			if (video.__listening) return;
-			video.__listening = true;
-			
-			const notifyState = () => {
-				const isPlaying = !video.paused && !video.ended && video.readyState > 2;
-				window.__onVideoStateChange?.(isPlaying);
-			};
-			
-			// 监听相关的事件
-			video.addEventListener('play', notifyState);
-			video.addEventListener('pause', notifyState);
-			video.addEventListener('ended', notifyState);
-			video.addEventListener('loadstart', notifyState);
-			video.addEventListener('canplay', notifyState);
-			video.addEventListener('canplaythrough', notifyState);
-			video.addEventListener('waiting', notifyState);
-			
-			// 初始状态通知
-			notifyState();
+// refresh 从页面中读取丢帧数和错误，页面中已没有该采集（例如页面刷新）时标记为失效
+func (mc *MediaCapture) refresh(id string) {
+	if mc.page.IsClosed() {
+		return
+	}
+	result, err := mc.page.Evaluate(`(id) => window.__MediaCaptureController?.status(id) ?? null`, id)
+	if err != nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	c, ok := mc.captures[id]
+	if !ok {
+		return
+	}
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		c.pause(time.Now())
+		c.err = "页面中的采集已失效"
+		return
+	}
+	c.dropped = cast.ToInt64(data["dropped"])
+	if msg := cast.ToString(data["error"]); msg != "" {
+		c.err = msg
+	}
+	if !cast.ToBool(data["running"]) {
+		c.pause(time.Now())
+	}
+}
+
+// ListenVideoState 为视频元素添加是否正在播放的监听，结果发布到 TopicVideoState，同一个元素只添加一次
+func (mc *MediaCapture) ListenVideoState(element playwright.Locator) error {
+	_, err := element.Evaluate(`(video) => {
+		if (video.__stateListener) return;
+		const notifyState = () => {
+			const isPlaying = !video.paused && !video.ended && video.readyState > 2;
+			window.__onVideoStateChange?.(isPlaying);
+		};
+		video.__stateListener = notifyState;
+		for (const type of ['play', 'pause', 'ended', 'loadstart', 'canplay', 'canplaythrough', 'waiting']) {
+			video.addEventListener(type, notifyState);
 		}
-	`, nil)
+		// 初始状态通知
+		notifyState();
+	}`, nil)
 	return err
 }
 
-// RemoveVideoStateListener 移除视频状态监听
+// RemoveVideoStateListener 移除 ListenVideoState 添加的监听
 func (mc *MediaCapture) RemoveVideoStateListener(element playwright.Locator) error {
-	page, err := element.Page()
-	if err != nil {
-		return err
-	}
-	// 移除JavaScript中的事件监听器
-	_, err = element.Evaluate(`
-		(video) => {
-			if (!video.__listening) return;
-			video.__listening = false;
-			
-			const notifyState = () => {
-				const isPlaying = !video.paused && !video.ended && video.readyState > 2;
-				window.__onVideoStateChange?.(isPlaying);
+	// 移除时需要使用添加时的同一个函数
+	_, err := element.Evaluate(`(video) => {
+		const notifyState = video.__stateListener;
+		if (!notifyState) return;
+		for (const type of ['play', 'pause', 'ended', 'loadstart', 'canplay', 'canplaythrough', 'waiting']) {
+			video.removeEventListener(type, notifyState);
+		}
+		delete video.__stateListener;
+	}`, nil)
+	return err
+}
+
+// WatchPlayback 为视频元素添加播放状态监听，状态变化以 PlaybackEvent 发布到 TopicVideoPlayback
+// 同一个元素只添加一次，返回用于区分事件来源的视频 id
+func (mc *MediaCapture) WatchPlayback(element playwright.Locator) (string, error) {
+	result, err := element.Evaluate(`(video) => {
+		if (!video.__playbackId) {
+			video.__playbackId = 'v' + Date.now().toString(36) + Math.random().toString(36).slice(2, 8);
+			const notify = (state) => window.__onVideoPlayback?.({
+				video: video.__playbackId,
+				state,
+				time: video.currentTime,
+				error: video.error ? (video.error.message || 'media error ' + video.error.code) : '',
+			});
+			video.__playbackHandlers = {
+				playing: () => notify('playing'),
+				pause: () => notify('paused'),
+				waiting: () => notify('waiting'),
+				ended: () => notify('ended'),
+				error: () => notify('error'),
 			};
-			
-			video.removeEventListener('play', notifyState);
-			video.removeEventListener('pause', notifyState);
-			video.removeEventListener('ended', notifyState);
-			video.removeEventListener('loadstart', notifyState);
-			video.removeEventListener('canplay', notifyState);
-			video.removeEventListener('canplaythrough', notifyState);
-			video.removeEventListener('waiting', notifyState);
+			for (const [type, handler] of Object.entries(video.__playbackHandlers)) {
+				video.addEventListener(type, handler);
+			}
 		}
-	`, nil)
+		return video.__playbackId;
+	}`, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
-	// 移除暴露的函数
-	_, err = page.Evaluate("delete window.__onVideoStateChange", nil)
+	id, _ := result.(string)
+	return id, nil
+}
+
+// UnwatchPlayback 移除 WatchPlayback 添加的监听，之后再次添加会得到新的视频 id
+func (mc *MediaCapture) UnwatchPlayback(element playwright.Locator) error {
+	_, err := element.Evaluate(`(video) => {
+		if (!video.__playbackHandlers) return;
+		for (const [type, handler] of Object.entries(video.__playbackHandlers)) {
+			video.removeEventListener(type, handler);
+		}
+		delete video.__playbackHandlers;
+		delete video.__playbackId;
+	}`, nil)
 	return err
 }
 
@@ -162,7 +460,23 @@ func (mc *MediaCapture) InjectScript(scriptContent string) error {
 	err = mc.page.ExposeFunction("__onVideoStateChange", func(args ...interface{}) interface{} {
 		if len(args) > 0 {
 			if isPlaying, ok := args[0].(bool); ok {
-				GetEventBus().Publish("media:video:state", isPlaying)
+				mc.publish(TopicVideoState, isPlaying)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = mc.page.ExposeFunction("__onVideoPlayback", func(args ...interface{}) interface{} {
+		if len(args) > 0 {
+			if data, ok := args[0].(map[string]interface{}); ok {
+				mc.publish(TopicVideoPlayback, PlaybackEvent{
+					Video: cast.ToString(data["video"]),
+					State: cast.ToString(data["state"]),
+					Time:  cast.ToFloat64(data["time"]),
+					Error: cast.ToString(data["error"]),
+				})
 			}
 		}
 		return nil
@@ -170,21 +484,20 @@ func (mc *MediaCapture) InjectScript(scriptContent string) error {
 	if err != nil {
 		return err
 	}
-	// 首先获取页面实例
 	err = mc.page.ExposeFunction("__onVideoFrame", func(args ...interface{}) interface{} {
 		if len(args) > 0 {
 			if data, ok := args[0].(map[string]interface{}); ok {
 				frame := VideoFrame{
-					Width:  cast.ToInt64(data["width"]),
-					Height: cast.ToInt64(data["height"]),
-					Data:   data["data"],
-					Ts:     cast.ToFloat64(data["ts"]),
+					Capture: cast.ToString(data["capture"]),
+					Width:   cast.ToInt64(data["width"]),
+					Height:  cast.ToInt64(data["height"]),
+					Data:    data["data"],
+					Ts:      cast.ToFloat64(data["ts"]),
+					Format:  cast.ToString(data["format"]),
+				}
+				if mc.received(frame.Capture, true) {
+					mc.publish(TopicVideoFrame, frame)
 				}
-				fmt.Println(frame)
-				fmt.Println(data["data"])
-				// 通过事件总线发送视频帧数据
-				fmt.Println("media:video:frame", GetEventBus().HasCallback("media:video:frame"))
-				//GetEventBus().Publish("media:video:frame", frame)
 			}
 		}
 		return nil
@@ -192,20 +505,20 @@ func (mc *MediaCapture) InjectScript(scriptContent string) error {
 	if err != nil {
 		return err
 	}
-	// 暴露__onVideoAudio回调函数到浏览器环境
 	err = mc.page.ExposeFunction("__onVideoAudio", func(args ...interface{}) interface{} {
 		if len(args) > 0 {
 			if data, ok := args[0].(map[string]interface{}); ok {
 				audio := VideoAudio{
+					Capture:    cast.ToString(data["capture"]),
 					SampleRate: cast.ToInt64(data["sampleRate"]),
 					Channels:   cast.ToInt64(data["channels"]),
 					Frames:     cast.ToInt64(data["frames"]),
 					Buffer:     data["buffer"],
 					Ts:         cast.ToFloat64(data["ts"]),
 				}
-				fmt.Println(fmt.Sprintf("__onVideoAudio: %+v", audio))
-				// 通过事件总线发送音频数据
-				GetEventBus().Publish("media:video:audio", audio)
+				if mc.received(audio.Capture, false) {
+					mc.publish(TopicVideoAudio, audio)
+				}
 			}
 		}
 		return nil
@@ -213,8 +526,28 @@ func (mc *MediaCapture) InjectScript(scriptContent string) error {
 	if err != nil {
 		return err
 	}
+	// 新文档中没有之前的采集
+	mc.page.On("domcontentloaded", func() {
+		mc.reset()
+	})
 	err = mc.page.AddInitScript(playwright.Script{
 		Content: &scriptContent,
 	})
 	return err
 }
+
+// received 记录收到的帧或音频，已销毁的采集仍在发送途中的数据返回 false
+func (mc *MediaCapture) received(id string, frame bool) bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	c, ok := mc.captures[id]
+	if !ok {
+		return false
+	}
+	if frame {
+		c.frames++
+	} else {
+		c.audio++
+	}
+	return true
+}
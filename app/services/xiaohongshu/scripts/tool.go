@@ -2,6 +2,8 @@ package scripts
 
 import (
 	"encoding/json"
+	"errors"
+	"xiaohongshu/app/services/xiaohongshu/driver"
 
 	"github.com/playwright-community/playwright-go"
 )
@@ -158,17 +160,13 @@ window.getElementInfo=getElementInfo;
 
 `
 
-func GetElementInfo(selector playwright.Locator) (ElementInfo, error) {
+func GetElementInfo(selector driver.Locator) (ElementInfo, error) {
 	var element ElementInfo
 	// 获取元素的信息并转化为结构体
-	evaluate, err := selector.EvaluateHandle("(element) => {let info= getElementInfo(element); console.log(info);return info;}", nil)
+	value, err := selector.Evaluate("(element) => {let info= getElementInfo(element); console.log(info);return info;}", nil)
 	if err != nil {
 		return element, err
 	}
-	value, err := evaluate.JSONValue()
-	if err != nil {
-		return ElementInfo{}, err
-	}
 	elementInfoJSON, err := json.Marshal(value)
 	if err != nil {
 		return element, err
@@ -178,21 +176,22 @@ func GetElementInfo(selector playwright.Locator) (ElementInfo, error) {
 
 }
 
-func GetElementIsVisible(selector playwright.Locator) bool {
-	isVisible, err := selector.EvaluateHandle("(element) => { const info = getElementInfo(element); return info.element.isPartiallyVisible;}", nil)
-	if err != nil {
-		return false
+func GetElementIsVisible(selector driver.Locator) bool {
+	value, err := selector.Evaluate("(element) => { const info = getElementInfo(element); return info.element.isPartiallyVisible;}", nil)
+	if errors.Is(err, driver.ErrUnsupported) {
+		// 无法执行脚本（内存驱动）时没有布局信息，视为可见
+		return true
 	}
-	value, err := isVisible.JSONValue()
-	if err != nil || !value.(bool) {
+	if err != nil {
 		return false
 	}
-	return true
+	isVisible, _ := value.(bool)
+	return isVisible
 }
 
 // SmoothScrollTo 在浏览器中对指定元素执行平滑滚动操作
-func SmoothScrollTo(element playwright.Locator, distance float64) error {
-	_, err := element.EvaluateHandle(`(element,distance) => {
+func SmoothScrollTo(element driver.Locator, distance float64) error {
+	_, err := element.Evaluate(`(element,distance) => {
 		window.smoothScrollTo(element, distance);
 	}`, distance)
 	return err
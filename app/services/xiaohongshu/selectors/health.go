@@ -0,0 +1,138 @@
+package selectors
+
+import (
+	"strings"
+	"time"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+)
+
+// Page 健康检查中的一类页面
+type Page struct {
+	Type      string
+	Container string // 容器的选择器名称
+	// Required 必须能在容器内找到的选择器，嵌套的元素用 / 分隔，
+	// 如 explore.feed/explore.cover 表示在第一个 explore.feed 内查找 explore.cover
+	Required []string
+}
+
+// Pages 健康检查覆盖的页面
+var Pages = map[string]Page{
+	"explore": {
+		Type:      "explore",
+		Container: "explore.container",
+		Required: []string{
+			"explore.feed",
+			"explore.feed/explore.link",
+			"explore.feed/explore.cover",
+			"explore.feed/explore.title",
+			"explore.feed/explore.author",
+			"explore.feed/explore.author/explore.avatar",
+			"explore.feed/explore.likes",
+		},
+	},
+	"channel": {
+		Type:      "explore",
+		Container: "channel.container",
+		Required:  []string{"channel.item"},
+	},
+	"search": {
+		Type:      "search",
+		Container: "search.container",
+		Required: []string{
+			"explore.feed",
+			"explore.feed/explore.cover",
+			"explore.feed/explore.title",
+		},
+	},
+	"note": {
+		Type:      "note",
+		Container: "note.container",
+		Required: []string{
+			"note.author",
+			"note.like",
+			"note.collect",
+			"note.desc",
+			"note.date",
+			"note.commentCount",
+		},
+	},
+}
+
+// Report 单个页面的检查结果
+type Report struct {
+	Page      string    `json:"page"`
+	URL       string    `json:"url"`
+	Version   string    `json:"version"`
+	Resolved  []string  `json:"resolved"`
+	Missing   []string  `json:"missing"`
+	Snapshot  string    `json:"snapshot,omitempty"` // 有选择器失效时保存容器的 DOM 快照
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Healthy 所有必需的选择器都能找到
+func (r Report) Healthy() bool {
+	return r.Error == "" && len(r.Missing) == 0
+}
+
+// Alert 检查失败时生成的告警，检查通过时返回 nil
+func (r Report) Alert(pageType string) *Alert {
+	if r.Healthy() {
+		return nil
+	}
+	total := len(r.Resolved) + len(r.Missing)
+	return &Alert{
+		PageType: pageType,
+		HitRate:  rate(len(r.Resolved), len(r.Missing)),
+		Samples:  total,
+		Failing:  r.Missing,
+		Snapshot: r.Snapshot,
+		Version:  r.Version,
+		RaisedAt: r.CheckedAt,
+	}
+}
+
+// Check 检查页面上所有必需的选择器，结果同时计入统计
+func (m *Metrics) Check(page driver.Page, name string) Report {
+	spec := Pages[name]
+	report := Report{Page: name, Version: m.registry.Info().Version, CheckedAt: time.Now()}
+	container := page.Locator(m.registry.Get(spec.Container))
+	if count, err := container.Count(); err != nil || count == 0 {
+		m.Record(spec.Container, false)
+		report.Missing = append([]string{spec.Container}, spec.Required...)
+		report.Snapshot = Snapshot(page.Locator("body"))
+		return report
+	}
+	m.Record(spec.Container, true)
+	container = container.First()
+	for _, path := range spec.Required {
+		if m.resolve(container, path) {
+			report.Resolved = append(report.Resolved, path)
+		} else {
+			report.Missing = append(report.Missing, path)
+		}
+	}
+	if len(report.Missing) > 0 {
+		report.Snapshot = Snapshot(container)
+	}
+	return report
+}
+
+// resolve 逐级查找嵌套的选择器，中间层取第一个匹配的元素
+func (m *Metrics) resolve(container driver.Locator, path string) bool {
+	locator := container
+	keys := strings.Split(path, "/")
+	for i, key := range keys {
+		locator = locator.Locator(m.registry.Get(key))
+		count, err := locator.Count()
+		hit := err == nil && count > 0
+		m.Record(key, hit)
+		if !hit {
+			return false
+		}
+		if i < len(keys)-1 {
+			locator = locator.First()
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package selectors
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+
+	"github.com/asaskevich/EventBus"
+)
+
+// maxSnapshot DOM 快照的最大长度，超出部分截断
+const maxSnapshot = 64 * 1024
+
+// pageTypes 选择器名称前缀对应的页面类型，未列出的前缀即为页面类型
+var pageTypes = map[string]string{
+	"channel": "explore",
+	"comment": "note",
+	"video":   "note",
+}
+
+// PageType 选择器所属的页面类型，如 comment.author 属于 note
+func PageType(key string) string {
+	prefix := key
+	if i := strings.IndexByte(key, '.'); i >= 0 {
+		prefix = key[:i]
+	}
+	if pageType, ok := pageTypes[prefix]; ok {
+		return pageType
+	}
+	return prefix
+}
+
+// Stat 单个选择器的命中统计
+type Stat struct {
+	Key      string    `json:"key"`
+	PageType string    `json:"pageType"`
+	Hits     int       `json:"hits"`
+	Misses   int       `json:"misses"`
+	LastMiss time.Time `json:"lastMiss"`
+	// 上次检查以来的计数，用于计算近期命中率
+	windowHits   int
+	windowMisses int
+}
+
+// HitRate 累计命中率，没有记录时为 1
+func (s Stat) HitRate() float64 {
+	return rate(s.Hits, s.Misses)
+}
+
+func rate(hits, misses int) float64 {
+	if hits+misses == 0 {
+		return 1
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Alert 选择器失效告警
+type Alert struct {
+	PageType string    `json:"pageType"`
+	HitRate  float64   `json:"hitRate"`
+	Samples  int       `json:"samples"`
+	Failing  []string  `json:"failing"`  // 命中率低于阈值的选择器名称
+	Snapshot string    `json:"snapshot"` // 容器的 DOM 快照
+	Version  string    `json:"version"`  // 当时生效的选择器版本
+	RaisedAt time.Time `json:"raisedAt"`
+}
+
+// Metrics 按页面类型统计选择器命中情况，命中率过低时发布告警
+type Metrics struct {
+	registry *Registry
+	mu       sync.Mutex
+	stats    map[string]*Stat
+	// 同一页面类型两次告警的最短间隔
+	lastAlert map[string]time.Time
+	bus       EventBus.Bus // 发布 TopicSelectorsAlert，为 nil 时不发布
+
+	Threshold     float64       // 近期命中率低于该值时告警
+	MinSamples    int           // 近期样本数达到该值才计算命中率
+	AlertInterval time.Duration // 同一页面类型两次告警的最短间隔
+}
+
+// NewMetrics 创建命中统计，registry 用于判断可选选择器和记录告警时的版本
+func NewMetrics(registry *Registry) *Metrics {
+	return &Metrics{
+		registry:      registry,
+		stats:         make(map[string]*Stat),
+		lastAlert:     make(map[string]time.Time),
+		Threshold:     0.8,
+		MinSamples:    10,
+		AlertInterval: 10 * time.Minute,
+	}
+}
+
+// SetBus 设置发布 TopicSelectorsAlert 的事件总线
+func (m *Metrics) SetBus(bus EventBus.Bus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bus = bus
+}
+
+// Registry 统计使用的选择器注册表
+func (m *Metrics) Registry() *Registry {
+	return m.registry
+}
+
+// Record 记录一次按名称查找元素的结果
+func (m *Metrics) Record(key string, hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stat, ok := m.stats[key]
+	if !ok {
+		stat = &Stat{Key: key, PageType: PageType(key)}
+		m.stats[key] = stat
+	}
+	if hit {
+		stat.Hits++
+		stat.windowHits++
+	} else {
+		stat.Misses++
+		stat.windowMisses++
+		stat.LastMiss = time.Now()
+	}
+}
+
+// Stats 所有选择器的累计统计，按名称排序
+func (m *Metrics) Stats() []Stat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]Stat, 0, len(m.stats))
+	for _, stat := range m.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// Observe 在一次抓取结束后调用，近期命中率低于阈值时发布 TopicSelectorsAlert 并返回告警
+// container 为抓取的容器元素，用于保存 DOM 快照，可以为 nil
+func (m *Metrics) Observe(pageType string, container driver.Locator) *Alert {
+	m.mu.Lock()
+	hits, misses := 0, 0
+	registry := m.registry
+	for _, stat := range m.stats {
+		if stat.PageType == pageType && !registry.Optional(stat.Key) {
+			hits += stat.windowHits
+			misses += stat.windowMisses
+		}
+	}
+	if hits+misses < m.MinSamples {
+		m.mu.Unlock()
+		return nil
+	}
+	var failing []string
+	for _, stat := range m.stats {
+		if stat.PageType != pageType {
+			continue
+		}
+		if !registry.Optional(stat.Key) && rate(stat.windowHits, stat.windowMisses) < m.Threshold {
+			failing = append(failing, stat.Key)
+		}
+		stat.windowHits, stat.windowMisses = 0, 0
+	}
+	hitRate := rate(hits, misses)
+	now := time.Now()
+	if hitRate >= m.Threshold || now.Sub(m.lastAlert[pageType]) < m.AlertInterval {
+		m.mu.Unlock()
+		return nil
+	}
+	m.lastAlert[pageType] = now
+	bus := m.bus
+	m.mu.Unlock()
+
+	sort.Strings(failing)
+	logger.Warn("selector hit rate dropped", "pageType", pageType, "hitRate", hitRate, "samples", hits+misses, "failing", failing)
+	alert := &Alert{
+		PageType: pageType,
+		HitRate:  hitRate,
+		Samples:  hits + misses,
+		Failing:  failing,
+		Snapshot: Snapshot(container),
+		Version:  registry.Info().Version,
+		RaisedAt: now,
+	}
+	if bus != nil {
+		bus.Publish(eventbus.TopicSelectorsAlert, *alert)
+	}
+	return alert
+}
+
+// Snapshot 容器的 DOM 快照，过长时截断
+func Snapshot(container driver.Locator) string {
+	if container == nil {
+		return ""
+	}
+	snapshot, err := driver.OuterHTML(container)
+	if err != nil {
+		return ""
+	}
+	return truncate(snapshot, maxSnapshot)
+}
+
+// truncate 截断到最多 n 个字节，退回到字符边界，不会截断半个汉字
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
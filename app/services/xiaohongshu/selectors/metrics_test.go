@@ -0,0 +1,104 @@
+package selectors
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+)
+
+const brokenExplore = `<html><body>
+<div id="exploreFeeds">
+  <section data-index="0">
+    <a class="cover" href="/explore/abc"><img src="/static/cover0.png"></a>
+    <div class="footer"><span class="renamed-title">标题</span></div>
+  </section>
+</div>
+</body></html>`
+
+func TestCheckReportsMissingSelectors(t *testing.T) {
+	doc, err := driver.ParseHTML(brokenExplore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := NewMetrics(MustNewRegistry()).Check(doc, "explore")
+	if report.Healthy() {
+		t.Fatalf("expected unhealthy report: %+v", report)
+	}
+	for _, path := range []string{"explore.feed/explore.title", "explore.feed/explore.author"} {
+		if !containsKey(report.Missing, path) {
+			t.Errorf("expected %s in missing %v", path, report.Missing)
+		}
+	}
+	if !containsKey(report.Resolved, "explore.feed/explore.cover") {
+		t.Errorf("expected explore.feed/explore.cover to resolve, resolved %v", report.Resolved)
+	}
+	if !strings.Contains(report.Snapshot, "renamed-title") {
+		t.Errorf("expected container snapshot, got %q", report.Snapshot)
+	}
+	alert := report.Alert("explore")
+	if alert == nil || alert.HitRate >= 1 || len(alert.Failing) != len(report.Missing) {
+		t.Fatalf("unexpected alert: %+v", alert)
+	}
+}
+
+func TestMetricsObserveAlertsBelowThreshold(t *testing.T) {
+	m := NewMetrics(MustNewRegistry())
+	m.MinSamples = 4
+	doc, err := driver.ParseHTML(brokenExplore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := doc.Locator(m.Registry().Get("explore.container"))
+
+	m.Record("explore.cover", true)
+	m.Record("explore.cover", true)
+	if alert := m.Observe("explore", container); alert != nil {
+		t.Fatalf("expected no alert below MinSamples, got %+v", alert)
+	}
+	m.Record("explore.title", false)
+	m.Record("explore.title", false)
+	// 可选选择器不计入命中率
+	m.Record("note.follow", false)
+	alert := m.Observe("explore", container)
+	if alert == nil {
+		t.Fatalf("expected alert")
+	}
+	if alert.HitRate != 0.5 || len(alert.Failing) != 1 || alert.Failing[0] != "explore.title" {
+		t.Fatalf("unexpected alert: %+v", alert)
+	}
+	if !strings.Contains(alert.Snapshot, `id="exploreFeeds"`) {
+		t.Fatalf("expected snapshot of container, got %q", alert.Snapshot)
+	}
+
+	// 告警后重新计数，且在间隔内不重复告警
+	for i := 0; i < 4; i++ {
+		m.Record("explore.title", false)
+	}
+	if alert := m.Observe("explore", container); alert != nil {
+		t.Fatalf("expected no repeated alert within interval, got %+v", alert)
+	}
+	stats := m.Stats()
+	if len(stats) != 3 || stats[1].Key != "explore.title" || stats[1].Misses != 6 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func containsKey(items []string, want string) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	s := "a" + strings.Repeat("笔记", 10)
+	for n := 0; n <= len(s); n++ {
+		got := truncate(s, n)
+		if len(got) > n || !utf8.ValidString(got) || !strings.HasPrefix(s, got) {
+			t.Fatalf("truncate(%d) = %q", n, got)
+		}
+	}
+}
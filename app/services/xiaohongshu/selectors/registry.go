@@ -0,0 +1,292 @@
+// Package selectors 页面 CSS 选择器注册表。
+// 默认选择器内嵌在 selectors.json 中，可以被缓存目录下的同名文件覆盖，
+// 覆盖文件修改后自动重新加载，站点改版时无需重新编译。
+package selectors
+
+import (
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
+
+	"github.com/asaskevich/EventBus"
+)
+
+// OverrideFile 缓存目录下覆盖文件的文件名
+const OverrideFile = "selectors.json"
+
+var logger = logging.Component("selectors")
+
+//go:embed selectors.json
+var defaultSelectors []byte
+
+// File 选择器文件格式
+type File struct {
+	Version string `json:"version"`
+	// Optional 页面上可能不存在的选择器（如没有子评论），找不到时不影响命中率
+	Optional  []string          `json:"optional,omitempty"`
+	Selectors map[string]string `json:"selectors"`
+}
+
+// Info 当前生效的选择器版本信息
+type Info struct {
+	Version  string    `json:"version"`  // 覆盖文件的版本，没有覆盖时为内置版本
+	Builtin  string    `json:"builtin"`  // 内置版本
+	Source   string    `json:"source"`   // 覆盖文件路径，未使用时为空
+	Checksum string    `json:"checksum"` // 生效选择器的摘要，用于区分同版本的不同内容
+	LoadedAt time.Time `json:"loadedAt"`
+}
+
+// Registry 选择器注册表
+type Registry struct {
+	mu        sync.RWMutex
+	builtin   File
+	selectors map[string]string
+	optional  map[string]bool
+	info      Info
+	path      string
+	modTime   time.Time
+	missing   map[string]bool
+	stop      chan struct{}
+	bus       EventBus.Bus // 重新加载后发布 TopicSelectorsReloaded，为 nil 时不发布
+}
+
+// NewRegistry 从内置的默认选择器创建注册表
+func NewRegistry() (*Registry, error) {
+	var builtin File
+	if err := json.Unmarshal(defaultSelectors, &builtin); err != nil {
+		return nil, fmt.Errorf("invalid builtin selectors: %w", err)
+	}
+	r := &Registry{builtin: builtin, missing: make(map[string]bool)}
+	r.apply(File{}, "")
+	return r, nil
+}
+
+// MustNewRegistry 同 NewRegistry，内置选择器无效时 panic
+func MustNewRegistry() *Registry {
+	r, err := NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// SetBus 设置发布 TopicSelectorsReloaded 的事件总线
+func (r *Registry) SetBus(bus EventBus.Bus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bus = bus
+}
+
+// Get 按名称获取选择器，未注册的名称返回空字符串并记录一次日志
+func (r *Registry) Get(key string) string {
+	r.mu.RLock()
+	selector, ok := r.selectors[key]
+	r.mu.RUnlock()
+	if !ok {
+		r.mu.Lock()
+		if !r.missing[key] {
+			r.missing[key] = true
+			logger.Warn("unknown selector key", "key", key)
+		}
+		r.mu.Unlock()
+	}
+	return selector
+}
+
+// Optional 选择器是否为可选，可选的选择器找不到时不计入命中率
+func (r *Registry) Optional(key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.optional[key]
+}
+
+// Keys 所有已注册的名称，按字母排序
+func (r *Registry) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]string, 0, len(r.selectors))
+	for key := range r.selectors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// All 返回当前生效的全部选择器
+func (r *Registry) All() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make(map[string]string, len(r.selectors))
+	for key, selector := range r.selectors {
+		all[key] = selector
+	}
+	return all
+}
+
+// Info 当前版本信息
+func (r *Registry) Info() Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.info
+}
+
+// LoadFile 加载覆盖文件，只覆盖文件中出现的名称；文件不存在时使用内置选择器
+func (r *Registry) LoadFile(path string) error {
+	r.mu.Lock()
+	r.path = path
+	r.mu.Unlock()
+	return r.reload()
+}
+
+// Watch 定时检查覆盖文件，修改后重新加载
+func (r *Registry) Watch(interval time.Duration) {
+	r.mu.Lock()
+	if r.stop != nil {
+		r.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	r.stop = stop
+	r.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if r.changed() {
+					if err := r.reload(); err != nil {
+						logger.Error("failed to reload selectors", "error", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// StopWatch 停止检查覆盖文件
+func (r *Registry) StopWatch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+// changed 覆盖文件的修改时间是否变化（包括被删除）
+func (r *Registry) changed() bool {
+	r.mu.RLock()
+	path, modTime := r.path, r.modTime
+	r.mu.RUnlock()
+	if path == "" {
+		return false
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return !modTime.IsZero()
+	}
+	return !stat.ModTime().Equal(modTime)
+}
+
+func (r *Registry) reload() error {
+	r.mu.RLock()
+	path := r.path
+	r.mu.RUnlock()
+
+	var override File
+	var modTime time.Time
+	stat, err := os.Stat(path)
+	switch {
+	case path == "" || os.IsNotExist(err):
+		path = ""
+	case err != nil:
+		return err
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &override); err != nil {
+			// 保留之前的选择器，避免写了一半的文件导致抓取全部失败
+			r.mu.Lock()
+			r.modTime = stat.ModTime()
+			r.mu.Unlock()
+			return fmt.Errorf("invalid selectors file %s: %w", path, err)
+		}
+		modTime = stat.ModTime()
+	}
+
+	r.mu.Lock()
+	r.modTime = modTime
+	r.mu.Unlock()
+	r.apply(override, path)
+	info := r.Info()
+	logger.Info("selectors loaded", "version", info.Version, "source", info.Source, "checksum", info.Checksum)
+	r.mu.RLock()
+	bus := r.bus
+	r.mu.RUnlock()
+	if bus != nil {
+		bus.Publish(eventbus.TopicSelectorsReloaded, info)
+	}
+	return nil
+}
+
+// apply 合并内置选择器和覆盖选择器
+func (r *Registry) apply(override File, source string) {
+	merged := make(map[string]string, len(r.builtin.Selectors))
+	for key, selector := range r.builtin.Selectors {
+		merged[key] = selector
+	}
+	for key, selector := range override.Selectors {
+		merged[key] = selector
+	}
+	optional := make(map[string]bool)
+	list := r.builtin.Optional
+	if override.Optional != nil {
+		list = override.Optional
+	}
+	for _, key := range list {
+		optional[key] = true
+	}
+	version := r.builtin.Version
+	if override.Version != "" {
+		version = override.Version
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.selectors = merged
+	r.optional = optional
+	r.info = Info{
+		Version:  version,
+		Builtin:  r.builtin.Version,
+		Source:   source,
+		Checksum: checksum(merged),
+		LoadedAt: time.Now(),
+	}
+}
+
+func checksum(selectors map[string]string) string {
+	keys := make([]string, 0, len(selectors))
+	for key := range selectors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha1.New()
+	for _, key := range keys {
+		hash.Write([]byte(key + "=" + selectors[key] + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
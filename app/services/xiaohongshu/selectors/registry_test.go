@@ -0,0 +1,76 @@
+package selectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistryOverrideAndReload(t *testing.T) {
+	r, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	builtin := r.Get("note.title")
+	if builtin == "" {
+		t.Fatalf("expected builtin selector for note.title")
+	}
+
+	path := filepath.Join(t.TempDir(), OverrideFile)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile without file: %v", err)
+	}
+	if info := r.Info(); info.Source != "" || info.Version != info.Builtin {
+		t.Fatalf("unexpected info without override: %+v", info)
+	}
+
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(`{"version":"custom-1","selectors":{"note.title":".new-title"}}`, now)
+	if !r.changed() {
+		t.Fatalf("expected override file to be detected")
+	}
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := r.Get("note.title"); got != ".new-title" {
+		t.Fatalf("note.title = %q, want override", got)
+	}
+	if got := r.Get("comment.author"); got == "" {
+		t.Fatalf("expected builtin selector to remain for comment.author")
+	}
+	if info := r.Info(); info.Version != "custom-1" || info.Source != path {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	// 格式错误的文件保留之前的选择器
+	write(`{"version":`, now.Add(time.Second))
+	if err := r.reload(); err == nil {
+		t.Fatalf("expected error for invalid file")
+	}
+	if got := r.Get("note.title"); got != ".new-title" {
+		t.Fatalf("note.title = %q after invalid file, want previous override", got)
+	}
+
+	// 删除覆盖文件后恢复内置选择器
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if !r.changed() {
+		t.Fatalf("expected removal to be detected")
+	}
+	if err := r.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := r.Get("note.title"); got != builtin {
+		t.Fatalf("note.title = %q, want builtin %q", got, builtin)
+	}
+}
@@ -0,0 +1,66 @@
+// Package site 抓取逻辑共用的依赖：选择器注册表、选择器命中统计和节奏控制器。
+// 每个容器持有自己的一份，多个账号或测试用例之间互不影响。
+package site
+
+import (
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/entity"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+)
+
+// Site 页面操作共用的依赖
+type Site struct {
+	Selectors *selectors.Registry
+	Metrics   *selectors.Metrics
+	Pacer     *pacing.Pacer
+}
+
+// New 使用给定的选择器注册表、命中统计和节奏控制器
+func New(registry *selectors.Registry, metrics *selectors.Metrics, pacer *pacing.Pacer) *Site {
+	return &Site{Selectors: registry, Metrics: metrics, Pacer: pacer}
+}
+
+// NewDefault 使用内置选择器和默认节奏配置创建一份独立的依赖
+func NewDefault() *Site {
+	registry := selectors.MustNewRegistry()
+	return New(registry, selectors.NewMetrics(registry), pacing.NewPacer(pacing.DefaultConfig()))
+}
+
+// Get 按名称获取选择器
+func (s *Site) Get(key string) string {
+	return s.Selectors.Get(key)
+}
+
+// Record 记录一次按名称查找元素的结果
+func (s *Site) Record(key string, hit bool) {
+	s.Metrics.Record(key, hit)
+}
+
+// Observe 一次抓取结束后检查该页面类型的近期命中率
+func (s *Site) Observe(pageType string, container driver.Locator) *selectors.Alert {
+	return s.Metrics.Observe(pageType, container)
+}
+
+// Element 创建经过节奏控制点击的元素
+func (s *Site) Element(text string, locator driver.Locator) entity.Element {
+	return entity.Element{Text: text, Selector: locator, Pacer: s.Pacer}
+}
+
+// BuildElement 按选择器名称（如 note.title）在 locator 内查找元素，ars 可指定固定文本
+// 查找结果计入选择器命中统计
+func (s *Site) BuildElement(locator driver.Locator, key string, ars ...string) entity.Element {
+	loc := locator.Locator(s.Get(key))
+	count, err := loc.Count()
+	s.Record(key, err == nil && count > 0)
+	if err != nil || count == 0 {
+		return entity.Element{}
+	}
+	text := ""
+	if len(ars) == 1 {
+		text = ars[0]
+	} else {
+		text, _ = loc.TextContent()
+	}
+	return s.Element(text, loc)
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/services/scheduler"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/explore"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+	"xiaohongshu/app/services/xiaohongshu/profile"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	homeURL   = "https://www.xiaohongshu.com/explore"
+	searchURL = "https://www.xiaohongshu.com/search_result?keyword=%s&source=web_explore_feed"
+	noteURL   = "https://www.xiaohongshu.com/explore/%s"
+
+	// defaultCheckKeyword 选择器健康检查默认使用的搜索关键词
+	defaultCheckKeyword = "穿搭"
+)
+
+// RegisterJobs 向调度器注册所有抓取类任务
+func (s *XiaohongshuService) RegisterJobs(sch *scheduler.Scheduler) {
+	sch.Register(scheduler.KindCrawlChannel, s.withWorkerPage(s.crawlChannel))
+	sch.Register(scheduler.KindSearchKeyword, s.withWorkerPage(s.searchKeyword))
+	sch.Register(scheduler.KindRefreshProfile, s.withWorkerPage(s.refreshProfile))
+	sch.Register(scheduler.KindRecrawlComments, s.withWorkerPage(s.recrawlComments))
+	sch.Register(scheduler.KindCheckSelectors, s.withWorkerPage(s.checkSelectors))
+}
+
+type pageHandler func(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error)
+
+// withWorkerPage 为每次任务执行创建独立页面，执行结束后关闭
+func (s *XiaohongshuService) withWorkerPage(handler pageHandler) scheduler.Handler {
+	return func(ctx context.Context, job scheduler.Job) (interface{}, error) {
+		if challenge := s.detector.Challenge(); challenge != nil {
+			return nil, fmt.Errorf("account is challenged (%s: %s), waiting for manual resolution", challenge.Kind, challenge.Reason)
+		}
+		page, err := s.NewWorkerPage()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = page.Close()
+			s.site.Pacer.Forget(page)
+		}()
+		return handler(ctx, page, job)
+	}
+}
+
+func (s *XiaohongshuService) crawlChannel(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error) {
+	var params scheduler.CrawlChannelParams
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if err := s.navigate(ctx, page, homeURL); err != nil {
+		return nil, err
+	}
+	if params.Channel != "" {
+		channel := explore.NewChannel(s.site, driver.NewPage(page))
+		if _, err := channel.Show(); err != nil {
+			return nil, err
+		}
+		info, ok := channel.Find(params.Channel)
+		if !ok {
+			return nil, fmt.Errorf("channel %s not found", params.Channel)
+		}
+		if err := info.Click(ctx); err != nil {
+			return nil, err
+		}
+		if err := s.site.Pacer.Sleep(ctx, pacing.ActionView); err != nil {
+			return nil, err
+		}
+	}
+	return s.crawlFeeds(ctx, explore.NewExplore(s.site, driver.NewPage(page)), params.Pages, "channel", params.Channel)
+}
+
+func (s *XiaohongshuService) searchKeyword(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error) {
+	var params scheduler.SearchKeywordParams
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if params.Keyword == "" {
+		return nil, fmt.Errorf("keyword is required")
+	}
+	if err := s.navigate(ctx, page, fmt.Sprintf(searchURL, url.QueryEscape(params.Keyword))); err != nil {
+		return nil, err
+	}
+	feeds := page.Locator(s.site.Get("search.container"))
+	if err := feeds.Locator(s.site.Get("explore.feed")).First().WaitFor(); err != nil {
+		return nil, err
+	}
+	return s.crawlFeeds(ctx, explore.NewExploreWithLocator(s.site, driver.NewLocator(feeds)), params.Pages, "keyword", params.Keyword)
+}
+
+func (s *XiaohongshuService) refreshProfile(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error) {
+	var params scheduler.RefreshProfileParams
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if params.UserId == "" {
+		return nil, fmt.Errorf("userId is required")
+	}
+	if err := s.site.Pacer.Before(ctx, pacing.ActionNavigate); err != nil {
+		return nil, err
+	}
+	userProfile := profile.NewProfile(s.site, driver.NewPage(page))
+	if err := userProfile.Open(params.UserId); err != nil {
+		return nil, err
+	}
+	info, err := userProfile.Show()
+	if err != nil {
+		return nil, err
+	}
+	s.bus.Publish(eventbus.TopicProfileRefreshed, info.Detail(params.UserId))
+	return info.Map(), nil
+}
+
+func (s *XiaohongshuService) recrawlComments(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error) {
+	var params scheduler.RecrawlCommentsParams
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	if params.NoteId == "" {
+		return nil, fmt.Errorf("noteId is required")
+	}
+	if err := s.navigate(ctx, page, fmt.Sprintf(noteURL, params.NoteId)); err != nil {
+		return nil, err
+	}
+	container := page.Locator(s.site.Get("note.container"))
+	if err := container.WaitFor(); err != nil {
+		return nil, err
+	}
+	info, err := note.NewNote(s.site, driver.NewPage(page), nil).Show()
+	if err != nil {
+		return nil, err
+	}
+	comments, err := info.Comment().Show()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]interface{}, 0, len(comments))
+	details := make([]note.CommentDetail, 0, len(comments))
+	for _, comment := range comments {
+		items = append(items, comment.Map())
+		details = append(details, comment.Detail())
+	}
+	s.bus.Publish(eventbus.TopicCommentsCrawled, note.CommentsCrawled{
+		Note:      info.Detail(params.NoteId, page.URL()),
+		Comments:  details,
+		CrawledAt: time.Now(),
+	})
+	result := info.Map()
+	result["noteId"] = params.NoteId
+	result["comments"] = items
+	return result, nil
+}
+
+func (s *XiaohongshuService) checkSelectors(ctx context.Context, page playwright.Page, job scheduler.Job) (interface{}, error) {
+	var params scheduler.CheckSelectorsParams
+	if err := job.DecodeParams(&params); err != nil {
+		return nil, err
+	}
+	return s.runSelectorCheck(ctx, page, params.Keyword)
+}
+
+// CheckSelectors 立即执行一次选择器健康检查
+func (s *XiaohongshuService) CheckSelectors(ctx context.Context, keyword string) ([]selectors.Report, error) {
+	if challenge := s.detector.Challenge(); challenge != nil {
+		return nil, fmt.Errorf("account is challenged (%s: %s), waiting for manual resolution", challenge.Kind, challenge.Reason)
+	}
+	page, err := s.NewWorkerPage()
+	if err != nil {
+		return nil, err
+	}
+	defer page.Close()
+	return s.runSelectorCheck(ctx, page, keyword)
+}
+
+// runSelectorCheck 依次打开推荐页、搜索页和推荐页的第一篇笔记，检查必需的选择器
+// 有选择器失效的页面发布 TopicSelectorsAlert
+func (s *XiaohongshuService) runSelectorCheck(ctx context.Context, page playwright.Page, keyword string) ([]selectors.Report, error) {
+	if keyword == "" {
+		keyword = defaultCheckKeyword
+	}
+	reports := make([]selectors.Report, 0, len(selectors.Pages))
+	check := func(name, target string) selectors.Report {
+		report := s.site.Metrics.Check(driver.NewPage(page), name)
+		report.URL = target
+		if alert := report.Alert(selectors.Pages[name].Type); alert != nil {
+			s.bus.Publish(eventbus.TopicSelectorsAlert, *alert)
+		}
+		reports = append(reports, report)
+		return report
+	}
+	waitFor := func(key string) {
+		// 等待失败也继续检查，由检查结果反映缺失的选择器
+		_ = driver.WaitFor(driver.NewPage(page).Locator(s.site.Get(key)).First(), 10*time.Second)
+	}
+
+	if err := s.navigate(ctx, page, homeURL); err != nil {
+		return reports, err
+	}
+	waitFor("explore.feed")
+	check("explore", homeURL)
+	check("channel", homeURL)
+	link, _ := page.Locator(s.site.Get("explore.feed")).First().Locator(s.site.Get("explore.link")).First().GetAttribute("href")
+
+	searchTarget := fmt.Sprintf(searchURL, url.QueryEscape(keyword))
+	if err := s.navigate(ctx, page, searchTarget); err != nil {
+		return reports, err
+	}
+	waitFor("search.container")
+	check("search", searchTarget)
+
+	if link == "" {
+		reports = append(reports, selectors.Report{
+			Page:      "note",
+			Version:   s.site.Selectors.Info().Version,
+			Error:     "no note link found on explore page",
+			CheckedAt: time.Now(),
+		})
+		return reports, nil
+	}
+	noteTarget, err := url.Parse(homeURL)
+	if err != nil {
+		return reports, err
+	}
+	if noteTarget, err = noteTarget.Parse(link); err != nil {
+		return reports, err
+	}
+	if err := s.navigate(ctx, page, noteTarget.String()); err != nil {
+		return reports, err
+	}
+	waitFor("note.container")
+	check("note", noteTarget.String())
+	return reports, nil
+}
+
+// crawlFeeds 依次抓取 pages 页瀑布流，至少抓取一页
+// source 和 value 标记条目的来源（频道或搜索关键词），value 为空时不标记
+func (s *XiaohongshuService) crawlFeeds(ctx context.Context, ex *explore.Explore, pages int, source, value string) ([]map[string]interface{}, error) {
+	if pages < 1 {
+		pages = 1
+	}
+	items := make([]map[string]interface{}, 0)
+	for i := 0; i < pages; i++ {
+		feeds, err := ex.Show()
+		if err != nil {
+			return items, err
+		}
+		pageItems := make([]map[string]interface{}, 0, len(feeds))
+		for _, feed := range feeds {
+			item := feed.Map()
+			if value != "" {
+				item[source] = value
+			}
+			pageItems = append(pageItems, item)
+		}
+		if len(pageItems) > 0 {
+			s.bus.Publish(eventbus.TopicFeedsSeen, pageItems)
+		}
+		items = append(items, pageItems...)
+		if i == pages-1 {
+			break
+		}
+		if err := ex.NextPage(ctx); err != nil {
+			return items, err
+		}
+		// 等待新内容加载，停顿时间随机
+		if err := s.site.Pacer.Sleep(ctx, pacing.ActionView); err != nil {
+			return items, err
+		}
+	}
+	return items, nil
+}
+
+// navigate 经过节奏控制的页面跳转
+func (s *XiaohongshuService) navigate(ctx context.Context, page playwright.Page, target string) error {
+	if err := s.site.Pacer.Before(ctx, pacing.ActionNavigate); err != nil {
+		return err
+	}
+	logger.InfoContext(ctx, "navigate", "url", target)
+	_, err := page.Goto(target)
+	if err != nil {
+		logger.WarnContext(ctx, "navigation failed", "url", target, "error", err)
+	}
+	return err
+}
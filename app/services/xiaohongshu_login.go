@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/services/xiaohongshu/login"
+)
+
+// loginState 当前进行中的扫码登录
+type loginState struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	account string
+}
+
+// Login 开始扫码登录，状态通过 eventbus.TopicLoginStatus 发布
+// 已有登录流程在进行时返回错误
+func (s *XiaohongshuService) Login(ctx context.Context) error {
+	if s.page == nil {
+		return fmt.Errorf("service is not started")
+	}
+	s.login.mu.Lock()
+	if s.login.cancel != nil {
+		s.login.mu.Unlock()
+		return fmt.Errorf("login is already in progress")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.login.cancel = cancel
+	s.login.mu.Unlock()
+
+	defer func() {
+		s.login.mu.Lock()
+		s.login.cancel = nil
+		s.login.mu.Unlock()
+		cancel()
+	}()
+	// 登录弹窗不是风控
+	restore := s.detector.Suppress()
+	defer restore()
+
+	logger.InfoContext(ctx, "login started")
+	err := login.NewLogin(s.site, s.page).Run(ctx, func(status login.Status) {
+		logger.InfoContext(ctx, "login status", "state", status.State, "message", status.Message)
+		s.bus.Publish(eventbus.TopicLoginStatus, status)
+	})
+	if err != nil {
+		return err
+	}
+	// 保存登录状态，下次启动时直接使用
+	if _, err := s.context.StorageState(s.cookiePath); err != nil {
+		return fmt.Errorf("failed to save storage state: %w", err)
+	}
+	s.accountCookiePath = &s.cookiePath
+	return nil
+}
+
+// CancelLogin 取消进行中的扫码登录
+func (s *XiaohongshuService) CancelLogin() {
+	s.login.mu.Lock()
+	defer s.login.mu.Unlock()
+	if s.login.cancel != nil {
+		s.login.cancel()
+	}
+}
+
+// Logout 退出登录：清除浏览器会话和保存的登录状态
+func (s *XiaohongshuService) Logout() error {
+	if s.context == nil {
+		return fmt.Errorf("service is not started")
+	}
+	s.CancelLogin()
+	if err := s.context.ClearCookies(); err != nil {
+		return fmt.Errorf("failed to clear cookies: %w", err)
+	}
+	if _, err := s.page.Evaluate(`() => { localStorage.clear(); sessionStorage.clear(); }`); err != nil {
+		logger.Warn("failed to clear local storage", "error", err)
+	}
+	if err := os.Remove(s.cookiePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove storage state: %w", err)
+	}
+	s.accountCookiePath = nil
+
+	s.login.mu.Lock()
+	account := s.login.account
+	s.login.account = ""
+	s.login.mu.Unlock()
+	s.site.Pacer.SetAccount("")
+	s.detector.SetAccount("")
+	logger.Info("logged out", "account", account)
+	s.bus.Publish(eventbus.TopicUserLoggedOut, account)
+
+	_, err := s.page.Reload()
+	return err
+}
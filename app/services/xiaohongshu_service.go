@@ -3,25 +3,49 @@ package services
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"strings"
+	"sync"
+	"time"
 	"xiaohongshu/app/entities"
+	"xiaohongshu/app/infra/config"
 	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/infra/logging"
 	"xiaohongshu/app/pkg/utils"
+	"xiaohongshu/app/services/xiaohongshu/har"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/risk"
 	scripts2 "xiaohongshu/app/services/xiaohongshu/scripts"
+	"xiaohongshu/app/services/xiaohongshu/site"
 
+	"github.com/asaskevich/EventBus"
 	"github.com/playwright-community/playwright-go"
 )
 
+var logger = logging.Component("xiaohongshu")
+
 type XiaohongshuService struct {
 	browser           playwright.Browser
+	bus               EventBus.Bus
+	site              *site.Site
+	context           playwright.BrowserContext
 	page              playwright.Page
 	accountCookiePath *string
 	cookiePath        string
+	cacheDirectory    string
 	scriptsPath       embed.FS
-	observer          *scripts2.ClassDOMObserver
+	watcher           *scripts2.DOMWatcher
+	notes             *note.Lifecycle
+	detector          *risk.Detector
+	login             loginState
+	harOptions        har.Options
+	config            *config.Store
+	harPath           string     // 当前会话的 HAR 录制路径
+	sessionMu         sync.Mutex // 保护会话的创建和关闭
+	configOnce        sync.Once  // 配置变化只订阅一次
 
 	mediaCapture *scripts2.MediaCapture
 }
@@ -30,7 +54,8 @@ func (s *XiaohongshuService) MediaCapture() *scripts2.MediaCapture {
 	return s.mediaCapture
 }
 
-func NewXiaohongshuService(browser playwright.Browser, scriptsPath embed.FS) (*XiaohongshuService, error) {
+// NewXiaohongshuService 创建服务，登录、风控等事件在 bus 上发布，页面操作使用 site 中的选择器和节奏控制
+func NewXiaohongshuService(browser playwright.Browser, bus EventBus.Bus, site *site.Site, scriptsPath embed.FS) (*XiaohongshuService, error) {
 	directory, err := utils.GetDefaultCacheDirectory()
 	if err != nil {
 		return nil, err
@@ -45,34 +70,257 @@ func NewXiaohongshuService(browser playwright.Browser, scriptsPath embed.FS) (*X
 		// 文件不存在，将 nil 赋值给 accountCookiePath 字段
 		accountCookiePath = nil
 	}
-	scripts2.InitEventBus()
-
 	return &XiaohongshuService{
 		browser:           browser,
+		bus:               bus,
+		site:              site,
 		accountCookiePath: accountCookiePath,
 		cookiePath:        cookiePath,
+		cacheDirectory:    directory,
 		scriptsPath:       scriptsPath,
 	}, nil
 }
 
+// SetHarOptions 设置 HAR 录制/回放模式，需在 Start 之前调用
+func (s *XiaohongshuService) SetHarOptions(options har.Options) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	s.harOptions = options
+	return nil
+}
+
+// SetConfig 设置应用配置，媒体采集配置修改后在下次开始采集时生效
+// 配置变化的订阅只在第一次调用时添加，再次调用只替换配置
+func (s *XiaohongshuService) SetConfig(store *config.Store) error {
+	s.config = store
+	var err error
+	s.configOnce.Do(func() {
+		err = s.bus.Subscribe(eventbus.TopicConfigChanged, s.onConfigChanged)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe config changes: %w", err)
+	}
+	return nil
+}
+
+// onConfigChanged 把修改后的音频配置应用到当前页面
+func (s *XiaohongshuService) onConfigChanged(change config.Change) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if s.page == nil || s.context == nil {
+		return
+	}
+	if err := applyAudioConfig(s.page, change.Config.Media.Audio); err != nil {
+		logger.Warn("failed to apply audio config", "error", err)
+	}
+}
+
+// settings 当前生效的配置，未设置时使用默认配置
+func (s *XiaohongshuService) settings() config.Config {
+	if s.config == nil {
+		return config.Default()
+	}
+	return s.config.Get()
+}
+
 func (s *XiaohongshuService) Start() error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if err := s.openSession(); err != nil {
+		return err
+	}
+	// 导航到页面
+	_, err := s.page.Goto(s.settings().Site.StartURL)
+	return err
+}
+
+// openSession 创建浏览器上下文和主页面，并启动各项监听
+func (s *XiaohongshuService) openSession() error {
 	var err error
-	context, err := s.browser.NewContext(playwright.BrowserNewContextOptions{
+	contextOptions := playwright.BrowserNewContextOptions{
 		StorageStatePath: s.accountCookiePath,
-	})
+	}
+	switch {
+	case s.harOptions.Recording():
+		s.harPath, err = s.harOptions.SessionPath(s.cacheDirectory, time.Now())
+		if err != nil {
+			return err
+		}
+		contextOptions.RecordHarPath = &s.harPath
+		contextOptions.RecordHarContent = playwright.HarContentPolicyEmbed
+	case s.harOptions.Replaying():
+		// 回放时不使用真实的登录状态
+		contextOptions.StorageStatePath = nil
+	}
+	s.context, err = s.browser.NewContext(contextOptions)
 	if err != nil {
 		return err
 	}
-	s.page, err = context.NewPage()
+	if s.harOptions.Replaying() {
+		err = s.context.RouteFromHAR(s.harOptions.File, playwright.BrowserContextRouteFromHAROptions{
+			NotFound: playwright.HarNotFoundAbort,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to replay har: %w", err)
+		}
+	}
+	// 启动风控检测，接口响应在上下文上监听，后台任务的页面同样生效
+	s.detector = risk.NewDetector(s.context, s.site.Selectors, s.bus, s.site.Pacer)
+	s.login.mu.Lock()
+	s.detector.SetAccount(s.login.account)
+	s.login.mu.Unlock()
+	s.detector.Start()
+	s.context.OnResponse(s.onResponse)
+	s.page, err = s.newPage()
 	if err != nil {
 		return err
 	}
 
-	// 设置视口大小，模拟真实浏览器
-	err = s.page.SetViewportSize(1366, 768)
+	// 启动监听视频监听
+	if err := s.injectAudioConfig(s.page); err != nil {
+		return err
+	}
+	mediaCaptureContent, _ := utils.ReadEmbeddedFile(s.scriptsPath, "scripts/media_capture.js")
+	s.mediaCapture = scripts2.NewMediaCapture(s.page)
+	err = s.mediaCapture.InjectScript(mediaCaptureContent)
+	if err != nil {
+		return err
+	}
+
+	// 启动 DOM 监听，页面导航后自动重新监听
+	domWatchScript, _ := utils.ReadEmbeddedFile(s.scriptsPath, "scripts/dom_watch.js")
+	s.watcher = scripts2.NewDOMWatcher(s.page)
+	if err := s.watcher.Start(domWatchScript); err != nil {
+		return err
+	}
+	// 笔记打开后自动提取详情，关闭时停止媒体采集
+	s.notes = note.NewLifecycle(s.site, s.page, s.watcher, s.mediaCapture, s.bus, s.settings().Site.NoteClass)
+	if err := s.notes.Start(); err != nil {
+		return err
+	}
+	s.page.On("domcontentloaded", func() {
+		logger.Debug("page loaded", "url", s.page.URL())
+	})
+	return nil
+}
+
+// closeSession 停止笔记生命周期、DOM 监听和媒体采集，保存登录状态并关闭浏览器上下文，录制模式下此时写入 HAR 文件
+func (s *XiaohongshuService) closeSession() error {
+	if s.context == nil {
+		return nil
+	}
+	if s.detector != nil {
+		s.detector.Stop()
+	}
+	var errs []error
+	// 停止笔记生命周期和 DOM 监听，重新打开会话时重新创建
+	if s.notes != nil {
+		if err := s.notes.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop note lifecycle: %w", err))
+		}
+		s.notes = nil
+	}
+	if s.watcher != nil {
+		if err := s.watcher.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop dom watches: %w", err))
+		}
+		s.watcher = nil
+	}
+	// 页面上的回调随上下文关闭，旧会话的采集和订阅不能带到新会话
+	if s.mediaCapture != nil {
+		if err := s.mediaCapture.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop media capture: %w", err))
+		}
+		s.mediaCapture = nil
+	}
+	if !s.harOptions.Replaying() {
+		if _, err := s.context.StorageState(s.cookiePath); err == nil {
+			s.accountCookiePath = &s.cookiePath
+		}
+	}
+	if err := s.context.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	s.context = nil
+	return errors.Join(errs...)
+}
+
+// Stop 结束当前会话
+func (s *XiaohongshuService) Stop() error {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	s.CancelLogin()
+	return s.closeSession()
+}
+
+// Shutdown 应用退出时调用：取消登录，停止媒体采集和 DOM 监听，保存登录状态并关闭页面和浏览器上下文
+// 之后服务不能再使用
+func (s *XiaohongshuService) Shutdown() error {
+	s.CancelLogin()
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if s.context == nil {
+		return nil
+	}
+	err := s.closeSession()
+	s.page = nil
 	if err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+	return nil
+}
+
+// HarOptions 当前的 HAR 配置
+func (s *XiaohongshuService) HarOptions() har.Options {
+	return s.harOptions
+}
+
+// ExportHar 导出当前会话的 HAR 到 dest
+// HAR 只在上下文关闭时写入，因此会结束当前会话并以相同的登录状态重新打开当前页面
+func (s *XiaohongshuService) ExportHar(dest string) error {
+	if !s.harOptions.Recording() {
+		return fmt.Errorf("har recording is not enabled")
+	}
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	if s.context == nil {
+		return fmt.Errorf("service is not started")
+	}
+	currentURL := s.page.URL()
+	recorded := s.harPath
+	if err := s.closeSession(); err != nil {
+		return fmt.Errorf("failed to finish har recording: %w", err)
+	}
+	if err := har.Copy(recorded, dest); err != nil {
+		return fmt.Errorf("failed to export har: %w", err)
+	}
+	if err := s.openSession(); err != nil {
+		return fmt.Errorf("failed to reopen session: %w", err)
+	}
+	if !strings.HasPrefix(currentURL, "http") {
+		currentURL = s.settings().Site.StartURL
+	}
+	if _, err := s.page.Goto(currentURL); err != nil {
 		return err
 	}
+	s.bus.Publish(eventbus.TopicSessionRestarted, s.page)
+	return nil
+}
+
+// newPage 在当前上下文中创建页面，并设置视口、注入基础脚本，页面导航前交给风控检测器监听
+func (s *XiaohongshuService) newPage() (playwright.Page, error) {
+	page, err := s.context.NewPage()
+	if err != nil {
+		return nil, err
+	}
+	s.detector.Watch(page)
+	// 设置视口大小，模拟真实浏览器
+	settings := s.settings().Browser
+	err = page.SetViewportSize(settings.ViewportWidth, settings.ViewportHeight)
+	if err != nil {
+		return nil, err
+	}
 	// 添加反检测脚本，隐藏webdriver标志
 	scriptContent := `
 			delete navigator.__proto__.webdriver;
@@ -85,69 +333,97 @@ func (s *XiaohongshuService) Start() error {
 				get: () => [1, 2, 3, 4, 5]
 			});
 		`
-	_ = s.page.AddInitScript(playwright.Script{
+	_ = page.AddInitScript(playwright.Script{
 		Content: &scriptContent,
 	})
 	js := scripts2.ToolJs
-	_ = s.page.AddInitScript(playwright.Script{
+	_ = page.AddInitScript(playwright.Script{
 		Content: &js,
 	})
+	return page, nil
+}
 
-	// 启动监听视频监听
-	mediaCaptureContent, _ := utils.ReadEmbeddedFile(s.scriptsPath, "scripts/media_capture.js")
-	s.mediaCapture = scripts2.NewMediaCapture(s.page)
-	err = s.mediaCapture.InjectScript(mediaCaptureContent)
+// audioConfigBinding 页面读取当前音频采集配置的函数名
+const audioConfigBinding = "__mediaCaptureAudioConfig"
+
+// injectAudioConfig 页面每次加载时通过暴露的函数读取当前的音频采集配置，写入 window.__MediaCaptureConfig，
+// media_capture.js 开始采集时读取。只注入一次初始化脚本，配置修改后刷新页面读取的也是最新配置
+func (s *XiaohongshuService) injectAudioConfig(page playwright.Page) error {
+	err := page.ExposeFunction(audioConfigBinding, func(args ...interface{}) interface{} {
+		config, err := audioConfig(s.settings().Media.Audio)
+		if err != nil {
+			logger.Warn("failed to encode audio config", "error", err)
+			return nil
+		}
+		return config
+	})
 	if err != nil {
 		return err
 	}
+	script := fmt.Sprintf(`window.%s().then((config) => { if (config) window.__MediaCaptureConfig = config; });`, audioConfigBinding)
+	if err := page.AddInitScript(playwright.Script{Content: &script}); err != nil {
+		return err
+	}
+	return applyAudioConfig(page, s.settings().Media.Audio)
+}
 
-	//启动note 监听
-	classDomObserverService, _ := utils.ReadEmbeddedFile(s.scriptsPath, "scripts/class_dom_observer.js")
-	_ = s.page.AddInitScript(playwright.Script{
-		Content: &classDomObserverService,
-	})
-	s.observer = scripts2.CreateClassDOMObserver(s.page)
-	err = s.observer.Start("note-detail-mask")
+// applyAudioConfig 更新已加载页面中的 window.__MediaCaptureConfig，下次开始采集时生效
+func applyAudioConfig(page playwright.Page, audio config.Audio) error {
+	data, err := audioConfig(audio)
 	if err != nil {
 		return err
 	}
-	s.page.On("load", func() {
-		go func() {
-			_ = s.observer.UnobserveAll()
-			_, err = s.observer.Observe()
-			if err != nil {
-				fmt.Println(err)
-			}
-		}()
-	})
-	s.page.On("domcontentloaded", func() {
-		fmt.Println("domcontentloaded")
-	})
-	s.page.OnResponse(s.onResponse)
-	// 导航到页面
-	_, err = s.page.Goto("https://www.xiaohongshu.com")
+	_, err = page.Evaluate(`(config) => { window.__MediaCaptureConfig = config; }`, data)
+	return err
+}
+
+// audioConfig 转换为可以传给页面的 {audio: {...}}，字段名与 JSON 一致
+func audioConfig(audio config.Audio) (map[string]interface{}, error) {
+	data, err := json.Marshal(map[string]interface{}{"audio": audio})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var result map[string]interface{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
 
-	return err
+// NewWorkerPage 为后台任务创建独立页面，与主页面共享登录状态，调用方负责关闭
+func (s *XiaohongshuService) NewWorkerPage() (playwright.Page, error) {
+	if s.context == nil {
+		return nil, fmt.Errorf("service is not started")
+	}
+	return s.newPage()
+}
+
+// Site 页面操作使用的选择器注册表、命中统计和节奏控制器
+func (s *XiaohongshuService) Site() *site.Site {
+	return s.site
+}
+
+// RiskDetector 风控检测器
+func (s *XiaohongshuService) RiskDetector() *risk.Detector {
+	return s.detector
 }
 
 func (s *XiaohongshuService) GetPage() playwright.Page {
 	return s.page
 }
 
-func (s *XiaohongshuService) ListenNote() {
-	_ = s.observer.OnAdd(func(string2 string) {
-		fmt.Println(fmt.Sprintf("Note added: %s", string2))
-	})
-	_ = s.observer.OnRemove(func(string2 string) {
-		fmt.Println(fmt.Sprintf("Note removed: %s", string2))
-	})
+// DOMWatcher 主页面的 DOM 监听服务
+func (s *XiaohongshuService) DOMWatcher() *scripts2.DOMWatcher {
+	return s.watcher
+}
+
+// Notes 主页面的笔记生命周期，笔记打开和关闭时发布 note:opened 和 note:closed
+func (s *XiaohongshuService) Notes() *note.Lifecycle {
+	return s.notes
 }
+
 func (s *XiaohongshuService) onResponse(response playwright.Response) {
 	// 检查URL是否包含v2/user/me
 	go s.me(response)
+	go s.detector.OnResponse(response)
 }
 
 func (s *XiaohongshuService) me(response playwright.Response) {
@@ -157,7 +433,7 @@ func (s *XiaohongshuService) me(response playwright.Response) {
 		go func() {
 			body, err := response.Body()
 			if err != nil {
-				fmt.Printf("获取响应体失败: %v\n", err)
+				logger.Warn("failed to read user info response", "error", err)
 				return
 			}
 			// 解析响应内容
@@ -167,20 +443,36 @@ func (s *XiaohongshuService) me(response playwright.Response) {
 				return
 			}
 			if apiResponse.Code != 0 {
-				fmt.Printf("API返回错误码: %d, 消息: %s\n", apiResponse.Code, apiResponse.Msg)
+				logger.Warn("user info api returned error", "code", apiResponse.Code, "message", apiResponse.Msg)
 				return
 			}
-			context := s.page.Context()
-			_, err = context.StorageState(s.cookiePath)
-			if err != nil {
+			// 响应可能在会话关闭之后才到达
+			s.sessionMu.Lock()
+			context, detector := s.context, s.detector
+			s.sessionMu.Unlock()
+			if context == nil {
 				return
 			}
+			// 回放的会话不覆盖真实的登录状态
+			if !s.harOptions.Replaying() {
+				_, err = context.StorageState(s.cookiePath)
+				if err != nil {
+					return
+				}
+			}
 			// 检查API响应是否成功
 			if apiResponse.Success {
+				// 之后的限流和配额按该账号计算
+				s.site.Pacer.SetAccount(apiResponse.Data.UserId)
+				detector.SetAccount(apiResponse.Data.UserId)
+				s.login.mu.Lock()
+				s.login.account = apiResponse.Data.UserId
+				s.login.mu.Unlock()
+				logger.Info("logged in", "account", apiResponse.Data.UserId)
 				// 通过event_bus发送用户信息
-				eventbus.GlobalEventBus.Publish("user-logged-in", apiResponse.Data)
+				s.bus.Publish(eventbus.TopicUserLoggedIn, apiResponse.Data)
 			} else {
-				fmt.Printf("API调用不成功: %+v\n", apiResponse)
+				logger.Warn("user info api was not successful", "code", apiResponse.Code, "message", apiResponse.Msg)
 			}
 		}()
 
@@ -8,7 +8,9 @@ import { BrowserRouter as Router, Routes, Route } from "react-router-dom"
 import HomePage from "./app/home/page"
 import DashboardPage from "./app/dashboard/page"
 import LifecyclePage from "./app/lifecycle/page"
+import LogsPage from "./app/logs/page"
 import NotFoundPage from "./app/not-found/page"
+import SettingsPage from "./app/settings/page"
 import StartPage from "./app/start/page"
 
 export default function App() {
@@ -35,6 +37,8 @@ export default function App() {
                 <Route path="/home" element={<HomePage />} />
                 <Route path="/dashboard" element={<DashboardPage />} />
                 <Route path="/lifecycle" element={<LifecyclePage />} />
+                <Route path="/logs" element={<LogsPage />} />
+                <Route path="/settings" element={<SettingsPage />} />
               </Routes>
             </SidebarInset>
           </SidebarProvider>
@@ -1,62 +1,389 @@
-import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
-import { ChartAreaInteractive } from "@/components/chart-area-interactive"
+import * as React from "react"
+import { Card, CardAction, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select"
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table"
+import { ChartAreaInteractive, type VolumePoint } from "@/components/chart-area-interactive"
+import { EventsOn, LogPrint } from "../../../wailsjs/runtime"
+import { Export, FindSimilarImages, GetNoteAnalytics, SearchNotes } from "../../../wailsjs/go/xiaohongshu/Xiaohongshu"
+
+type Term = { text: string; count: number }
+
+type Commenter = { author: string; comments: number; likes: number }
+
+type Report = {
+  noteId: string
+  title: string
+  author: string
+  comments: number
+  topLevel: number
+  replies: number
+  commenters: number
+  likes: number
+  undated: number
+  crawledAt: number
+  volume: VolumePoint[] | null
+  topCommenters: Commenter[] | null
+  keywords: Term[] | null
+  hashtags: Term[] | null
+  sentiment: { positive: number; neutral: number; negative: number; average: number }
+}
+
+type SearchHit = { noteId: string; source: string; ref: string; text: string; rank: number }
+
+type SimilarNote = { noteId: string; distance: number; matches: { url: string; sourceUrl: string; distance: number }[] }
+
+const exportKinds: Record<string, string> = {
+  feeds: "推荐流",
+  notes: "笔记",
+  comments: "评论",
+  authors: "作者",
+}
+
+const exportFormats = ["csv", "jsonl", "xlsx"]
 
 export default function DashboardPage() {
+  const [noteId, setNoteId] = React.useState("")
+  const [report, setReport] = React.useState<Report | null>(null)
+  const [similar, setSimilar] = React.useState<SimilarNote[]>([])
+  const [error, setError] = React.useState("")
+
+  const analyze = React.useCallback((id: string) => {
+    id = id.trim()
+    if (!id) {
+      return
+    }
+    setNoteId(id)
+    setError("")
+    GetNoteAnalytics(id)
+      .then((result) => setReport(result as Report))
+      .catch((err) => {
+        setReport(null)
+        setError(String(err))
+      })
+    FindSimilarImages(id, -1)
+      .then((result) => setSimilar(result as SimilarNote[]))
+      .catch((err) => {
+        setSimilar([])
+        LogPrint("查找相似图片失败: " + err)
+      })
+  }, [])
+
+  // 在浏览器中打开笔记时自动分析
+  React.useEffect(() => {
+    return EventsOn("note-opened", (detail: { id: string }) => analyze(detail.id))
+  }, [analyze])
+
+  const sentiment = report?.sentiment
+  const rated = sentiment ? sentiment.positive + sentiment.neutral + sentiment.negative : 0
+  const percent = (count: number) => (rated > 0 ? Math.round((count / rated) * 100) : 0)
+
   return (
     <div className="flex flex-1 flex-col gap-4 p-4">
+      <Card>
+        <CardHeader>
+          <CardTitle>笔记分析</CardTitle>
+          <CardDescription>
+            {report ? `${report.title || report.noteId} · ${report.author}` : "输入笔记 id，或在浏览器中打开笔记"}
+          </CardDescription>
+          <CardAction className="flex items-center gap-2">
+            <Input
+              className="w-56"
+              placeholder="笔记 id"
+              value={noteId}
+              onChange={(event) => setNoteId(event.target.value)}
+              onKeyDown={(event) => event.key === "Enter" && analyze(noteId)}
+            />
+            <Button size="sm" onClick={() => analyze(noteId)}>
+              分析
+            </Button>
+          </CardAction>
+        </CardHeader>
+        {error && (
+          <CardContent>
+            <div className="text-sm text-red-600 break-all">{error}</div>
+          </CardContent>
+        )}
+      </Card>
+
       <div className="grid auto-rows-min gap-4 md:grid-cols-4">
         <Card>
           <CardHeader>
-            <CardTitle>用户统计</CardTitle>
-            <CardDescription>总用户数</CardDescription>
+            <CardTitle>评论数</CardTitle>
+            <CardDescription>
+              一级评论 {report?.topLevel ?? 0}，回复 {report?.replies ?? 0}
+            </CardDescription>
           </CardHeader>
           <CardContent>
-            <p className="text-2xl font-bold">1,234</p>
+            <p className="text-2xl font-bold">{(report?.comments ?? 0).toLocaleString()}</p>
           </CardContent>
         </Card>
-        
+
         <Card>
           <CardHeader>
-            <CardTitle>文章数量</CardTitle>
-            <CardDescription>发布的文章</CardDescription>
+            <CardTitle>评论用户</CardTitle>
+            <CardDescription>不同的评论者</CardDescription>
           </CardHeader>
           <CardContent>
-            <p className="text-2xl font-bold">567</p>
+            <p className="text-2xl font-bold">{(report?.commenters ?? 0).toLocaleString()}</p>
           </CardContent>
         </Card>
-        
+
         <Card>
           <CardHeader>
-            <CardTitle>评论数</CardTitle>
-            <CardDescription>用户评论</CardDescription>
+            <CardTitle>评论点赞</CardTitle>
+            <CardDescription>评论获得的点赞总数</CardDescription>
           </CardHeader>
           <CardContent>
-            <p className="text-2xl font-bold">8,901</p>
+            <p className="text-2xl font-bold">{(report?.likes ?? 0).toLocaleString()}</p>
           </CardContent>
         </Card>
-        
+
         <Card>
           <CardHeader>
-            <CardTitle>点赞数</CardTitle>
-            <CardDescription>获得的点赞</CardDescription>
+            <CardTitle>情感倾向</CardTitle>
+            <CardDescription>评论的平均情感得分</CardDescription>
           </CardHeader>
           <CardContent>
-            <p className="text-2xl font-bold">12,345</p>
+            <p className="text-2xl font-bold">{(sentiment?.average ?? 0).toFixed(2)}</p>
           </CardContent>
         </Card>
       </div>
-      
-      <div className="grid auto-rows-min gap-4">
+
+      <ChartAreaInteractive data={report?.volume ?? []} />
+
+      <div className="grid auto-rows-min gap-4 md:grid-cols-3">
         <Card>
           <CardHeader>
-            <CardTitle>数据趋势</CardTitle>
-            <CardDescription>最近30天的数据变化</CardDescription>
+            <CardTitle>活跃评论者</CardTitle>
+            <CardDescription>评论最多的用户</CardDescription>
           </CardHeader>
           <CardContent>
-            <ChartAreaInteractive />
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>用户</TableHead>
+                  <TableHead className="text-right">评论</TableHead>
+                  <TableHead className="text-right">点赞</TableHead>
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {(report?.topCommenters ?? []).map((commenter) => (
+                  <TableRow key={commenter.author}>
+                    <TableCell className="max-w-40 truncate">{commenter.author}</TableCell>
+                    <TableCell className="text-right tabular-nums">{commenter.comments}</TableCell>
+                    <TableCell className="text-right tabular-nums">{commenter.likes}</TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
           </CardContent>
         </Card>
+
+        <Card>
+          <CardHeader>
+            <CardTitle>关键词</CardTitle>
+            <CardDescription>评论中出现最多的词和话题</CardDescription>
+          </CardHeader>
+          <CardContent className="flex flex-wrap gap-2">
+            {(report?.keywords ?? []).map((term) => (
+              <Badge key={term.text} variant="secondary">
+                {term.text} {term.count}
+              </Badge>
+            ))}
+            {(report?.hashtags ?? []).map((term) => (
+              <Badge key={"#" + term.text} variant="outline">
+                #{term.text} {term.count}
+              </Badge>
+            ))}
+          </CardContent>
+        </Card>
+
+        <Card>
+          <CardHeader>
+            <CardTitle>情感分布</CardTitle>
+            <CardDescription>正面、中性和负面评论的占比</CardDescription>
+          </CardHeader>
+          <CardContent className="flex flex-col gap-3 text-sm">
+            {[
+              { label: "正面", count: sentiment?.positive ?? 0, color: "bg-green-600" },
+              { label: "中性", count: sentiment?.neutral ?? 0, color: "bg-muted-foreground" },
+              { label: "负面", count: sentiment?.negative ?? 0, color: "bg-red-600" },
+            ].map((row) => (
+              <div key={row.label} className="flex flex-col gap-1">
+                <div className="flex justify-between">
+                  <span>{row.label}</span>
+                  <span className="tabular-nums">
+                    {row.count}（{percent(row.count)}%）
+                  </span>
+                </div>
+                <div className="h-2 rounded bg-muted">
+                  <div className={`h-2 rounded ${row.color}`} style={{ width: `${percent(row.count)}%` }} />
+                </div>
+              </div>
+            ))}
+          </CardContent>
+        </Card>
+      </div>
+
+      <div className="grid auto-rows-min gap-4 md:grid-cols-2">
+        <SearchCard onSelect={analyze} />
+        <SimilarCard notes={similar} onSelect={analyze} />
       </div>
+
+      <ExportCard />
     </div>
   )
-}
\ No newline at end of file
+}
+
+function SearchCard({ onSelect }: { onSelect: (noteId: string) => void }) {
+  const [query, setQuery] = React.useState("")
+  const [hits, setHits] = React.useState<SearchHit[]>([])
+  const [error, setError] = React.useState("")
+
+  const search = () => {
+    if (!query.trim()) {
+      return
+    }
+    setError("")
+    SearchNotes(query.trim(), 20)
+      .then((result) => setHits(result as SearchHit[]))
+      .catch((err) => setError(String(err)))
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>搜索笔记</CardTitle>
+        <CardDescription>在笔记正文和图片文字中搜索，点击结果进行分析</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-3 text-sm">
+        <div className="flex gap-2">
+          <Input
+            placeholder="关键词"
+            value={query}
+            onChange={(event) => setQuery(event.target.value)}
+            onKeyDown={(event) => event.key === "Enter" && search()}
+          />
+          <Button size="sm" onClick={search}>
+            搜索
+          </Button>
+        </div>
+        {error && <div className="text-red-600 break-all">{error}</div>}
+        {hits.map((hit) => (
+          <button
+            key={`${hit.noteId}-${hit.source}-${hit.ref}`}
+            className="flex flex-col items-start gap-1 rounded border p-2 text-left hover:bg-muted"
+            onClick={() => onSelect(hit.noteId)}
+          >
+            <div className="flex items-center gap-2">
+              <span className="font-medium">{hit.noteId}</span>
+              <Badge variant="outline">{hit.source === "image" ? "图片文字" : "笔记"}</Badge>
+            </div>
+            <span className="line-clamp-2 text-muted-foreground">{hit.text}</span>
+          </button>
+        ))}
+      </CardContent>
+    </Card>
+  )
+}
+
+function SimilarCard({ notes, onSelect }: { notes: SimilarNote[]; onSelect: (noteId: string) => void }) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>相似图片</CardTitle>
+        <CardDescription>与当前笔记图片相似的其他笔记，距离越小越相似</CardDescription>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-3 text-sm">
+        {notes.length === 0 && <div className="text-muted-foreground">没有相似的笔记</div>}
+        {notes.map((note) => (
+          <button
+            key={note.noteId}
+            className="flex flex-col items-start gap-2 rounded border p-2 text-left hover:bg-muted"
+            onClick={() => onSelect(note.noteId)}
+          >
+            <div className="flex items-center gap-2">
+              <span className="font-medium">{note.noteId}</span>
+              <Badge variant="outline">距离 {note.distance}</Badge>
+            </div>
+            <div className="flex flex-wrap gap-2">
+              {note.matches.map((match) => (
+                <img key={match.url} src={match.url} alt="" className="h-16 w-16 rounded object-cover" referrerPolicy="no-referrer" />
+              ))}
+            </div>
+          </button>
+        ))}
+      </CardContent>
+    </Card>
+  )
+}
+
+function ExportCard() {
+  const [kind, setKind] = React.useState("notes")
+  const [format, setFormat] = React.useState("csv")
+  const [keyword, setKeyword] = React.useState("")
+  const [message, setMessage] = React.useState("")
+  const [error, setError] = React.useState("")
+
+  const run = async () => {
+    setError("")
+    setMessage("")
+    try {
+      const result = await Export(kind, { keyword }, format, "")
+      // 取消保存对话框时没有返回结果
+      if (result) {
+        setMessage(`已导出 ${result.rows} 行到 ${result.path}`)
+      }
+    } catch (err) {
+      setError(String(err))
+    }
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>导出数据</CardTitle>
+        <CardDescription>导出采集到的推荐流、笔记、评论和作者</CardDescription>
+        <CardAction className="flex items-center gap-2">
+          <Select value={kind} onValueChange={setKind}>
+            <SelectTrigger size="sm" className="w-28" aria-label="数据类型">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              {Object.entries(exportKinds).map(([value, label]) => (
+                <SelectItem key={value} value={value}>
+                  {label}
+                </SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+          <Select value={format} onValueChange={setFormat}>
+            <SelectTrigger size="sm" className="w-24" aria-label="导出格式">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              {exportFormats.map((value) => (
+                <SelectItem key={value} value={value}>
+                  {value.toUpperCase()}
+                </SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+          <Input className="w-40" placeholder="关键词（可选）" value={keyword} onChange={(event) => setKeyword(event.target.value)} />
+          <Button size="sm" onClick={run}>
+            导出
+          </Button>
+        </CardAction>
+      </CardHeader>
+      {(message || error) && (
+        <CardContent className="text-sm">
+          {message && <div className="text-green-600 break-all">{message}</div>}
+          {error && <div className="text-red-600 break-all">{error}</div>}
+        </CardContent>
+      )}
+    </Card>
+  )
+}
@@ -1,5 +1,6 @@
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
 import { Button } from "@/components/ui/button"
+import { LoginCard } from "@/components/login-card"
 import { Greet } from "../../../wailsjs/go/app/App"
 import {EventsOn, LogPrint} from "../../../wailsjs/runtime"
 import * as React from "react"
@@ -166,8 +167,8 @@ export default function HomePage() {
             </div>
           </CardContent>
         </Card>
-        
-      
+
+        <LoginCard />
       </div>
     </div>
   )
@@ -0,0 +1,162 @@
+import * as React from "react"
+import { Card, CardAction, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select"
+import { EventsOn, LogPrint } from "../../../wailsjs/runtime"
+import { GetLogLevel, GetLogs, SetLogLevel, StopLogStream, StreamLogs } from "../../../wailsjs/go/app/App"
+
+type LogEntry = {
+  time: string
+  level: "DEBUG" | "INFO" | "WARN" | "ERROR"
+  msg: string
+  component?: string
+  correlation?: string
+  [key: string]: unknown
+}
+
+// 面板最多保留的日志条数
+const maxEntries = 1000
+
+const levels = ["debug", "info", "warn", "error"]
+
+const levelVariant: Record<string, "secondary" | "outline" | "destructive" | "default"> = {
+  DEBUG: "outline",
+  INFO: "secondary",
+  WARN: "default",
+  ERROR: "destructive",
+}
+
+// 除固定字段外的其他字段
+function fields(entry: LogEntry) {
+  return Object.entries(entry)
+    .filter(([key]) => !["time", "level", "msg", "component", "correlation"].includes(key))
+    .map(([key, value]) => `${key}=${typeof value === "string" ? value : JSON.stringify(value)}`)
+    .join(" ")
+}
+
+export default function LogsPage() {
+  const [entries, setEntries] = React.useState<LogEntry[]>([])
+  const [filter, setFilter] = React.useState("info")
+  const [outputLevel, setOutputLevel] = React.useState("info")
+  const [query, setQuery] = React.useState("")
+  const [paused, setPaused] = React.useState(false)
+
+  React.useEffect(() => {
+    GetLogLevel().then(setOutputLevel)
+  }, [])
+
+  React.useEffect(() => {
+    if (paused) {
+      return
+    }
+    GetLogs(200, filter)
+      .then((items) => setEntries(items as LogEntry[]))
+      .catch((error) => LogPrint("读取日志失败: " + error))
+    const off = EventsOn("log-entry", (entry: LogEntry) => {
+      setEntries((current) => [...current, entry].slice(-maxEntries))
+    })
+    StreamLogs(filter).catch((error) => LogPrint("订阅日志失败: " + error))
+    return () => {
+      off()
+      StopLogStream()
+    }
+  }, [filter, paused])
+
+  const changeOutputLevel = async (level: string) => {
+    try {
+      await SetLogLevel(level)
+      setOutputLevel(level)
+    } catch (error) {
+      LogPrint("修改日志级别失败: " + error)
+    }
+  }
+
+  // 点击关联 id 时只看同一次操作的日志
+  const visible = entries.filter((entry) => {
+    if (!query) {
+      return true
+    }
+    return JSON.stringify(entry).includes(query)
+  })
+
+  return (
+    <div className="flex flex-1 flex-col gap-4 p-4">
+      <Card>
+        <CardHeader>
+          <CardTitle>日志</CardTitle>
+          <CardDescription>实时查看应用日志，点击关联 id 可筛选同一次操作的日志</CardDescription>
+          <CardAction className="flex items-center gap-2">
+            <Select value={outputLevel} onValueChange={changeOutputLevel}>
+              <SelectTrigger size="sm" className="w-32" aria-label="输出级别">
+                <SelectValue placeholder="输出级别" />
+              </SelectTrigger>
+              <SelectContent>
+                {levels.map((level) => (
+                  <SelectItem key={level} value={level}>
+                    输出 {level}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+            <Select value={filter} onValueChange={setFilter}>
+              <SelectTrigger size="sm" className="w-32" aria-label="显示级别">
+                <SelectValue placeholder="显示级别" />
+              </SelectTrigger>
+              <SelectContent>
+                {levels.map((level) => (
+                  <SelectItem key={level} value={level}>
+                    显示 {level}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+            <Button variant="outline" size="sm" onClick={() => setPaused(!paused)}>
+              {paused ? "继续" : "暂停"}
+            </Button>
+            <Button variant="outline" size="sm" onClick={() => setEntries([])}>
+              清空
+            </Button>
+          </CardAction>
+        </CardHeader>
+        <CardContent className="flex flex-col gap-2">
+          <Input
+            placeholder="搜索内容、组件或关联 id"
+            value={query}
+            onChange={(event) => setQuery(event.target.value)}
+          />
+          <div className="h-[600px] overflow-auto rounded-md border font-mono text-xs">
+            {visible.map((entry, index) => (
+              <div key={index} className="flex gap-2 border-b px-2 py-1">
+                <span className="text-muted-foreground shrink-0">
+                  {new Date(entry.time).toLocaleTimeString()}
+                </span>
+                <Badge variant={levelVariant[entry.level] ?? "secondary"} className="shrink-0">
+                  {entry.level}
+                </Badge>
+                {entry.component && <span className="shrink-0 text-blue-600">[{entry.component}]</span>}
+                <span className="shrink-0">{entry.msg}</span>
+                <span className="text-muted-foreground break-all">{fields(entry)}</span>
+                {entry.correlation && (
+                  <button
+                    className="ml-auto shrink-0 text-purple-600 hover:underline"
+                    onClick={() => setQuery(entry.correlation ?? "")}
+                  >
+                    #{entry.correlation}
+                  </button>
+                )}
+              </div>
+            ))}
+          </div>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
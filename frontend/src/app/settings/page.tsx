@@ -0,0 +1,184 @@
+import * as React from "react"
+import { Card, CardAction, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Checkbox } from "@/components/ui/checkbox"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { EventsOn, LogPrint } from "../../../wailsjs/runtime"
+import { GetConfig, SaveConfig } from "../../../wailsjs/go/app/App"
+
+type Field = {
+  key: string
+  env: string
+  restartRequired: boolean
+}
+
+type Config = Record<string, Record<string, unknown>>
+
+type ConfigState = {
+  path: string
+  config: Config
+  effective: Config
+  fields: Field[]
+  overridden: string[] | null
+}
+
+const sectionTitles: Record<string, string> = {
+  browser: "浏览器",
+  site: "站点",
+  media: "媒体采集",
+  database: "数据库",
+  log: "日志",
+  har: "HAR 录制与回放",
+}
+
+// 按 browser.cdpUrl 这样的路径读取配置项
+function getValue(config: Config, key: string): unknown {
+  return key.split(".").reduce<unknown>((value, part) => (value as Record<string, unknown>)?.[part], config)
+}
+
+function setValue(config: Config, key: string, value: unknown): Config {
+  const next = structuredClone(config)
+  const parts = key.split(".")
+  let target = next as Record<string, unknown>
+  for (const part of parts.slice(0, -1)) {
+    target = target[part] as Record<string, unknown>
+  }
+  target[parts[parts.length - 1]] = value
+  return next
+}
+
+export default function SettingsPage() {
+  const [state, setState] = React.useState<ConfigState | null>(null)
+  const [draft, setDraft] = React.useState<Config | null>(null)
+  const [message, setMessage] = React.useState("")
+  const [error, setError] = React.useState("")
+  const [restartRequired, setRestartRequired] = React.useState<string[]>([])
+
+  const load = React.useCallback(() => {
+    GetConfig()
+      .then((result) => {
+        const next = result as ConfigState
+        setState(next)
+        setDraft(next.config)
+      })
+      .catch((err) => LogPrint("读取配置失败: " + err))
+  }, [])
+
+  React.useEffect(() => {
+    load()
+    return EventsOn("config-changed", load)
+  }, [load])
+
+  const save = async () => {
+    if (!draft) {
+      return
+    }
+    setError("")
+    setMessage("")
+    try {
+      const result = await SaveConfig(draft)
+      const changed = (result.changed ?? []) as string[]
+      const restart = (result.restartRequired ?? []) as string[]
+      setRestartRequired((current) => Array.from(new Set([...current, ...restart])))
+      setMessage(changed.length > 0 ? `已保存 ${changed.length} 项修改` : "没有修改")
+    } catch (err) {
+      setError(String(err))
+    }
+  }
+
+  if (!state || !draft) {
+    return null
+  }
+
+  const overridden = new Set(state.overridden ?? [])
+  const sections = Object.keys(sectionTitles).map((section) => ({
+    section,
+    fields: state.fields.filter((field) => field.key.split(".")[0] === section),
+  }))
+
+  const renderInput = (field: Field) => {
+    const value = getValue(draft, field.key)
+    const disabled = overridden.has(field.key)
+    if (typeof value === "boolean") {
+      return (
+        <Checkbox
+          id={field.key}
+          checked={value}
+          disabled={disabled}
+          onCheckedChange={(checked) => setDraft(setValue(draft, field.key, checked === true))}
+        />
+      )
+    }
+    if (typeof value === "number") {
+      return (
+        <Input
+          id={field.key}
+          type="number"
+          step="any"
+          value={String(value)}
+          disabled={disabled}
+          onChange={(event) => setDraft(setValue(draft, field.key, Number(event.target.value)))}
+        />
+      )
+    }
+    return (
+      <Input
+        id={field.key}
+        value={String(value ?? "")}
+        disabled={disabled}
+        onChange={(event) => setDraft(setValue(draft, field.key, event.target.value))}
+      />
+    )
+  }
+
+  return (
+    <div className="flex flex-1 flex-col gap-4 p-4">
+      <Card>
+        <CardHeader>
+          <CardTitle>设置</CardTitle>
+          <CardDescription>配置文件：{state.path}</CardDescription>
+          <CardAction className="flex items-center gap-2">
+            <Button variant="outline" size="sm" onClick={() => setDraft(state.config)}>
+              还原
+            </Button>
+            <Button size="sm" onClick={save}>
+              保存
+            </Button>
+          </CardAction>
+        </CardHeader>
+        <CardContent className="flex flex-col gap-2 text-sm">
+          {message && <div className="text-green-600">{message}</div>}
+          {error && <div className="text-red-600 break-all">{error}</div>}
+          {restartRequired.length > 0 && (
+            <div className="text-amber-600">以下配置项需要重启应用才能生效：{restartRequired.join("、")}</div>
+          )}
+        </CardContent>
+      </Card>
+      {sections.map(({ section, fields }) => (
+        <Card key={section}>
+          <CardHeader>
+            <CardTitle>{sectionTitles[section]}</CardTitle>
+          </CardHeader>
+          <CardContent className="grid gap-4 md:grid-cols-2">
+            {fields.map((field) => (
+              <div key={field.key} className="flex flex-col gap-2">
+                <Label htmlFor={field.key} className="flex flex-wrap items-center gap-2">
+                  {field.key}
+                  {field.restartRequired && <Badge variant="outline">需重启</Badge>}
+                  {overridden.has(field.key) && (
+                    <Badge variant="secondary" title={field.env}>
+                      由 {field.env} 覆盖为 {String(getValue(state.effective, field.key))}
+                    </Badge>
+                  )}
+                </Label>
+                {renderInput(field)}
+              </div>
+            ))}
+          </CardContent>
+        </Card>
+      ))}
+    </div>
+  )
+}
@@ -2,144 +2,213 @@ import { useEffect, useState } from "react"
 import { Button } from "@/components/ui/button"
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
 import { Link, useNavigate } from "react-router-dom"
-import { EventsEmit, EventsOff, EventsOn, LogPrint, } from "../../../wailsjs/runtime"
+import { EventsOn, LogPrint } from "../../../wailsjs/runtime"
+import { GetStartupStatus, RetryInitialization } from "../../../wailsjs/go/app/App"
 import { Spinner } from "@/components/ui/spinner"
 
+type StageProgress = {
+  stage: string
+  message: string
+  bytes: number
+  total: number
+  percent: number // 总量未知时为 -1
+  done: boolean
+}
+
+type StartupError = {
+  stage: string
+  code: string
+  action: "retry" | "open-settings" | "restart"
+  message: string
+  hint: string
+}
+
+type StartupStatus = {
+  stages: StageProgress[]
+  errors: StartupError[]
+  running: boolean
+  done: boolean
+  ready: boolean
+}
+
+const stageNames: Record<string, string> = {
+  "resolve-paths": "确定应用目录",
+  "load-config": "读取配置",
+  "download-driver": "下载 playwright 驱动",
+  "download-browser": "下载浏览器",
+  "launch-browser": "连接浏览器",
+  "open-database": "打开数据库",
+  migrate: "迁移数据库",
+  "start-service": "启动服务",
+}
+
+function formatBytes(bytes: number) {
+  return (bytes / 1024 / 1024).toFixed(1) + " MB"
+}
+
+// 合并一个阶段的进度，保持阶段开始的顺序
+function mergeStage(stages: StageProgress[], progress: StageProgress) {
+  const index = stages.findIndex((item) => item.stage === progress.stage)
+  if (index < 0) {
+    return [...stages, progress]
+  }
+  const next = [...stages]
+  next[index] = progress
+  return next
+}
+
 export default function StartPage() {
   const navigate = useNavigate()
-  const [isLoading, setIsLoading] = useState(true)
-  const [errors, setErrors] = useState<string[]>([])
+  const [status, setStatus] = useState<StartupStatus>({ stages: [], errors: [], running: true, done: false, ready: false })
+  const [notice, setNotice] = useState("")
+
   useEffect(() => {
-      LogPrint("发送开始准备信号 mounted")
-      EventsEmit('startReady')
-    // 监听初始化完成事件
-    const cleanup = EventsOn("initialization-complete", (errorMessages: string[]) => {
+    // 页面可能晚于部分事件加载，先读取当前状态
+    GetStartupStatus()
+      .then((current) => setStatus(current as StartupStatus))
+      .catch((error) => LogPrint("读取启动状态失败: " + error))
+    const offProgress = EventsOn("startup-progress", (progress: StageProgress) => {
+      setStatus((current) => ({ ...current, stages: mergeStage(current.stages, progress) }))
+    })
+    const offError = EventsOn("startup-error", (error: StartupError) => {
+      setStatus((current) => ({ ...current, errors: [...current.errors, error] }))
+    })
+    const offComplete = EventsOn("initialization-complete", (current: StartupStatus) => {
       LogPrint("Received initialization-complete event")
-      // 发送确认接收信号给后端
-      EventsEmit('initialization-received')
-      
-      // 解析后端发送的错误信息
-      if (errorMessages.length > 0) {
-        // 如果有错误，设置错误状态但不跳转
-        setErrors(errorMessages)
-        setIsLoading(false)
-        // 取消事件监听，避免重复处理
-        EventsOff("initialization-complete")
-      } else {
-        // 如果没有错误，自动跳转到首页
-        setIsLoading(false)
-        // 取消事件监听，避免重复处理
-        EventsOff("initialization-complete")
-        navigate("/home")
-      }
+      setStatus(current)
     })
-    // 发送开始准备信号
-
-    // 组件卸载时清理事件监听器
     return () => {
-      cleanup()
+      offProgress()
+      offError()
+      offComplete()
+    }
+  }, [])
+
+  useEffect(() => {
+    // 没有影响使用的错误时自动进入首页
+    if (status.ready && status.errors.length === 0) {
+      navigate("/home")
+    }
+  }, [status, navigate])
+
+  const retry = async () => {
+    setNotice("")
+    try {
+      await RetryInitialization()
+      setStatus((current) => ({ ...current, errors: [], running: true, done: false }))
+    } catch (error) {
+      setNotice(String(error))
     }
-  }, [navigate])
-
-  if (isLoading) {
-    return (
-      <div className="min-h-screen w-full flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100 dark:from-gray-900 dark:to-gray-800">
-        <div className="flex flex-col items-center gap-4">
-          <Spinner className="size-8 text-primary" />
-          <p className="text-lg text-muted-foreground">加载中...</p>
-        </div>
-      </div>
-    )
   }
 
-  // 如果有错误，显示错误面板
-  if (errors.length > 0) {
-    return (
-      <div className="min-h-screen w-full flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100 dark:from-gray-900 dark:to-gray-800 p-4">
-        <div className="flex flex-col items-center gap-6 max-w-2xl w-full">
-          <Card className="w-full shadow-xl">
-            <CardHeader className="text-center">
-              <CardTitle className="text-3xl font-bold">初始化出现问题</CardTitle>
-              <CardDescription className="text-lg">
-                应用在初始化过程中遇到了一些问题
-              </CardDescription>
-            </CardHeader>
-            <CardContent className="flex flex-col gap-4">
-              <div className="rounded-lg border border-red-200 bg-red-50 p-4 dark:border-red-800 dark:bg-red-950">
-                <h3 className="font-medium text-red-800 dark:text-red-200">错误详情</h3>
-                <p className="text-sm text-red-700 dark:text-red-300">
-                  以下是初始化过程中遇到的错误：
-                </p>
-              </div>
-              
+  const handle = (error: StartupError) => {
+    switch (error.action) {
+      case "retry":
+        retry()
+        break
+      case "open-settings":
+        navigate("/settings")
+        break
+      case "restart":
+        setNotice("请关闭并重新打开应用")
+        break
+    }
+  }
+
+  const actionLabel: Record<StartupError["action"], string> = {
+    retry: "重试",
+    "open-settings": "打开设置",
+    restart: "需要重启",
+  }
+
+  return (
+    <div className="min-h-screen w-full flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100 dark:from-gray-900 dark:to-gray-800 p-4">
+      <div className="flex flex-col items-center gap-6 max-w-2xl w-full">
+        <Card className="w-full shadow-xl">
+          <CardHeader className="text-center">
+            <CardTitle className="text-3xl font-bold">
+              {status.errors.length > 0 ? "初始化出现问题" : "欢迎使用小红书应用"}
+            </CardTitle>
+            <CardDescription className="text-lg">
+              {status.running ? "正在准备运行环境，首次启动需要下载浏览器" : "您的社交媒体管理和分析平台"}
+            </CardDescription>
+          </CardHeader>
+          <CardContent className="flex flex-col gap-4">
+            <div className="space-y-2">
+              {status.stages.map((stage) => (
+                <div key={stage.stage} className="flex flex-col gap-1 text-sm">
+                  <div className="flex items-center gap-2">
+                    {stage.done ? (
+                      <span className="text-green-600">✓</span>
+                    ) : status.errors.some((error) => error.stage === stage.stage) ? (
+                      <span className="text-red-600">✗</span>
+                    ) : (
+                      <Spinner className="size-4 text-primary" />
+                    )}
+                    <span>{stageNames[stage.stage] ?? stage.stage}</span>
+                    <span className="text-muted-foreground">{stage.message}</span>
+                    {!stage.done && stage.total > 0 && (
+                      <span className="ml-auto text-muted-foreground">
+                        {formatBytes(stage.bytes)} / {formatBytes(stage.total)}
+                      </span>
+                    )}
+                  </div>
+                  {!stage.done && stage.percent >= 0 && (
+                    <div className="h-1.5 w-full rounded bg-muted">
+                      <div className="h-1.5 rounded bg-primary" style={{ width: `${stage.percent}%` }} />
+                    </div>
+                  )}
+                </div>
+              ))}
+            </div>
+
+            {status.errors.length > 0 && (
               <div className="space-y-2 max-h-60 overflow-y-auto">
-                {errors.map((error, index) => (
-                  <div 
-                    key={index} 
+                {status.errors.map((error, index) => (
+                  <div
+                    key={index}
                     className="rounded-lg border border-red-200 bg-red-50 p-3 text-sm dark:border-red-800 dark:bg-red-950"
                   >
-                    <p className="text-red-800 dark:text-red-200 break-words">
-                      {index + 1}. {error}
-                    </p>
+                    <div className="flex items-center gap-2">
+                      <p className="font-medium text-red-800 dark:text-red-200">
+                        {stageNames[error.stage] ?? error.stage}失败（{error.code}）
+                      </p>
+                      <Button
+                        size="sm"
+                        variant="outline"
+                        className="ml-auto"
+                        disabled={status.running || error.action === "restart"}
+                        onClick={() => handle(error)}
+                      >
+                        {actionLabel[error.action]}
+                      </Button>
+                    </div>
+                    <p className="text-red-700 dark:text-red-300 break-words">{error.message}</p>
+                    <p className="text-muted-foreground">{error.hint}</p>
                   </div>
                 ))}
               </div>
-              
+            )}
+
+            {notice && <p className="text-sm text-red-700 dark:text-red-300">{notice}</p>}
+
+            {status.done && status.errors.length > 0 && (
               <div className="flex flex-col sm:flex-row gap-3 pt-4">
-                <Button 
-                  onClick={() => window.location.reload()} 
-                  className="w-full"
-                >
+                <Button onClick={retry} className="w-full">
                   重新尝试
                 </Button>
-                <Button 
-                  variant="outline" 
-                  asChild 
-                  className="w-full"
-                >
-                  <Link to="/home">
-                    忽略并继续
-                  </Link>
+                <Button variant="outline" asChild className="w-full">
+                  <Link to="/home">忽略并继续</Link>
                 </Button>
               </div>
-            </CardContent>
-          </Card>
-          
-          <div className="text-center text-sm text-muted-foreground">
-            <p>© 2023 小红书应用. 保留所有权利.</p>
-          </div>
-        </div>
-      </div>
-    )
-  }
-
-  // 正常情况下的欢迎页面
-  return (
-    <div className="min-h-screen w-full flex items-center justify-center bg-gradient-to-br from-blue-50 to-indigo-100 dark:from-gray-900 dark:to-gray-800">
-      <div className="flex flex-col items-center gap-8 p-8 max-w-md w-full">
-        <Card className="w-full shadow-xl">
-          <CardHeader className="text-center">
-            <CardTitle className="text-3xl font-bold">欢迎使用小红书应用</CardTitle>
-            <CardDescription className="text-lg">
-              您的社交媒体管理和分析平台
-            </CardDescription>
-          </CardHeader>
-          <CardContent className="flex flex-col items-center gap-6">
-            <p className="text-center text-muted-foreground">
-              点击下方按钮开始探索应用的所有功能
-            </p>
-            <Button asChild size="lg" className="w-full">
-              <Link to="/home">
-                开始使用
-              </Link>
-            </Button>
+            )}
           </CardContent>
         </Card>
-        
+
         <div className="text-center text-sm text-muted-foreground">
           <p>© 2023 小红书应用. 保留所有权利.</p>
         </div>
       </div>
     </div>
   )
-}
\ No newline at end of file
+}
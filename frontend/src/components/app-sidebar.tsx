@@ -48,6 +48,11 @@ const data = {
       url: "/lifecycle",
       icon: IconListDetails,
     },
+    {
+      title: "Logs",
+      url: "/logs",
+      icon: IconReport,
+    },
     {
       title: "Analytics",
       url: "/analytics",
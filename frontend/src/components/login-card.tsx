@@ -0,0 +1,87 @@
+import * as React from "react"
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Button } from "@/components/ui/button"
+import { EventsOn, LogPrint } from "../../wailsjs/runtime"
+import { CancelLogin, Logout, StartLogin } from "../../wailsjs/go/xiaohongshu/Xiaohongshu"
+
+type LoginStatus = {
+  state: "waiting" | "scanned" | "confirmed" | "expired" | "refreshed" | "failed"
+  qrCode?: string
+  message?: string
+}
+
+// 各登录状态的提示文字
+const stateText: Record<LoginStatus["state"], string> = {
+  waiting: "请使用小红书 App 扫码登录",
+  scanned: "扫码成功，请在手机上确认",
+  confirmed: "登录成功",
+  expired: "二维码已过期",
+  refreshed: "二维码已刷新，请重新扫码",
+  failed: "登录失败",
+}
+
+export function LoginCard() {
+  const [status, setStatus] = React.useState<LoginStatus | null>(null)
+  const [qrCode, setQrCode] = React.useState("")
+
+  React.useEffect(() => {
+    const offStatus = EventsOn("login-status", (next: LoginStatus) => {
+      setStatus(next)
+      if (next.qrCode) {
+        setQrCode(next.qrCode)
+      }
+    })
+    const offLogout = EventsOn("user-logged-out", () => {
+      setStatus(null)
+      setQrCode("")
+    })
+    return () => {
+      offStatus()
+      offLogout()
+    }
+  }, [])
+
+  const start = async () => {
+    try {
+      setQrCode("")
+      await StartLogin()
+    } catch (error) {
+      LogPrint("扫码登录启动失败: " + error)
+    }
+  }
+
+  const logout = async () => {
+    try {
+      await Logout()
+    } catch (error) {
+      LogPrint("退出登录失败: " + error)
+    }
+  }
+
+  const pending = status !== null && status.state !== "confirmed" && status.state !== "failed"
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>账号登录</CardTitle>
+        <CardDescription>
+          {status ? stateText[status.state] : "扫码登录小红书账号"}
+          {status?.message && `（${status.message}）`}
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {pending && qrCode && (
+          <img src={qrCode} alt="登录二维码" className="w-40 h-40 mb-4" />
+        )}
+        <div className="flex gap-2">
+          {pending ? (
+            <Button variant="outline" onClick={() => CancelLogin()}>取消</Button>
+          ) : (
+            <Button onClick={start}>扫码登录</Button>
+          )}
+          <Button variant="outline" onClick={logout}>退出登录</Button>
+        </div>
+      </CardContent>
+    </Card>
+  )
+}
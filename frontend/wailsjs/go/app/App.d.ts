@@ -2,6 +2,30 @@
 // This file is automatically generated. DO NOT EDIT
 import {context} from '../models';
 
+export function GetConfig():Promise<Record<string, any>>;
+
+export function GetLogLevel():Promise<string>;
+
+export function GetLogs(arg1:number,arg2:string):Promise<Array<Record<string, any>>>;
+
+export function GetSelectorMetrics():Promise<Array<Record<string, any>>>;
+
+export function GetSelectors():Promise<Record<string, any>>;
+
+export function GetStartupStatus():Promise<Record<string, any>>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function QueryEvents(arg1:string,arg2:string,arg3:number,arg4:number,arg5:number):Promise<Array<Record<string, any>>>;
+
+export function RetryInitialization():Promise<void>;
+
+export function SaveConfig(arg1:Record<string, any>):Promise<Record<string, any>>;
+
+export function SetLogLevel(arg1:string):Promise<void>;
+
 export function Startup(arg1:context.Context):Promise<void>;
+
+export function StopLogStream():Promise<void>;
+
+export function StreamLogs(arg1:string):Promise<void>;
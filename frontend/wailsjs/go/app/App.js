@@ -2,10 +2,58 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function GetConfig() {
+  return window['go']['app']['App']['GetConfig']();
+}
+
+export function GetLogLevel() {
+  return window['go']['app']['App']['GetLogLevel']();
+}
+
+export function GetLogs(arg1, arg2) {
+  return window['go']['app']['App']['GetLogs'](arg1, arg2);
+}
+
+export function GetSelectorMetrics() {
+  return window['go']['app']['App']['GetSelectorMetrics']();
+}
+
+export function GetSelectors() {
+  return window['go']['app']['App']['GetSelectors']();
+}
+
+export function GetStartupStatus() {
+  return window['go']['app']['App']['GetStartupStatus']();
+}
+
 export function Greet(arg1) {
   return window['go']['app']['App']['Greet'](arg1);
 }
 
+export function QueryEvents(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['app']['App']['QueryEvents'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function RetryInitialization() {
+  return window['go']['app']['App']['RetryInitialization']();
+}
+
+export function SaveConfig(arg1) {
+  return window['go']['app']['App']['SaveConfig'](arg1);
+}
+
+export function SetLogLevel(arg1) {
+  return window['go']['app']['App']['SetLogLevel'](arg1);
+}
+
 export function Startup(arg1) {
   return window['go']['app']['App']['Startup'](arg1);
 }
+
+export function StopLogStream() {
+  return window['go']['app']['App']['StopLogStream']();
+}
+
+export function StreamLogs(arg1) {
+  return window['go']['app']['App']['StreamLogs'](arg1);
+}
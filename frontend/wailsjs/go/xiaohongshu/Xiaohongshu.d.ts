@@ -2,14 +2,40 @@
 // This file is automatically generated. DO NOT EDIT
 import {context} from '../models';
 
+export function CancelLogin():Promise<void>;
+
+export function CheckSelectors(arg1:string):Promise<Array<Record<string, any>>>;
+
+export function Export(arg1:string,arg2:Record<string, any>,arg3:string,arg4:string):Promise<Record<string, any>>;
+
+export function ExportHar():Promise<string>;
+
+export function ExtractKeyframes(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number):Promise<Array<Record<string, any>>>;
+
+export function FindSimilarImages(arg1:string,arg2:number):Promise<Array<Record<string, any>>>;
+
+export function GetCaptures():Promise<Array<Record<string, any>>>;
+
+export function GetImageTexts(arg1:string):Promise<Array<Record<string, any>>>;
+
 export function GetItems():Promise<Array<Record<string, any>>>;
 
-export function NextPage():Promise<void>;
+export function GetKeyframes(arg1:string):Promise<Array<Record<string, any>>>;
+
+export function GetNoteAnalytics(arg1:string):Promise<Record<string, any>>;
 
-export function OnDomReady(arg1:context.Context):Promise<void>;
+export function GetOCRProgress():Promise<Record<string, any>>;
+
+export function Logout():Promise<void>;
+
+export function NextPage():Promise<void>;
 
 export function OnItemClick(arg1:number):Promise<void>;
 
 export function Refresh():Promise<void>;
 
+export function SearchNotes(arg1:string,arg2:number):Promise<Array<Record<string, any>>>;
+
+export function StartLogin():Promise<void>;
+
 export function Startup(arg1:context.Context):Promise<void>;
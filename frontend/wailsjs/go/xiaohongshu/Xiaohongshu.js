@@ -2,16 +2,60 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelLogin() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['CancelLogin']();
+}
+
+export function CheckSelectors(arg1) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['CheckSelectors'](arg1);
+}
+
+export function Export(arg1, arg2, arg3, arg4) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['Export'](arg1, arg2, arg3, arg4);
+}
+
+export function ExportHar() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['ExportHar']();
+}
+
+export function ExtractKeyframes(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['ExtractKeyframes'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function FindSimilarImages(arg1, arg2) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['FindSimilarImages'](arg1, arg2);
+}
+
+export function GetCaptures() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['GetCaptures']();
+}
+
+export function GetImageTexts(arg1) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['GetImageTexts'](arg1);
+}
+
 export function GetItems() {
   return window['go']['xiaohongshu']['Xiaohongshu']['GetItems']();
 }
 
-export function NextPage() {
-  return window['go']['xiaohongshu']['Xiaohongshu']['NextPage']();
+export function GetKeyframes(arg1) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['GetKeyframes'](arg1);
+}
+
+export function GetNoteAnalytics(arg1) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['GetNoteAnalytics'](arg1);
+}
+
+export function GetOCRProgress() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['GetOCRProgress']();
 }
 
-export function OnDomReady(arg1) {
-  return window['go']['xiaohongshu']['Xiaohongshu']['OnDomReady'](arg1);
+export function Logout() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['Logout']();
+}
+
+export function NextPage() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['NextPage']();
 }
 
 export function OnItemClick(arg1) {
@@ -22,6 +66,14 @@ export function Refresh() {
   return window['go']['xiaohongshu']['Xiaohongshu']['Refresh']();
 }
 
+export function SearchNotes(arg1, arg2) {
+  return window['go']['xiaohongshu']['Xiaohongshu']['SearchNotes'](arg1, arg2);
+}
+
+export function StartLogin() {
+  return window['go']['xiaohongshu']['Xiaohongshu']['StartLogin']();
+}
+
 export function Startup(arg1) {
   return window['go']['xiaohongshu']['Xiaohongshu']['Startup'](arg1);
 }
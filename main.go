@@ -3,9 +3,17 @@ package main
 import (
 	"context"
 	"embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"xiaohongshu/app/binds/app"
+	"xiaohongshu/app/binds/scheduler"
 	"xiaohongshu/app/binds/xiaohongshu"
 	"xiaohongshu/app/infra/app_context"
+	"xiaohongshu/app/infra/config"
+	"xiaohongshu/app/infra/container"
+	"xiaohongshu/app/services/xiaohongshu/har"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -18,14 +26,52 @@ var assets embed.FS
 //go:embed all:scripts
 var script embed.FS
 
+// parseHarFlags 从启动参数中只读取 -har 和 -har-file，覆盖配置文件中的 har 配置
+// 例如 -har=record 或 --har replay -har-file=session.har。wails 和系统传入的其他参数
+// （如 macOS 的 -psn_…）直接忽略，不影响之后的参数；未指定的项返回空字符串
+func parseHarFlags(args []string) (har.Options, error) {
+	var options har.Options
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "har" && name != "har-file") {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+				return options, fmt.Errorf("flag -%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "har" {
+			options.Mode = value
+		} else {
+			options.File = value
+		}
+	}
+	if options.Mode != "" && !slices.Contains(config.HarModes, options.Mode) {
+		return options, fmt.Errorf("invalid -har %q, expected off, record or replay", options.Mode)
+	}
+	return options, nil
+}
+
 func main() {
 	// Create an instance of the app structure
-	appContext := app_context.NewContext()
+	deps := container.New()
+	// 包级日志器写入应用容器的日志
+	deps.Logger.SetDefault()
+	appContext := app_context.NewContext(deps)
 	appBind := app.NewApp(appContext)
-	xiaohongshuBind := xiaohongshu.NewXiaohongshu(appContext, script)
+	harFlags, err := parseHarFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	xiaohongshuBind := xiaohongshu.NewXiaohongshu(appContext, script, harFlags)
+	schedulerBind := scheduler.NewScheduler(appContext)
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "xiaohongshu",
 		Width:  1024,
 		Height: 768,
@@ -37,20 +83,23 @@ func main() {
 			appContext.OnStartup(ctx)
 			appBind.Startup(ctx)
 			xiaohongshuBind.Startup(ctx)
+			schedulerBind.Startup(ctx)
 		},
 		OnShutdown: func(ctx context.Context) {
-
+			appContext.OnShutdown(ctx)
 		},
 		OnDomReady: func(ctx context.Context) {
 			go func() {
 				appContext.OnDomReady(ctx)
-				xiaohongshuBind.OnDomReady(ctx)
-				appContext.OnMount(ctx)
+				if appContext.StartupStatus().Ready {
+					appContext.OnMount(ctx)
+				}
 			}()
 		},
 		Bind: []interface{}{
 			appBind,
 			xiaohongshuBind,
+			schedulerBind,
 		},
 
 		Debug: options.Debug{
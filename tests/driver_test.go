@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"xiaohongshu/app/services/xiaohongshu/explore"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/site"
+	"xiaohongshu/tests/harness"
+
+	"golang.org/x/net/html"
+)
+
+// 以下测试使用内存驱动直接解析夹具，不需要浏览器
+
+func TestMemoryExplore(t *testing.T) {
+	doc := harness.Document(t, "explore.html")
+	feeds, err := explore.NewExplore(site.NewDefault(), doc).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if len(feeds) != 3 {
+		t.Fatalf("expected 3 feeds, got %d", len(feeds))
+	}
+	if got := feeds[1].Map(); got["title"] != "十分钟快手早餐" || got["likes"] != "356" || got["avatarUrl"] != "/static/avatar1.png" {
+		t.Fatalf("unexpected feed: %+v", got)
+	}
+
+	// 内存驱动不执行脚本，点击通过 OnClick 观察
+	var clicked *html.Node
+	doc.OnClick = func(node *html.Node) error {
+		clicked = node
+		return nil
+	}
+	if err := feeds[2].Element.Click(context.Background()); err != nil {
+		t.Fatalf("Click: %v", err)
+	}
+	if clicked == nil || clicked.Data != "section" {
+		t.Fatalf("expected the feed section to be clicked, got %+v", clicked)
+	}
+}
+
+func TestMemoryChannel(t *testing.T) {
+	channel := explore.NewChannel(site.NewDefault(), harness.Document(t, "explore.html"))
+	channels, err := channel.Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if len(channels) != 4 || !channels[0].Active() {
+		t.Fatalf("unexpected channels: %+v", channels)
+	}
+	if _, ok := channel.Find("旅行"); !ok {
+		t.Fatalf("expected to find channel 旅行")
+	}
+}
+
+func TestMemoryNote(t *testing.T) {
+	info, err := note.NewNote(site.NewDefault(), harness.Document(t, "notes/note_video.html"), nil).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if info.Video() == nil {
+		t.Fatalf("expected video note")
+	}
+	result := info.Map()
+	if result["title"] != "十分钟快手早餐" || result["commentCount"] != "共 3 条评论" || result["dateAddress"] != "03-15 上海" {
+		t.Fatalf("unexpected note: %+v", result)
+	}
+
+	comments, err := info.Comment().Show()
+	if err != nil {
+		t.Fatalf("Comment.Show: %v", err)
+	}
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(comments))
+	}
+	first := comments[0].Map()
+	if imgs := first["imgs"].([]string); len(imgs) != 1 || imgs[0] != "/static/comment0.png" {
+		t.Fatalf("unexpected comment images: %+v", imgs)
+	}
+	if subs := first["subComments"].([]map[string]interface{}); len(subs) != 1 || subs[0]["author"] != "小红薯A" {
+		t.Fatalf("unexpected sub comments: %+v", subs)
+	}
+}
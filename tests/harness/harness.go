@@ -0,0 +1,185 @@
+// Package harness 离线测试环境：本地 HTTP 服务提供录制好的小红书页面，
+// 通过 Playwright 路由把 https://www.xiaohongshu.com 的请求转发到本地，
+// 使抓取逻辑可以在无网络、无登录的 CI 环境中针对固定页面结构测试。
+//
+// 需要预先安装 Playwright 驱动和 Chromium：
+//
+//	go run github.com/playwright-community/playwright-go/cmd/playwright install --with-deps chromium
+//
+// 未安装时测试会被跳过。
+package harness
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Origin 被替换的线上站点
+const Origin = "https://www.xiaohongshu.com"
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// Routes 请求路径到夹具文件的映射，/explore/<id> 映射到 notes/<id>.html
+var Routes = map[string]string{
+	"/":                            "explore.html",
+	"/explore":                     "explore.html",
+	"/search_result":               "search.html",
+	"/api/sns/web/v2/user/me":      "api/user_me.json",
+	"/api/sns/web/v2/comment/page": "api/comment_page.json",
+}
+
+// Server 提供夹具页面的本地 HTTP 服务
+type Server struct {
+	*httptest.Server
+}
+
+// NewServer 启动夹具服务
+func NewServer() *Server {
+	return &Server{Server: httptest.NewServer(http.HandlerFunc(serveFixture))}
+}
+
+func serveFixture(w http.ResponseWriter, r *http.Request) {
+	name, ok := Routes[r.URL.Path]
+	if !ok && strings.HasPrefix(r.URL.Path, "/explore/") {
+		name, ok = "notes/"+strings.TrimPrefix(r.URL.Path, "/explore/")+".html", true
+	}
+	if !ok && strings.HasPrefix(r.URL.Path, "/static/") {
+		name, ok = "static/pixel.png", true
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := Fixture(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case strings.HasSuffix(name, ".html"):
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	case strings.HasSuffix(name, ".json"):
+		w.Header().Set("Content-Type", "application/json")
+	case strings.HasSuffix(name, ".png"):
+		w.Header().Set("Content-Type", "image/png")
+	}
+	_, _ = w.Write(data)
+}
+
+// Fixture 读取夹具文件，name 相对于 fixtures 目录
+func Fixture(name string) ([]byte, error) {
+	return fs.ReadFile(fixtures, "fixtures/"+name)
+}
+
+// Document 把夹具解析为内存页面，无需浏览器即可运行抓取逻辑
+func Document(t testing.TB, name string) *driver.Document {
+	t.Helper()
+	data, err := Fixture(name)
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	doc, err := driver.ParseHTML(string(data))
+	if err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", name, err)
+	}
+	return doc
+}
+
+// Route 把上下文中对线上站点的请求转发到夹具服务
+func (s *Server) Route(context playwright.BrowserContext) error {
+	return context.Route(Origin+"/**", func(route playwright.Route) {
+		target := s.URL + strings.TrimPrefix(route.Request().URL(), Origin)
+		response, err := http.Get(target)
+		if err != nil {
+			_ = route.Abort()
+			return
+		}
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			_ = route.Abort()
+			return
+		}
+		_ = route.Fulfill(playwright.RouteFulfillOptions{
+			Status:      playwright.Int(response.StatusCode),
+			ContentType: playwright.String(response.Header.Get("Content-Type")),
+			Body:        body,
+		})
+	})
+}
+
+// Harness 一次测试使用的浏览器和夹具服务
+type Harness struct {
+	Server  *Server
+	PW      *playwright.Playwright
+	Browser playwright.Browser
+	Context playwright.BrowserContext
+	Page    playwright.Page
+}
+
+// New 启动无头浏览器和夹具服务，驱动或浏览器不可用时跳过测试
+// 页面注入与应用相同的工具脚本，测试结束时自动关闭
+func New(t testing.TB) *Harness {
+	t.Helper()
+	pw, err := playwright.Run(&playwright.RunOptions{Verbose: false})
+	if err != nil {
+		t.Skipf("playwright driver is not installed: %v", err)
+	}
+	browser, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(true),
+	})
+	if err != nil {
+		_ = pw.Stop()
+		t.Skipf("chromium is not available: %v", err)
+	}
+	h := &Harness{Server: NewServer(), PW: pw, Browser: browser}
+	t.Cleanup(h.Close)
+
+	h.Context, err = browser.NewContext(playwright.BrowserNewContextOptions{
+		Viewport: &playwright.Size{Width: 1366, Height: 768},
+	})
+	if err != nil {
+		t.Fatalf("failed to create context: %v", err)
+	}
+	if err := h.Server.Route(h.Context); err != nil {
+		t.Fatalf("failed to route %s: %v", Origin, err)
+	}
+	h.Page, err = h.Context.NewPage()
+	if err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	js := scripts.ToolJs
+	if err := h.Page.AddInitScript(playwright.Script{Content: &js}); err != nil {
+		t.Fatalf("failed to add tool script: %v", err)
+	}
+	return h
+}
+
+// Goto 打开线上地址对应的夹具页面，path 如 /explore
+func (h *Harness) Goto(t testing.TB, path string) {
+	t.Helper()
+	if _, err := h.Page.Goto(Origin + path); err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+}
+
+// Close 关闭浏览器和夹具服务
+func (h *Harness) Close() {
+	if h.Browser != nil {
+		_ = h.Browser.Close()
+	}
+	if h.PW != nil {
+		_ = h.PW.Stop()
+	}
+	h.Server.Close()
+}
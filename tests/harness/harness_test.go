@@ -0,0 +1,39 @@
+package harness
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServerRoutes(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	cases := map[string]string{
+		"/explore":                "exploreFeeds",
+		"/explore/note_video":     `data-type="video"`,
+		"/search_result?keyword=": "feeds-container",
+		"/api/sns/web/v2/user/me": `"user_id"`,
+	}
+	for path, want := range cases {
+		response, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s: status %d, body does not contain %q", path, response.StatusCode, want)
+		}
+	}
+	response, err := http.Get(server.URL + "/explore/missing")
+	if err != nil {
+		t.Fatalf("GET missing: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for missing note, got %d", response.StatusCode)
+	}
+}
@@ -0,0 +1,556 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"xiaohongshu/app/infra/eventbus"
+	"xiaohongshu/app/pkg/utils"
+	"xiaohongshu/app/services/xiaohongshu/driver"
+	"xiaohongshu/app/services/xiaohongshu/explore"
+	"xiaohongshu/app/services/xiaohongshu/note"
+	"xiaohongshu/app/services/xiaohongshu/pacing"
+	"xiaohongshu/app/services/xiaohongshu/scripts"
+	"xiaohongshu/app/services/xiaohongshu/selectors"
+	"xiaohongshu/app/services/xiaohongshu/site"
+	"xiaohongshu/tests/harness"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// offlineHarness 离线环境和抓取使用的依赖
+type offlineHarness struct {
+	*harness.Harness
+	site *site.Site
+}
+
+// newOfflineHarness 启动离线环境，节奏控制去掉随机停顿以加快测试
+func newOfflineHarness(t *testing.T) *offlineHarness {
+	registry := selectors.MustNewRegistry()
+	return &offlineHarness{
+		Harness: harness.New(t),
+		site:    site.New(registry, selectors.NewMetrics(registry), pacing.NewPacer(pacing.Config{})),
+	}
+}
+
+func TestOfflineExplore(t *testing.T) {
+	h := newOfflineHarness(t)
+	h.Goto(t, "/explore")
+
+	feeds, err := explore.NewExplore(h.site, driver.NewPage(h.Page)).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if len(feeds) != 3 {
+		t.Fatalf("expected 3 feeds, got %d", len(feeds))
+	}
+	first := feeds[0].Map()
+	want := map[string]interface{}{
+		"index":         0,
+		"title":         "春日穿搭分享",
+		"coverImageUrl": "/static/cover0.png",
+		"username":      "小红薯A",
+		"avatarUrl":     "/static/avatar0.png",
+		"likes":         "1024",
+	}
+	for key, value := range want {
+		if first[key] != value {
+			t.Errorf("feed[0].%s = %v, want %v", key, first[key], value)
+		}
+	}
+}
+
+func TestOfflineSearch(t *testing.T) {
+	h := newOfflineHarness(t)
+	h.Goto(t, "/search_result?keyword=露营")
+
+	feeds, err := explore.NewExploreWithLocator(h.site, driver.NewLocator(h.Page.Locator(".feeds-container"))).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if len(feeds) != 2 || feeds[0].Title.Text != "露营装备怎么选" {
+		t.Fatalf("unexpected search feeds: %+v", feeds)
+	}
+}
+
+func TestOfflineChannel(t *testing.T) {
+	h := newOfflineHarness(t)
+	h.Goto(t, "/explore")
+
+	channel := explore.NewChannel(h.site, driver.NewPage(h.Page))
+	channels, err := channel.Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if len(channels) != 4 {
+		t.Fatalf("expected 4 channels, got %d", len(channels))
+	}
+	if !channels[0].Active() || channels[1].Active() {
+		t.Fatalf("expected only the first channel to be active")
+	}
+	if _, ok := channel.Find("美食"); !ok {
+		t.Fatalf("expected to find channel 美食")
+	}
+}
+
+func TestOfflineNote(t *testing.T) {
+	cases := []struct {
+		id        string
+		noteType  string
+		title     string
+		withVideo bool
+	}{
+		{id: "note_image", noteType: "default", title: "春日穿搭分享"},
+		{id: "note_video", noteType: "video", title: "十分钟快手早餐", withVideo: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.id, func(t *testing.T) {
+			h := newOfflineHarness(t)
+			h.Goto(t, "/explore/"+tc.id)
+
+			info, err := note.NewNote(h.site, driver.NewPage(h.Page), nil).Show()
+			if err != nil {
+				t.Fatalf("Show: %v", err)
+			}
+			result := info.Map()
+			if result["type"] != tc.noteType || result["title"] != tc.title {
+				t.Fatalf("unexpected note: %+v", result)
+			}
+			if (info.Video() != nil) != tc.withVideo {
+				t.Fatalf("video = %v, want video %v", info.Video(), tc.withVideo)
+			}
+			if !tc.withVideo {
+				images := strings.Join(result["images"].([]string), ",")
+				for _, src := range []string{"/static/slide0.png", "/static/slide1.png", "/static/slide2.png"} {
+					if !strings.Contains(images, src) {
+						t.Errorf("expected image %s in %s", src, images)
+					}
+				}
+			}
+
+			comments, err := info.Comment().Show()
+			if err != nil {
+				t.Fatalf("Comment.Show: %v", err)
+			}
+			if len(comments) != 2 {
+				t.Fatalf("expected 2 comments, got %d", len(comments))
+			}
+			first := comments[0].Map()
+			if first["author"] != "路人甲" || first["content"] != "好看！求链接" {
+				t.Fatalf("unexpected comment: %+v", first)
+			}
+			if subs := first["subComments"].([]map[string]interface{}); len(subs) != 1 || subs[0]["content"] != "主页置顶有~" {
+				t.Fatalf("unexpected sub comments: %+v", subs)
+			}
+		})
+	}
+}
+
+func TestOfflineDOMWatch(t *testing.T) {
+	h := newOfflineHarness(t)
+	watchJs, err := utils.ReadEmbeddedFile(script, "scripts/dom_watch.js")
+	if err != nil {
+		t.Fatalf("read watch script: %v", err)
+	}
+	h.Goto(t, "/explore")
+	watcher := scripts.NewDOMWatcher(h.Page)
+	if err := watcher.Start(watchJs); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = watcher.Close() })
+
+	notes := make(chan scripts.DOMEvent, 4)
+	if _, err := watcher.Watch(scripts.WatchSpec{Selector: ".note-detail-mask"}, func(event scripts.DOMEvent) { notes <- event }); err != nil {
+		t.Fatalf("Watch note: %v", err)
+	}
+	channels := make(chan scripts.DOMEvent, 16)
+	channelWatch, err := watcher.Watch(scripts.WatchSpec{Selector: ".channel", Attributes: []string{"class"}}, func(event scripts.DOMEvent) { channels <- event })
+	if err != nil {
+		t.Fatalf("Watch channels: %v", err)
+	}
+	next := func(ch chan scripts.DOMEvent, what string) scripts.DOMEvent {
+		t.Helper()
+		select {
+		case event := <-ch:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s was not observed", what)
+			return scripts.DOMEvent{}
+		}
+	}
+	// 已存在的频道作为初始元素通知
+	for i := 0; i < 4; i++ {
+		if event := next(channels, "initial channel"); event.Type != scripts.DOMAdded || !event.Initial {
+			t.Fatalf("unexpected initial event: %+v", event)
+		}
+	}
+	if _, err := h.Page.Evaluate(`() => document.querySelectorAll('.channel')[1].classList.add('active')`); err != nil {
+		t.Fatalf("toggle channel: %v", err)
+	}
+	toggled := next(channels, "channel class change")
+	if toggled.Type != scripts.DOMAttribute || toggled.Attribute != "class" || toggled.Value != "channel active" {
+		t.Fatalf("unexpected attribute event: %+v", toggled)
+	}
+	if text, err := watcher.Locator(toggled.Handle).TextContent(); err != nil || text != "穿搭" {
+		t.Fatalf("handle resolved to %q: %v", text, err)
+	}
+
+	feeds, err := explore.NewExplore(h.site, driver.NewPage(h.Page)).Show()
+	if err != nil || len(feeds) == 0 {
+		t.Fatalf("Show: %v (%d feeds)", err, len(feeds))
+	}
+	if err := feeds[0].Cover.Selector.Click(); err != nil {
+		t.Fatalf("open note: %v", err)
+	}
+	opened := next(notes, "note modal")
+	if opened.Type != scripts.DOMAdded || !strings.Contains(opened.HTML, "note-detail-mask") {
+		t.Fatalf("unexpected note event: %+v", opened)
+	}
+	if err := h.Page.Locator(".note-detail-mask .close-circle").Click(); err != nil {
+		t.Fatalf("close note: %v", err)
+	}
+	if closed := next(notes, "note modal removal"); closed.Type != scripts.DOMRemoved || closed.Handle != opened.Handle {
+		t.Fatalf("unexpected removal: %+v (opened %s)", closed, opened.Handle)
+	}
+
+	// 取消的监听不再收到事件，其余监听在导航后重新生效
+	if err := watcher.Unwatch(channelWatch); err != nil {
+		t.Fatalf("Unwatch: %v", err)
+	}
+	h.Goto(t, "/explore")
+	feeds, err = explore.NewExplore(h.site, driver.NewPage(h.Page)).Show()
+	if err != nil || len(feeds) == 0 {
+		t.Fatalf("Show after navigation: %v (%d feeds)", err, len(feeds))
+	}
+	if err := feeds[0].Cover.Selector.Click(); err != nil {
+		t.Fatalf("open note after navigation: %v", err)
+	}
+	if event := next(notes, "note modal after navigation"); event.Type != scripts.DOMAdded {
+		t.Fatalf("unexpected note event after navigation: %+v", event)
+	}
+	select {
+	case event := <-channels:
+		t.Fatalf("unwatched channel event: %+v", event)
+	default:
+	}
+}
+
+func TestOfflineNoteLifecycle(t *testing.T) {
+	h := newOfflineHarness(t)
+	watchJs, err := utils.ReadEmbeddedFile(script, "scripts/dom_watch.js")
+	if err != nil {
+		t.Fatalf("read watch script: %v", err)
+	}
+	h.Goto(t, "/explore")
+	watcher := scripts.NewDOMWatcher(h.Page)
+	if err := watcher.Start(watchJs); err != nil {
+		t.Fatalf("Start watcher: %v", err)
+	}
+	t.Cleanup(func() { _ = watcher.Close() })
+	bus := eventbus.New()
+	opened := make(chan note.NoteDetail, 4)
+	closed := make(chan note.NoteDetail, 4)
+	_ = bus.Subscribe(eventbus.TopicNoteOpened, func(detail note.NoteDetail) { opened <- detail })
+	_ = bus.Subscribe(eventbus.TopicNoteClosed, func(detail note.NoteDetail) { closed <- detail })
+	lifecycle := note.NewLifecycle(h.site, h.Page, watcher, nil, bus, "note-detail-mask")
+	if err := lifecycle.Start(); err != nil {
+		t.Fatalf("Start lifecycle: %v", err)
+	}
+	t.Cleanup(func() { _ = lifecycle.Stop() })
+	next := func(ch chan note.NoteDetail, what string) note.NoteDetail {
+		t.Helper()
+		select {
+		case detail := <-ch:
+			return detail
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s was not published", what)
+			return note.NoteDetail{}
+		}
+	}
+
+	feeds, err := explore.NewExplore(h.site, driver.NewPage(h.Page)).Show()
+	if err != nil || len(feeds) == 0 {
+		t.Fatalf("Show: %v (%d feeds)", err, len(feeds))
+	}
+	info, err := lifecycle.WaitOpened(context.Background(), feeds[0].Cover.Click)
+	if err != nil {
+		t.Fatalf("WaitOpened: %v", err)
+	}
+	if info.Map()["title"] != "春日穿搭分享" {
+		t.Fatalf("unexpected note: %+v", info.Map())
+	}
+	detail := next(opened, "note:opened")
+	if detail.ID != "note_image" || detail.Title != "春日穿搭分享" {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if err := h.Page.Locator(".note-detail-mask .close-circle").Click(); err != nil {
+		t.Fatalf("close note: %v", err)
+	}
+	if detail := next(closed, "note:closed"); detail.ID != "note_image" {
+		t.Fatalf("unexpected closed note: %+v", detail)
+	}
+	// 在浏览器中直接打开的笔记同样被提取，且只发布一次
+	h.Goto(t, "/explore/note_video")
+	if detail := next(opened, "note:opened after navigation"); detail.ID != "note_video" || detail.Type != "video" {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	select {
+	case detail := <-opened:
+		t.Fatalf("note opened twice: %+v", detail)
+	case <-time.After(500 * time.Millisecond):
+	}
+	if current, ok := lifecycle.Current(); !ok || current.ID != "note_video" {
+		t.Fatalf("unexpected current note: %+v %v", current, ok)
+	}
+	// 再次打开当前的笔记时立即返回，不等待超时
+	started := time.Now()
+	info, err = lifecycle.WaitOpened(context.Background(), func(ctx context.Context) error {
+		h.Goto(t, "/explore/note_video")
+		return nil
+	})
+	if err != nil || time.Since(started) > 5*time.Second {
+		t.Fatalf("WaitOpened on current note: %v after %s", err, time.Since(started))
+	}
+	if info.Map()["type"] != "video" {
+		t.Fatalf("unexpected note: %+v", info.Map())
+	}
+	// 停止后不再跟踪地址变化
+	if err := lifecycle.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	h.Goto(t, "/explore/note_image")
+	select {
+	case detail := <-opened:
+		t.Fatalf("note opened after Stop: %+v", detail)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestOfflineSwiper(t *testing.T) {
+	h := newOfflineHarness(t)
+	h.Goto(t, "/explore/note_image")
+	info, err := note.NewNote(h.site, driver.NewPage(h.Page), nil).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	swiper := info.Swiper()
+	if swiper == nil {
+		t.Fatal("expected swiper on image note")
+	}
+	if count, err := swiper.Count(); err != nil || count != 3 {
+		t.Fatalf("Count = %d, %v; want 3 without duplicated slides", count, err)
+	}
+	current := func() int {
+		t.Helper()
+		index, err := swiper.Current()
+		if err != nil {
+			t.Fatalf("Current: %v", err)
+		}
+		return index
+	}
+	if err := swiper.Next(context.Background()); err != nil || current() != 1 {
+		t.Fatalf("Next: %v (current %d)", err, current())
+	}
+	if err := swiper.Prev(context.Background()); err != nil || current() != 0 {
+		t.Fatalf("Prev: %v (current %d)", err, current())
+	}
+	if err := swiper.GoTo(context.Background(), 2); err != nil || current() != 2 {
+		t.Fatalf("GoTo: %v (current %d)", err, current())
+	}
+
+	slides, err := swiper.AllImages(context.Background())
+	if err != nil {
+		t.Fatalf("AllImages: %v", err)
+	}
+	want := []note.Slide{
+		{Index: 0, Image: "/static/slide0.png"},
+		{Index: 1, Image: "/static/slide1.png", LiveVideo: "/static/live1.mp4"},
+		{Index: 2, Image: "/static/slide2.png"},
+	}
+	if len(slides) != len(want) {
+		t.Fatalf("unexpected slides: %+v", slides)
+	}
+	for i := range want {
+		if !strings.HasSuffix(slides[i].Image, want[i].Image) || !strings.HasSuffix(slides[i].LiveVideo, want[i].LiveVideo) || slides[i].Index != want[i].Index {
+			t.Errorf("slide %d = %+v, want %+v", i, slides[i], want[i])
+		}
+	}
+	if current() != 2 {
+		t.Fatalf("AllImages should restore the current slide, got %d", current())
+	}
+}
+
+func TestOfflineVideoControl(t *testing.T) {
+	h := newOfflineHarness(t)
+	capture := scripts.NewMediaCapture(h.Page)
+	content, err := script.ReadFile("scripts/media_capture.js")
+	if err != nil {
+		t.Fatalf("read media_capture.js: %v", err)
+	}
+	if err := capture.InjectScript(string(content)); err != nil {
+		t.Fatalf("InjectScript: %v", err)
+	}
+	h.Goto(t, "/explore/note_video")
+	info, err := note.NewNote(h.site, driver.NewPage(h.Page), capture).Show()
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	video := info.Video()
+	if video == nil {
+		t.Fatal("expected video on video note")
+	}
+
+	if err := video.SetVolume(0.5); err != nil {
+		t.Fatalf("SetVolume: %v", err)
+	}
+	if err := video.SetVolume(2); err == nil {
+		t.Fatal("SetVolume should reject volume above 1")
+	}
+	if err := video.SetMuted(false); err != nil || video.IsMute() {
+		t.Fatalf("SetMuted: %v (muted %v)", err, video.IsMute())
+	}
+	if err := video.SetPlaybackRate(1.5); err != nil {
+		t.Fatalf("SetPlaybackRate: %v", err)
+	}
+	if state, err := video.State(); err != nil || state != note.StatePaused {
+		t.Fatalf("State = %q, %v; want paused", state, err)
+	}
+	if _, err := video.Duration(); err == nil {
+		t.Fatal("Duration should fail before metadata is loaded")
+	}
+
+	// /static/video.mp4 返回的是图片，播放必然失败
+	states := make(chan note.PlaybackState, 8)
+	cancel, err := video.ListenState(func(change note.StateChange) { states <- change.State })
+	if err != nil {
+		t.Fatalf("ListenState: %v", err)
+	}
+	defer cancel()
+	if err := video.Play(); err == nil {
+		t.Fatal("Play should fail for an undecodable source")
+	}
+	if err := video.WaitUntil(note.StateError, 5*time.Second); err != nil {
+		t.Fatalf("WaitUntil: %v", err)
+	}
+	select {
+	case state := <-states:
+		if state != note.StateError {
+			t.Fatalf("unexpected state change %q", state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no state change received")
+	}
+}
+
+func TestOfflineMediaCaptureRegistry(t *testing.T) {
+	h := newOfflineHarness(t)
+	capture := scripts.NewMediaCapture(h.Page)
+	content, err := script.ReadFile("scripts/media_capture.js")
+	if err != nil {
+		t.Fatalf("read media_capture.js: %v", err)
+	}
+	if err := capture.InjectScript(string(content)); err != nil {
+		t.Fatalf("InjectScript: %v", err)
+	}
+	h.Goto(t, "/explore/note_video")
+	element := h.Page.Locator("video")
+
+	id, err := capture.Start(element)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if again, err := capture.Start(element); err != nil || again != id {
+		t.Fatalf("Start again = %q, %v; want the same id %q", again, err, id)
+	}
+	if status, err := capture.Status(id); err != nil || !status.Running {
+		t.Fatalf("Status = %+v, %v; want running", status, err)
+	}
+	if err := capture.Stop(id); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if status, _ := capture.Status(id); status.Running {
+		t.Fatal("capture should be stopped")
+	}
+	if err := capture.Resume(id); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if err := capture.Destroy(id); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+	if _, err := capture.Status(id); err == nil {
+		t.Fatal("destroyed capture should be unknown")
+	}
+	if err := capture.Stop(id); err == nil {
+		t.Fatal("stopping a destroyed capture should fail")
+	}
+	if _, err := capture.Start(element); err != nil {
+		t.Fatalf("Start after Destroy: %v", err)
+	}
+	if err := capture.Shutdown(); err != nil || len(capture.Captures()) != 0 {
+		t.Fatalf("Shutdown: %v, captures %+v", err, capture.Captures())
+	}
+
+	// 移除监听时使用添加时的同一个函数
+	if err := capture.ListenVideoState(element); err != nil {
+		t.Fatalf("ListenVideoState: %v", err)
+	}
+	if err := capture.RemoveVideoStateListener(element); err != nil {
+		t.Fatalf("RemoveVideoStateListener: %v", err)
+	}
+	if listening, _ := element.Evaluate(`(video) => video.__stateListener !== undefined`, nil); listening != false {
+		t.Fatal("state listener should be removed")
+	}
+}
+
+func TestOfflineMediaCaptureCloseOnSessionSwap(t *testing.T) {
+	h := newOfflineHarness(t)
+	content, err := script.ReadFile("scripts/media_capture.js")
+	if err != nil {
+		t.Fatalf("read media_capture.js: %v", err)
+	}
+	// open 模拟 ExportHar 重新打开会话：新页面上创建新的 MediaCapture 并订阅播放状态
+	var mu sync.Mutex
+	counts := map[string]int{}
+	open := func(name string, page playwright.Page) *scripts.MediaCapture {
+		t.Helper()
+		capture := scripts.NewMediaCapture(page)
+		if err := capture.InjectScript(string(content)); err != nil {
+			t.Fatalf("InjectScript: %v", err)
+		}
+		if _, err := page.Goto(harness.Origin + "/explore/note_video"); err != nil {
+			t.Fatalf("Goto: %v", err)
+		}
+		capture.Subscribe(scripts.TopicVideoState, func(interface{}) {
+			mu.Lock()
+			counts[name]++
+			mu.Unlock()
+		})
+		return capture
+	}
+	notify := func(page playwright.Page) {
+		t.Helper()
+		if _, err := page.Evaluate(`() => window.__onVideoStateChange(false)`); err != nil {
+			t.Fatalf("notify: %v", err)
+		}
+	}
+
+	old := open("old", h.Page)
+	if err := old.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	page, err := h.Context.NewPage()
+	if err != nil {
+		t.Fatalf("NewPage: %v", err)
+	}
+	open("new", page)
+	// 旧页面上迟到的回调和新页面的事件都只由新会话收到一次
+	notify(h.Page)
+	notify(page)
+	mu.Lock()
+	defer mu.Unlock()
+	if counts["old"] != 0 || counts["new"] != 1 {
+		t.Fatalf("unexpected capture events after session swap: %+v", counts)
+	}
+}
@@ -0,0 +1,175 @@
+(function (global) {
+    /**
+     * DOMWatch：按 CSS 选择器监听元素的添加、移除和属性变化，通过 window.__domWatchEmit 通知 Go 端。
+     * 每个监听有独立的 id，重复调用 watch 会替换同 id 的监听，页面导航后由 Go 端重新调用。
+     * 元素首次匹配时分配 data-dom-watch 句柄，Go 端通过 [data-dom-watch="句柄"] 定位元素。
+     */
+    if (global.__DOMWatch) return
+
+    const HANDLE_ATTR = 'data-dom-watch'
+    const HTML_LIMIT = 512 // outerHTML 截取长度
+    let nextHandle = 0
+
+    // Go 端并发处理每次调用，逐个发送以保持事件顺序
+    let queue = Promise.resolve()
+
+    function send(payload) {
+        queue = queue
+            .then(() => global.__domWatchEmit?.(payload))
+            .catch((e) => console.error('dom watch emit failed', e))
+    }
+
+    function handleOf(el) {
+        let handle = el.getAttribute(HANDLE_ATTR)
+        if (!handle) {
+            handle = 'w' + Date.now().toString(36) + '-' + (nextHandle++).toString(36)
+            el.setAttribute(HANDLE_ATTR, handle)
+        }
+        return handle
+    }
+
+    function dataset(el) {
+        const data = {}
+        for (const attr of el.attributes) {
+            if (attr.name.startsWith('data-') && attr.name !== HANDLE_ATTR) {
+                data[attr.name.slice(5)] = attr.value
+            }
+        }
+        return data
+    }
+
+    function snippet(el) {
+        const html = el.outerHTML || ''
+        return html.length > HTML_LIMIT ? html.slice(0, HTML_LIMIT) : html
+    }
+
+    class Watch {
+        constructor(id, { selector, attributes = [] }) {
+            this.id = id
+            this.selector = selector
+            this.attributes = attributes
+            this.tracked = new Set()
+            this.observer = null
+        }
+
+        emit(type, el, extra = {}) {
+            // 移除后元素仍保留句柄，便于与添加事件对应
+            const payload = Object.assign({
+                watchId: this.id,
+                type,
+                selector: this.selector,
+                handle: handleOf(el),
+                html: snippet(el),
+                data: dataset(el),
+            }, extra)
+            send(payload)
+        }
+
+        matches(el) {
+            try {
+                return el.matches(this.selector)
+            } catch (e) {
+                return false
+            }
+        }
+
+        add(el, extra) {
+            if (this.tracked.has(el)) return
+            this.tracked.add(el)
+            this.emit('added', el, extra)
+        }
+
+        // 在 node 及其子孙中查找匹配的元素
+        scan(node, extra) {
+            if (!(node instanceof Element)) return
+            if (this.matches(node)) this.add(node, extra)
+            node.querySelectorAll?.(this.selector).forEach((el) => this.add(el, extra))
+        }
+
+        // 已不在文档中的元素视为移除
+        sweep() {
+            for (const el of this.tracked) {
+                if (!el.isConnected) {
+                    this.tracked.delete(el)
+                    this.emit('removed', el)
+                }
+            }
+        }
+
+        start() {
+            if (this.observer || !document.body) return
+            this.scan(document.body, { initial: true })
+
+            // class 总是监听，元素因类名变化开始或不再匹配选择器时也会通知
+            const filter = Array.from(new Set(['class', ...this.attributes]))
+            this.observer = new MutationObserver((mutations) => {
+                let removed = false
+                for (const mutation of mutations) {
+                    if (mutation.type === 'childList') {
+                        mutation.addedNodes.forEach((node) => this.scan(node))
+                        removed = removed || mutation.removedNodes.length > 0
+                        continue
+                    }
+                    const el = mutation.target
+                    if (mutation.attributeName === HANDLE_ATTR) continue
+                    const matched = this.matches(el)
+                    if (matched && !this.tracked.has(el)) {
+                        this.add(el)
+                    } else if (!matched && this.tracked.has(el)) {
+                        this.tracked.delete(el)
+                        this.emit('removed', el)
+                    } else if (matched && this.attributes.includes(mutation.attributeName)) {
+                        const value = el.getAttribute(mutation.attributeName)
+                        if (value !== mutation.oldValue) {
+                            this.emit('attribute', el, {
+                                attribute: mutation.attributeName,
+                                oldValue: mutation.oldValue ?? '',
+                                value: value ?? '',
+                            })
+                        }
+                    }
+                }
+                if (removed) this.sweep()
+            })
+            this.observer.observe(document.body, {
+                childList: true,
+                subtree: true,
+                attributes: true,
+                attributeFilter: filter,
+                attributeOldValue: true,
+            })
+        }
+
+        destroy() {
+            this.observer?.disconnect()
+            this.observer = null
+            this.tracked.clear()
+        }
+    }
+
+    const watches = new Map()
+
+    global.__DOMWatch = {
+        watch(id, spec) {
+            watches.get(id)?.destroy()
+            const watch = new Watch(id, spec)
+            watches.set(id, watch)
+            watch.start()
+            return id
+        },
+        unwatch(id) {
+            const watch = watches.get(id)
+            if (!watch) return false
+            watch.destroy()
+            watches.delete(id)
+            return true
+        },
+        unwatchAll() {
+            watches.forEach((watch) => watch.destroy())
+            watches.clear()
+        },
+        list() {
+            return Array.from(watches.keys())
+        },
+    }
+})(window);
@@ -1,14 +1,37 @@
 (() => {
     if (window.__MediaCaptureController) return;
+
+    const FRAME_INTERVAL = 1000; // 每秒1帧
+    const FRAME_SCALE = 0.2;     // 截帧缩小到原尺寸的20%
+
+    /**
+     * MediaCaptureController：按采集 id 管理页面上每个视频元素的截帧和音频采集。
+     * 帧和音频通过 window.__onVideoFrame / window.__onVideoAudio 发送，载荷带有采集 id；
+     * 上一帧还没有被 Go 端接收、视频没有可绘制的画面或画面跨域无法读取时记为丢帧。
+     */
     class MediaCaptureController {
         constructor() {
-            this.videoMap = new Map(); // key: videoEl, value: { audioCtx, src, compressor, canvas, ctx, rafId }
+            this.captures = new Map(); // key: 采集 id, value: { video, audioCtx, src, compressor, canvas, ctx, rafId, running, stats }
+        }
+
+        // 视频元素已有的采集 id
+        find(videoEl) {
+            for (const [id, state] of this.captures) {
+                if (state.video === videoEl) return id;
+            }
+            return null;
         }
 
-        async start(videoEl = null) {
+        // 开始采集 videoEl，已在采集时返回已有的 id，已停止时继续采集
+        async start(videoEl, id) {
             if (!videoEl) videoEl = document.querySelector('video');
             if (!videoEl) throw new Error('no video element');
-            if (this.videoMap.has(videoEl)) return; // 已经在采集
+            const existing = this.find(videoEl);
+            if (existing) {
+                this.resume(existing);
+                return existing;
+            }
+            if (!id) id = 'capture-' + Date.now().toString(36);
 
             const video = videoEl;
 
@@ -17,317 +40,279 @@
             video.volume = 1.0;
             video.crossOrigin = 'anonymous';
 
-            // 推荐方案配置
-            const AUDIO_CONFIG = {
+            // 推荐方案配置，可由应用配置 media.audio 覆盖
+            const AUDIO_CONFIG = Object.assign({
                 sampleRate: 24000,       // 24kHz适合语音
                 bitDepth: 16,           // 16位整型
                 enableVAD: true,        // 语音活动检测
                 vadThreshold: 0.001,    // VAD阈值
                 silenceFrames: 10,      // 连续静音帧数阈值
                 bufferSize: 2048        // 缓冲区大小
+            }, (window.__MediaCaptureConfig && window.__MediaCaptureConfig.audio) || {});
+
+            // ================== VIDEO ==================
+            const canvas = document.createElement('canvas');
+            const state = {
+                id,
+                video,
+                audioCtx: new AudioContext({ sampleRate: AUDIO_CONFIG.sampleRate }),
+                src: null,
+                compressor: null,
+                canvas,
+                ctx: canvas.getContext('2d'),
+                rafId: null,
+                running: false,
+                pending: false, // 上一帧是否还在发送
+                lastCaptureTime: 0,
+                bitDepth: AUDIO_CONFIG.bitDepth,
+                stats: { frames: 0, dropped: 0, audioChunks: 0, error: '' },
             };
+            this.captures.set(id, state);
+            video.__captureId = id;
 
-            const audioCtx = new AudioContext({ sampleRate: AUDIO_CONFIG.sampleRate });
-            await audioCtx.resume();
-
+            await state.audioCtx.resume();
             try {
-                // 定义内联 AudioWorklet 处理器
-                const workletCode = `
-                class AudioCompressorProcessor extends AudioWorkletProcessor {
-                    constructor() {
-                        super();
-                        this.energyThreshold = ${AUDIO_CONFIG.vadThreshold};
-                        this.silenceCount = 0;
-                        this.maxSilenceFrames = ${AUDIO_CONFIG.silenceFrames};
-                        this.isActive = false;
-                    }
+                await this.connectAudio(state, AUDIO_CONFIG);
+            } catch (error) {
+                console.warn('AudioWorklet not supported:', error);
+                // 降级处理：暂停音频上下文，只采集画面
+                await state.audioCtx.suspend();
+            }
+            // 等待音频初始化期间可能已被销毁
+            if (this.captures.get(id) !== state) return id;
+            this.run(state);
+            return id;
+        }
 
-                    process(inputs) {
-                        const input = inputs[0];
-                        if (!input || input.length === 0) return true;
-                        
-                        const ch0 = input[0];
-                        
-                        // 语音活动检测
-                        const shouldSend = this.vadCheck(ch0);
-                        
-                        if (shouldSend) {
-                            // 32位浮点转16位整型
-                            const int16Buffer = this.float32ToInt16(ch0);
-                            
-                            this.port.postMessage({
-                                sampleRate: ${AUDIO_CONFIG.sampleRate},
-                                channels: 1,
-                                frames: ch0.length,
-                                buffer: int16Buffer.buffer,
-                                ts: currentTime
-                            });
-                        }
-                        
-                        return true;
-                    }
+        async connectAudio(state, AUDIO_CONFIG) {
+            const { audioCtx, video } = state;
+            // 定义内联 AudioWorklet 处理器
+            const workletCode = `
+            class AudioCompressorProcessor extends AudioWorkletProcessor {
+                constructor() {
+                    super();
+                    this.energyThreshold = ${AUDIO_CONFIG.vadThreshold};
+                    this.silenceCount = 0;
+                    this.maxSilenceFrames = ${AUDIO_CONFIG.silenceFrames};
+                    this.isActive = false;
+                }
+
+                process(inputs) {
+                    const input = inputs[0];
+                    if (!input || input.length === 0) return true;
+                    
+                    const ch0 = input[0];
                     
                     // 语音活动检测
-                    vadCheck(data) {
-                        if (!${AUDIO_CONFIG.enableVAD}) return true;
-                        
-                        let energy = 0;
-                        // 只检查前100个样本以提升性能
-                        for (let i = 0; i < Math.min(data.length, 100); i++) {
-                            energy += Math.abs(data[i]);
-                            if (energy > this.energyThreshold) break;
-                        }
+                    const shouldSend = this.vadCheck(ch0);
+                    
+                    if (shouldSend) {
+                        // 32位浮点转16位整型
+                        const int16Buffer = this.float32ToInt16(ch0);
                         
-                        if (energy > this.energyThreshold) {
-                            this.silenceCount = 0;
-                            this.isActive = true;
-                            return true;
-                        } else {
-                            this.silenceCount++;
-                            if (this.silenceCount > this.maxSilenceFrames) {
-                                this.isActive = false;
-                            }
-                            // 静音期间也发送几帧，避免语音截断
-                            return this.isActive;
-                        }
+                        this.port.postMessage({
+                            sampleRate: ${AUDIO_CONFIG.sampleRate},
+                            channels: 1,
+                            frames: ch0.length,
+                            buffer: int16Buffer.buffer,
+                            ts: currentTime
+                        });
                     }
                     
-                    // 32位浮点转16位整型
-                    float32ToInt16(floatArray) {
-                        const int16 = new Int16Array(floatArray.length);
-                        for (let i = 0; i < floatArray.length; i++) {
-                            // 将 [-1, 1] 的浮点数映射到 [-32768, 32767] 的整数
-                            const sample = floatArray[i];
-                            int16[i] = sample < -1 ? -32768 : 
-                                    sample > 1 ? 32767 : 
-                                    Math.floor(sample * 32768);
-                        }
-                        return int16;
-                    }
+                    return true;
                 }
                 
-                registerProcessor('audio-compressor-processor', AudioCompressorProcessor);
-            `;
-
-                // 创建 Blob URL 加载 worklet
-                const blob = new Blob([workletCode], { type: 'application/javascript' });
-                const blobURL = URL.createObjectURL(blob);
-                await audioCtx.audioWorklet.addModule(blobURL);
-                URL.revokeObjectURL(blobURL);
-
-                const src = audioCtx.createMediaElementSource(video);
-                const compressor = new AudioWorkletNode(audioCtx, 'audio-compressor-processor');
-
-                src.connect(compressor);
-                compressor.connect(audioCtx.destination);
-
-                const onAudioMessage = (e) => {
-                    if (!this.videoMap.has(video)) return;
-                    if (video.paused) return;
-
-                    const audioData = e.data;
-                    console.log(audioData.buffer);
-                    // 保持原有接口格式，但数据已压缩
-                    window?.__onVideoAudio?.({
-                        sampleRate: audioData.sampleRate,
-                        channels: 1,
-                        frames: audioData.frames,
-                        buffer: audioData.buffer,  // 已经是16位整型
-                        ts: performance.now() / 1000,  // 使用更高精度的时间戳
-                        compressed: true,  // 添加标记表明是压缩数据
-                        bitDepth: AUDIO_CONFIG.bitDepth
-                    });
-                };
-
-                compressor.port.onmessage = onAudioMessage.bind(this);
-
-                // ================== VIDEO ==================
-                const canvas = document.createElement('canvas');
-                const ctx = canvas.getContext('2d');
-                let rafId = null;
-                let lastCaptureTime = 0;
-                const FPS = 1; // 每秒1帧
-                const INTERVAL = 1000 / FPS; // 1000ms
-
-                const captureFrame = () => {
-                    if (!this.videoMap.has(video)) return;
-                    const now = performance.now();
-                    if (now - lastCaptureTime >= INTERVAL) {
-                        if (video.readyState >= 2 && !video.paused) {
-                            const w = video.videoWidth;
-                            const h = video.videoHeight;
-                            if (w && h) {
-                                // 设置目标分辨率（示例：缩小到原尺寸的20%）
-                                const scale = 0.2; // 缩放比例，0.2表示20%
-                                const targetWidth = Math.floor(w * scale);
-                                const targetHeight = Math.floor(h * scale);
-                                // 设置canvas为缩小的尺寸
-                                canvas.width = targetWidth;
-                                canvas.height = targetHeight;
-                                // 绘制并缩小
-                                ctx.drawImage(video, 0, 0, w, h, 0, 0, targetWidth, targetHeight);
-                                // 获取Base64格式的压缩图片
-                                const compressedDataURL = canvas.toDataURL('image/jpeg', 0.8);
-                                console.log(compressedDataURL);
-                                // 移除DataURL前缀，只保留Base64数据
-                                const base64Data = compressedDataURL.split(',')[1];
-                                window?.__onVideoFrame?.({
-                                    width: targetWidth,  // 压缩后的宽度
-                                    height: targetHeight, // 压缩后的高度
-                                    data: base64Data,  // Base64编码的图片数据
-                                    ts: video.currentTime,
-                                    format: 'image/jpeg',
-                                    encoding: 'base64',  // 指定编码格式
-                                    originalWidth: w,  // 原始宽度（可选）
-                                    originalHeight: h,  // 原始高度（可选）
-                                });
-                            }
-                        }
-                        lastCaptureTime = now;
+                // 语音活动检测
+                vadCheck(data) {
+                    if (!${AUDIO_CONFIG.enableVAD}) return true;
+                    
+                    let energy = 0;
+                    // 只检查前100个样本以提升性能
+                    for (let i = 0; i < Math.min(data.length, 100); i++) {
+                        energy += Math.abs(data[i]);
+                        if (energy > this.energyThreshold) break;
                     }
-
-                    rafId = requestAnimationFrame(captureFrame);
-                };
-
-                // 保存状态
-                this.videoMap.set(video, {
-                    audioCtx,
-                    src,
-                    compressor,
-                    onAudioMessage,
-                    canvas,
-                    ctx,
-                    rafId
-                });
-                captureFrame();
-
-            } catch (error) {
-                console.warn('AudioWorklet not supported:', error);
-
-                // 降级处理：暂停音频上下文
-                await audioCtx.suspend();
-
-                // 保存状态但不处理音频
-                const canvas = document.createElement('canvas');
-                const ctx = canvas.getContext('2d');
-                let rafId = null;
-                let lastCaptureTime = 0;
-                const FPS = 1;
-                const INTERVAL = 1000 / FPS;
-
-                const captureFrame = () => {
-                    if (!this.videoMap.has(video)) return;
-                    const now = performance.now();
-                    if (now - lastCaptureTime >= INTERVAL) {
-                        if (video.readyState >= 2 && !video.paused) {
-                            const w = video.videoWidth;
-                            const h = video.videoHeight;
-                            if (w && h) {
-                                const scale = 0.2;
-                                const targetWidth = Math.floor(w * scale);
-                                const targetHeight = Math.floor(h * scale);
-                                canvas.width = targetWidth;
-                                canvas.height = targetHeight;
-                                ctx.drawImage(video, 0, 0, w, h, 0, 0, targetWidth, targetHeight);
-                                const compressedDataURL = canvas.toDataURL('image/jpeg', 0.8);
-                                console.log(compressedDataURL);
-                                const base64Data = compressedDataURL.split(',')[1];
-                                window?.__onVideoFrame?.({
-                                    width: targetWidth,
-                                    height: targetHeight,
-                                    data: base64Data,
-                                    ts: video.currentTime,
-                                    format: 'image/jpeg',
-                                    encoding: 'base64',
-                                    originalWidth: w,
-                                    originalHeight: h,
-                                });
-                            }
+                    
+                    if (energy > this.energyThreshold) {
+                        this.silenceCount = 0;
+                        this.isActive = true;
+                        return true;
+                    } else {
+                        this.silenceCount++;
+                        if (this.silenceCount > this.maxSilenceFrames) {
+                            this.isActive = false;
                         }
-                        lastCaptureTime = now;
+                        // 静音期间也发送几帧，避免语音截断
+                        return this.isActive;
                     }
-                    rafId = requestAnimationFrame(captureFrame);
-                };
-
-                this.videoMap.set(video, {
-                    audioCtx,
-                    src: null,
-                    compressor: null,
-                    onAudioMessage: null,
-                    canvas,
-                    ctx,
-                    rafId
-                });
-                captureFrame();
+                }
+                
+                // 32位浮点转16位整型
+                float32ToInt16(floatArray) {
+                    const int16 = new Int16Array(floatArray.length);
+                    for (let i = 0; i < floatArray.length; i++) {
+                        // 将 [-1, 1] 的浮点数映射到 [-32768, 32767] 的整数
+                        const sample = floatArray[i];
+                        int16[i] = sample < -1 ? -32768 : 
+                                sample > 1 ? 32767 : 
+                                Math.floor(sample * 32768);
+                    }
+                    return int16;
+                }
             }
+            
+            registerProcessor('audio-compressor-processor', AudioCompressorProcessor);
+        `;
+
+            // 创建 Blob URL 加载 worklet
+            const blob = new Blob([workletCode], { type: 'application/javascript' });
+            const blobURL = URL.createObjectURL(blob);
+            await audioCtx.audioWorklet.addModule(blobURL);
+            URL.revokeObjectURL(blobURL);
+
+            // 同一个视频元素只能创建一次音频源，销毁后再次采集时会抛出异常并降级为只采集画面
+            const src = audioCtx.createMediaElementSource(video);
+            const compressor = new AudioWorkletNode(audioCtx, 'audio-compressor-processor');
+
+            src.connect(compressor);
+            compressor.connect(audioCtx.destination);
+
+            compressor.port.onmessage = (e) => this.onAudio(state, e.data);
+            state.src = src;
+            state.compressor = compressor;
         }
 
-        stop(videoEl) {
-            if (!videoEl) return;
-            const state = this.videoMap.get(videoEl);
-            if (!state) return;
-
-            const { audioCtx, rafId } = state;
+        onAudio(state, audioData) {
+            if (!state.running || state.video.paused) return;
+            state.stats.audioChunks++;
+            // 保持原有接口格式，但数据已压缩
+            window?.__onVideoAudio?.({
+                capture: state.id,
+                sampleRate: audioData.sampleRate,
+                channels: 1,
+                frames: audioData.frames,
+                buffer: audioData.buffer,  // 已经是16位整型
+                ts: performance.now() / 1000,  // 使用更高精度的时间戳
+                compressed: true,  // 添加标记表明是压缩数据
+                bitDepth: state.bitDepth
+            });
+        }
 
-            // 停止视频捕获
-            if (rafId) cancelAnimationFrame(rafId);
+        run(state) {
+            state.running = true;
+            const tick = () => {
+                if (!state.running) return;
+                const now = performance.now();
+                if (now - state.lastCaptureTime >= FRAME_INTERVAL) {
+                    state.lastCaptureTime = now;
+                    if (!state.video.paused) this.captureFrame(state);
+                }
+                state.rafId = requestAnimationFrame(tick);
+            };
+            tick();
+        }
 
-            // 暂停音频处理
-            if (audioCtx && audioCtx.state !== 'closed') {
-                audioCtx.suspend();
+        captureFrame(state) {
+            const { video, canvas, ctx, stats } = state;
+            const w = video.videoWidth;
+            const h = video.videoHeight;
+            if (state.pending || video.readyState < 2 || !w || !h) {
+                stats.dropped++;
+                return;
             }
-
-            // 更新状态，移除回调函数
-            state.rafId = null;
-            if (state.compressor && state.onAudioMessage) {
-                state.compressor.port.onmessage = null;
+            const targetWidth = Math.max(1, Math.floor(w * FRAME_SCALE));
+            const targetHeight = Math.max(1, Math.floor(h * FRAME_SCALE));
+            let base64Data;
+            try {
+                canvas.width = targetWidth;
+                canvas.height = targetHeight;
+                ctx.drawImage(video, 0, 0, w, h, 0, 0, targetWidth, targetHeight);
+                // 移除DataURL前缀，只保留Base64数据
+                base64Data = canvas.toDataURL('image/jpeg', 0.8).split(',')[1];
+            } catch (error) {
+                // 视频跨域且没有 CORS 时画布被污染，无法读取像素
+                stats.dropped++;
+                stats.error = String(error);
+                return;
             }
+            stats.frames++;
+            state.pending = true;
+            Promise.resolve(window?.__onVideoFrame?.({
+                capture: state.id,
+                width: targetWidth,  // 压缩后的宽度
+                height: targetHeight, // 压缩后的高度
+                data: base64Data,  // Base64编码的图片数据
+                ts: video.currentTime,
+                format: 'image/jpeg',
+                encoding: 'base64',  // 指定编码格式
+                originalWidth: w,
+                originalHeight: h,
+            })).catch(() => {}).finally(() => { state.pending = false; });
         }
 
-        destroy(videoEl) {
-            if (!videoEl) return;
-            const state = this.videoMap.get(videoEl);
-            if (!state) return;
+        // 暂停截帧和音频处理，可以通过 resume 继续
+        stop(id) {
+            const state = this.captures.get(id);
+            if (!state) return false;
+            state.running = false;
+            if (state.rafId) cancelAnimationFrame(state.rafId);
+            state.rafId = null;
+            if (state.audioCtx.state !== 'closed') state.audioCtx.suspend();
+            return true;
+        }
 
-            const { audioCtx, src, compressor, rafId } = state;
+        resume(id) {
+            const state = this.captures.get(id);
+            if (!state) return false;
+            if (state.running) return true;
+            if (state.audioCtx.state !== 'closed') state.audioCtx.resume();
+            this.run(state);
+            return true;
+        }
 
-            // 停止视频捕获
-            if (rafId) cancelAnimationFrame(rafId);
+        // 停止采集并释放音频上下文和画布
+        destroy(id) {
+            const state = this.captures.get(id);
+            if (!state) return false;
+            this.stop(id);
 
-            // 断开音频连接
+            const { audioCtx, src, compressor, canvas, video } = state;
             if (compressor) {
-                compressor.disconnect();
                 compressor.port.onmessage = null;
+                compressor.disconnect();
             }
-
             if (src) src.disconnect();
+            if (audioCtx.state !== 'closed') audioCtx.close();
+            canvas.width = 0;
+            canvas.height = 0;
 
-            // 关闭音频上下文
-            if (audioCtx && audioCtx.state !== 'closed') {
-                audioCtx.close();
-            }
-
-            // 清理canvas
-            if (state.canvas) {
-                state.canvas.width = 0;
-                state.canvas.height = 0;
-            }
-
-            this.videoMap.delete(videoEl);
+            delete video.__captureId;
+            this.captures.delete(id);
+            return true;
         }
 
         stopAll() {
-            for (const videoEl of this.videoMap.keys()) {
-                this.stop(videoEl);
+            for (const id of this.captures.keys()) {
+                this.stop(id);
             }
         }
 
         destroyAll() {
-            for (const videoEl of Array.from(this.videoMap.keys())) {
-                this.destroy(videoEl);
+            for (const id of Array.from(this.captures.keys())) {
+                this.destroy(id);
             }
         }
-    }
 
+        // 采集的运行状态和计数，采集不存在时返回 null
+        status(id) {
+            const state = this.captures.get(id);
+            if (!state) return null;
+            return Object.assign({ id, running: state.running, connected: state.video.isConnected }, state.stats);
+        }
+
+        list() {
+            return Array.from(this.captures.keys()).map((id) => this.status(id));
+        }
+    }
     window.__MediaCaptureController = new MediaCaptureController();
-})();
\ No newline at end of file
+})();